@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/John-Robertt/AVMC/internal/app/audit"
+	"github.com/John-Robertt/AVMC/internal/app/run"
+	"github.com/John-Robertt/AVMC/internal/config"
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func auditCmd(args []string) int {
+	fix := false
+	rest := make([]string, 0, len(args))
+	for _, a := range args {
+		if isHelp(a) {
+			printAuditUsage()
+			return 0
+		}
+		if a == "--fix" {
+			fix = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+
+	// 其余参数与 run 完全一致（path/provider/apply），复用同一套解析与配置合并规则。
+	ra, err := parseRunArgs(rest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "参数错误：%v\n\n", err)
+		printAuditUsage()
+		return 2
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取当前目录失败：%v\n", err)
+		return 1
+	}
+
+	eff, err := config.LoadEffective(cwd, config.CLIArgs{
+		Path:        ra.Path,
+		Provider:    ra.Provider,
+		ProviderSet: ra.ProviderSet,
+		Apply:       ra.Apply,
+		ApplySet:    ra.ApplySet,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	rep, err := audit.ScanWithOptions(eff.Path, audit.Options{VideoExts: eff.VideoExts, Code: eff.Code})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "检查 out/ 失败：%v\n", err)
+		return 1
+	}
+
+	var restored []string
+	if fix {
+		reg, err := newRegistry(eff)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "初始化 provider registry 失败：%v\n", err)
+			return 1
+		}
+
+		// 先按“移走坏文件前”的报告生成计划；apply 才真正移走（只限有计划的目录），dry-run 只预演。
+		// 坏文件先改名留作备份：新文件写入后才删除备份，没有补齐的坏文件原样移回。
+		plans := run.ApplyOverrides(eff.Overrides, audit.PlanFixes(eff.Provider, rep))
+		var bk *audit.Backup
+		if eff.Apply {
+			bk, err = audit.Cleanup(eff.Path, rep, plans)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "清理坏文件失败：%v\n", err)
+				if _, ferr := bk.Finish(); ferr != nil {
+					fmt.Fprintf(os.Stderr, "恢复坏文件失败：%v\n", ferr)
+				}
+				return 1
+			}
+		}
+
+		fr := run.ExecutePlans(context.Background(), eff, reg, plans, nil)
+		rep.Fix = &fr
+
+		restored, err = bk.Finish()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "恢复坏文件失败：%v\n", err)
+			return 1
+		}
+	}
+
+	emitAuditReport(rep)
+	for _, f := range restored {
+		fmt.Fprintf(os.Stderr, "%s: 未能补齐，已恢复原文件\n", f)
+	}
+	if len(restored) > 0 {
+		return 1
+	}
+	return auditExitCode(rep, eff.Apply)
+}
+
+// auditExitCode：无问题 => 0；--fix --apply 且只剩可修复问题并全部修复成功 => 0；否则 1。
+func auditExitCode(rep domain.AuditReport, applied bool) int {
+	if rep.Summary.Issues == 0 {
+		return 0
+	}
+	if rep.Fix != nil && applied && rep.Summary.Issues == rep.Summary.Repairable && rep.Fix.Summary.Failed == 0 {
+		return 0
+	}
+	return 1
+}
+
+func emitAuditReport(rep domain.AuditReport) {
+	if isTTY(os.Stdout) {
+		fmt.Fprintf(os.Stdout, "完成：dirs=%d ok=%d with_issues=%d issues=%d repairable=%d\n",
+			rep.Summary.Dirs, rep.Summary.OK, rep.Summary.WithIssues, rep.Summary.Issues, rep.Summary.Repairable,
+		)
+		for _, it := range rep.Items {
+			for _, is := range it.Issues {
+				fmt.Fprintf(os.Stderr, "%s %s: %s\n", is.File, is.Kind, is.Msg)
+			}
+		}
+		if rep.Fix != nil {
+			fmt.Fprintf(os.Stdout, "修复：processed=%d skipped=%d failed=%d\n",
+				rep.Fix.Summary.Processed, rep.Fix.Summary.Skipped, rep.Fix.Summary.Failed,
+			)
+		}
+		return
+	}
+
+	// stdout 非 TTY：与 run 相同，只输出一个 JSON（摘要走 stderr）。
+	enc := json.NewEncoder(os.Stdout)
+	_ = enc.Encode(rep)
+	fmt.Fprintf(os.Stderr, "完成：dirs=%d ok=%d with_issues=%d issues=%d repairable=%d\n",
+		rep.Summary.Dirs, rep.Summary.OK, rep.Summary.WithIssues, rep.Summary.Issues, rep.Summary.Repairable,
+	)
+}
+
+func printAuditUsage() {
+	fmt.Fprint(os.Stdout, `用法：
+  avmc audit [path] [--fix] [--provider javbus|javdb] [--apply[=true|false]]
+
+检查 <path>/out/ 下每个 CODE 目录：缺失 NFO/poster/fanart、NFO 无法解析或 <num> 与目录不一致、
+0 字节或无法解码的图片（含 thumb/landscape、extrafanart/、.actors/）、没有视频的目录、原子写残留的临时文件。
+
+参数：
+  --fix       对可修复问题重新规划并补齐 sidecar（不移动任何视频）；默认 dry-run，需配合 --apply 落盘
+  --provider  补齐时的首选 provider：javbus|javdb
+  --apply     --fix 时真正替换坏文件并写入 sidecar（坏文件在新文件写入后才删除，补齐失败时恢复）
+  -h, --help  显示帮助
+`)
+}
@@ -31,6 +31,22 @@ func main() {
 		if code := runCmd(args[1:]); code != 0 {
 			os.Exit(code)
 		}
+	case "audit":
+		if code := auditCmd(args[1:]); code != 0 {
+			os.Exit(code)
+		}
+	case "plan":
+		if code := planCmd(args[1:]); code != 0 {
+			os.Exit(code)
+		}
+	case "apply":
+		if code := applyCmd(args[1:]); code != 0 {
+			os.Exit(code)
+		}
+	case "nfo":
+		if code := nfoCmd(args[1:]); code != 0 {
+			os.Exit(code)
+		}
 	default:
 		fmt.Fprintf(os.Stderr, "未知命令：%q\n\n", args[0])
 		printUsage()
@@ -73,10 +89,7 @@ func runCmd(args []string) int {
 		return 1
 	}
 
-	reg, e := provider.NewRegistry(
-		javbus.Provider{},
-		javdb.Provider{BaseURL: eff.JavDBBaseURL},
-	)
+	reg, e := newRegistry(eff)
 	if e != nil {
 		fmt.Fprintf(os.Stderr, "初始化 provider registry 失败：%v\n", e)
 		return 1
@@ -109,6 +122,13 @@ func runCmd(args []string) int {
 	return 1
 }
 
+func newRegistry(eff config.EffectiveConfig) (provider.Registry, error) {
+	return provider.NewRegistry(
+		javbus.Provider{},
+		javdb.Provider{BaseURL: eff.JavDBBaseURL},
+	)
+}
+
 type runArgs struct {
 	Path        string
 	Provider    string
@@ -178,11 +198,19 @@ func isHelp(s string) bool {
 func printUsage() {
 	fmt.Fprint(os.Stdout, `用法：
   avmc run [path] [--provider javbus|javdb] [--apply[=true|false]]
+  avmc audit [path] [--fix] [--provider javbus|javdb] [--apply[=true|false]]
+  avmc plan [path] --out plan.json [--provider javbus|javdb]
+  avmc apply plan.json
+  avmc nfo validate <file.nfo>...
 
 命令：
   run    运行流程（默认 dry-run）
+  audit  检查 out/ 一致性（可选 --fix 补齐可修复问题）
+  plan   预演并把计划写入文件（可审阅/编辑后再执行）
+  apply  严格执行 plan 文件（源文件变化的条目会被拒绝）
+  nfo    NFO 工具：validate 检查 NFO 结构问题
 
-使用 "avmc run --help" 查看详细说明。
+使用 "avmc <命令> --help" 查看详细说明。
 `)
 }
 
@@ -199,9 +227,7 @@ func printRunUsage() {
 
 func emitReport(rr domain.RunReport) {
 	if isTTY(os.Stdout) {
-		fmt.Fprintf(os.Stdout, "完成：processed=%d skipped=%d failed=%d unmatched=%d\n",
-			rr.Summary.Processed, rr.Summary.Skipped, rr.Summary.Failed, rr.Summary.Unmatched,
-		)
+		fmt.Fprintln(os.Stdout, summaryLine(rr.Summary))
 		if rr.Summary.Failed > 0 || rr.Summary.Unmatched > 0 {
 			for _, it := range rr.Items {
 				if it.Status != domain.StatusFailed && it.Status != domain.StatusUnmatched {
@@ -224,9 +250,18 @@ func emitReport(rr domain.RunReport) {
 	// stdout 非 TTY：stdout 必须且仅输出一个 RunReport JSON（日志/摘要走 stderr）。
 	enc := json.NewEncoder(os.Stdout)
 	_ = enc.Encode(rr)
-	fmt.Fprintf(os.Stderr, "完成：processed=%d skipped=%d failed=%d unmatched=%d\n",
-		rr.Summary.Processed, rr.Summary.Skipped, rr.Summary.Failed, rr.Summary.Unmatched,
+	fmt.Fprintln(os.Stderr, summaryLine(rr.Summary))
+}
+
+// summaryLine 是人类可读的一行摘要；fetches_saved 只在有合并请求时追加。
+func summaryLine(s domain.ReportSummary) string {
+	line := fmt.Sprintf("完成：processed=%d skipped=%d failed=%d unmatched=%d",
+		s.Processed, s.Skipped, s.Failed, s.Unmatched,
 	)
+	if s.FetchesSaved > 0 {
+		line += fmt.Sprintf(" fetches_saved=%d", s.FetchesSaved)
+	}
+	return line
 }
 
 func reportForConfigError(cwdAbs string, ra runArgs, err error) domain.RunReport {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/John-Robertt/AVMC/internal/nfo"
+)
+
+// nfoFileResult 是 nfo validate 对单个文件的结论（非 TTY 时以 JSON 数组输出）。
+type nfoFileResult struct {
+	File     string        `json:"file"`
+	Problems []nfo.Problem `json:"problems"`
+}
+
+func nfoCmd(args []string) int {
+	if len(args) == 0 || isHelp(args[0]) {
+		printNFOUsage()
+		return 0
+	}
+	if args[0] != "validate" {
+		fmt.Fprintf(os.Stderr, "未知的 nfo 子命令：%q\n\n", args[0])
+		printNFOUsage()
+		return 2
+	}
+
+	files := args[1:]
+	for _, a := range files {
+		if isHelp(a) {
+			printNFOUsage()
+			return 0
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "参数错误：至少需要一个 NFO 文件\n\n")
+		printNFOUsage()
+		return 2
+	}
+
+	results := make([]nfoFileResult, 0, len(files))
+	bad := 0
+	for _, f := range files {
+		r := nfoFileResult{File: f, Problems: []nfo.Problem{}}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			r.Problems = append(r.Problems, nfo.Problem{Msg: fmt.Sprintf("读取失败：%v", err)})
+		} else if ps := nfo.Validate(b); ps != nil {
+			r.Problems = ps
+		}
+		if len(r.Problems) > 0 {
+			bad++
+		}
+		results = append(results, r)
+	}
+
+	if isTTY(os.Stdout) {
+		for _, r := range results {
+			for _, p := range r.Problems {
+				field := p.Field
+				if field == "" {
+					field = "-"
+				}
+				fmt.Fprintf(os.Stderr, "%s %s: %s\n", r.File, field, p.Msg)
+			}
+		}
+		fmt.Fprintf(os.Stdout, "完成：files=%d invalid=%d\n", len(results), bad)
+	} else {
+		// stdout 非 TTY：与 run/audit 相同，只输出一个 JSON（摘要走 stderr）。
+		_ = json.NewEncoder(os.Stdout).Encode(results)
+		fmt.Fprintf(os.Stderr, "完成：files=%d invalid=%d\n", len(results), bad)
+	}
+	if bad > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printNFOUsage() {
+	fmt.Fprint(os.Stdout, `用法：
+  avmc nfo validate <file.nfo>...
+
+检查 NFO 的结构问题：不是合法 XML 或根元素不是 <movie>、缺少 <num> 或不是合法 CODE、缺少 <title>、
+日期不是 YYYY-MM-DD、<year> 与发行日期不一致、runtime/rating/votes 不合法、<actor> 缺少 <name>。
+任一文件有问题时退出码为 1。
+
+参数：
+  -h, --help  显示帮助
+`)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/app/run"
+	"github.com/John-Robertt/AVMC/internal/config"
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/fsx"
+)
+
+func planCmd(args []string) int {
+	outPath := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case isHelp(a):
+			printPlanUsage()
+			return 0
+		case a == "--out":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "参数错误：--out 需要一个值\n\n")
+				printPlanUsage()
+				return 2
+			}
+			i++
+			outPath = args[i]
+		case strings.HasPrefix(a, "--out="):
+			outPath = strings.TrimPrefix(a, "--out=")
+		default:
+			rest = append(rest, a)
+		}
+	}
+
+	// 其余参数与 run 一致（path/provider）；plan 始终是 dry-run。
+	ra, err := parseRunArgs(rest)
+	if err == nil && ra.ApplySet && ra.Apply {
+		err = fmt.Errorf("plan 始终为 dry-run；请用 avmc apply <plan.json> 执行计划")
+	}
+	if err == nil && outPath == "" {
+		err = fmt.Errorf("缺少 --out")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "参数错误：%v\n\n", err)
+		printPlanUsage()
+		return 2
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取当前目录失败：%v\n", err)
+		return 1
+	}
+	cwdAbs, _ := filepath.Abs(cwd)
+
+	eff, err := config.LoadEffective(cwd, config.CLIArgs{
+		Path:        ra.Path,
+		Provider:    ra.Provider,
+		ProviderSet: ra.ProviderSet,
+		Apply:       false,
+		ApplySet:    true,
+	})
+	if err != nil {
+		emitReport(reportForConfigError(cwdAbs, ra, err))
+		return 1
+	}
+
+	reg, err := newRegistry(eff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化 provider registry 失败：%v\n", err)
+		return 1
+	}
+
+	progressW, interactive := pickProgressWriter()
+	var obs run.Observer
+	if interactive {
+		obs = newProgressUI(progressW)
+	}
+
+	pf, rr := run.Plan(context.Background(), eff, reg, obs)
+	if err := writePlanFile(outPath, pf); err != nil {
+		fmt.Fprintf(os.Stderr, "写入 plan 文件失败：%v\n", err)
+		emitReport(rr)
+		return 1
+	}
+
+	emitReport(rr)
+	if interactive {
+		fmt.Fprintf(progressW, "plan: %s（items=%d）\n", outPath, len(pf.Items))
+	}
+	if rr.Summary.Failed == 0 && rr.Summary.Unmatched == 0 {
+		return 0
+	}
+	return 1
+}
+
+func applyCmd(args []string) int {
+	planPath := ""
+	for _, a := range args {
+		switch {
+		case isHelp(a):
+			printApplyUsage()
+			return 0
+		case strings.HasPrefix(a, "-"):
+			fmt.Fprintf(os.Stderr, "参数错误：未知参数 %q\n\n", a)
+			printApplyUsage()
+			return 2
+		case planPath != "":
+			fmt.Fprintf(os.Stderr, "参数错误：重复的 plan 文件：%q 与 %q\n\n", planPath, a)
+			printApplyUsage()
+			return 2
+		default:
+			planPath = a
+		}
+	}
+	if planPath == "" {
+		fmt.Fprintf(os.Stderr, "参数错误：缺少 plan 文件\n\n")
+		printApplyUsage()
+		return 2
+	}
+
+	pf, err := readPlanFile(planPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取 plan 文件失败：%v\n", err)
+		return 1
+	}
+	if pf.Path == "" {
+		fmt.Fprintf(os.Stderr, "plan 文件缺少 path：%s\n", planPath)
+		return 1
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取当前目录失败：%v\n", err)
+		return 1
+	}
+
+	// 配置（并发/代理/图片参数等）按 plan 记录的 path 读取 <path>/avmc.json。
+	eff, err := config.LoadEffective(cwd, config.CLIArgs{Path: pf.Path, Apply: true, ApplySet: true})
+	if err != nil {
+		emitReport(reportForConfigError(pf.Path, runArgs{Path: pf.Path, Apply: true, ApplySet: true}, err))
+		return 1
+	}
+
+	reg, err := newRegistry(eff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化 provider registry 失败：%v\n", err)
+		return 1
+	}
+
+	progressW, interactive := pickProgressWriter()
+	var obs run.Observer
+	if interactive {
+		obs = newProgressUI(progressW)
+	}
+
+	rr := run.ApplyPlan(context.Background(), eff, reg, pf, obs)
+	if err := writeReportFile(eff.Path, rr); err != nil {
+		fmt.Fprintf(os.Stderr, "写入 report.json 失败：%v\n", err)
+		emitReport(rr)
+		return 1
+	}
+
+	emitReport(rr)
+	if interactive {
+		emitLocations(progressW, eff)
+	}
+	if rr.Summary.Failed == 0 && rr.Summary.Unmatched == 0 {
+		return 0
+	}
+	return 1
+}
+
+func writePlanFile(p string, pf domain.PlanFile) error {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(pf, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	return fsx.WriteFileAtomicReplace(filepath.Dir(abs), filepath.Base(abs), b)
+}
+
+func readPlanFile(p string) (domain.PlanFile, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return domain.PlanFile{}, err
+	}
+	var pf domain.PlanFile
+	if err := json.Unmarshal(b, &pf); err != nil {
+		return domain.PlanFile{}, fmt.Errorf("JSON 无效：%w", err)
+	}
+	return pf, nil
+}
+
+func printPlanUsage() {
+	fmt.Fprint(os.Stdout, `用法：
+  avmc plan [path] --out plan.json [--provider javbus|javdb]
+
+以 dry-run 方式完成扫描、规划与刮削，把可执行的计划（含已解析的元数据与源文件 size/mtime 指纹）
+写入 plan 文件，供审阅后用 avmc apply 原样执行。stdout 输出与 run 相同的 dry-run 报告。
+
+参数：
+  --out       plan 文件输出路径（必填）
+  --provider  首选 provider：javbus|javdb
+  -h, --help  显示帮助
+`)
+}
+
+func printApplyUsage() {
+	fmt.Fprint(os.Stdout, `用法：
+  avmc apply plan.json
+
+严格执行 avmc plan 生成的计划：不重新扫描、规划或刮削。源文件 size/mtime 与规划时不一致
+（或已不存在）的条目会被拒绝（source_changed），目标已存在的条目报 target_conflict；其余条目照常执行。
+配置读取 plan 文件中 path 下的 avmc.json；报告写入 <path>/cache/report.json。
+
+参数：
+  -h, --help  显示帮助
+`)
+}
@@ -79,6 +79,9 @@ func (p *progressUI) OnStart(eff config.EffectiveConfig) {
 		fmt.Fprintf(p.w, "  javdb_base_url: %s\n", truncate(eff.JavDBBaseURL, 120))
 	}
 	fmt.Fprintf(p.w, "  exclude_dirs: %s + 固定排除 out/, cache/\n", formatStringListJSON(eff.ExcludeDirs))
+	if eff.FillOut {
+		fmt.Fprintln(p.w, "  fill_out: on（补齐已有 out/<CODE>/ 的 sidecar）")
+	}
 
 	fmt.Fprintln(p.w, "输出:")
 	fmt.Fprintf(p.w, "  out: %s\n", filepath.Join(eff.Path, "out"))
@@ -98,10 +101,22 @@ func (p *progressUI) OnPhaseDone(name string, fields map[string]any, dur time.Du
 
 	switch name {
 	case "scan":
+		if n := intField(fields, "skipped"); n > 0 {
+			fmt.Fprintf(p.w, "扫描: files=%d unmatched=%d skipped=%d (%s)\n",
+				intField(fields, "files"), intField(fields, "unmatched"), n, formatShortDuration(dur),
+			)
+			break
+		}
 		fmt.Fprintf(p.w, "扫描: files=%d unmatched=%d (%s)\n",
 			intField(fields, "files"), intField(fields, "unmatched"), formatShortDuration(dur),
 		)
 	case "group":
+		if n := intField(fields, "out_codes"); n > 0 {
+			fmt.Fprintf(p.w, "分组: codes=%d out_codes=%d (%s)\n",
+				intField(fields, "codes"), n, formatShortDuration(dur),
+			)
+			break
+		}
 		fmt.Fprintf(p.w, "分组: codes=%d (%s)\n",
 			intField(fields, "codes"), formatShortDuration(dur),
 		)
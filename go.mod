@@ -2,7 +2,10 @@ module github.com/John-Robertt/AVMC
 
 go 1.22
 
-require github.com/PuerkitoBio/goquery v1.9.2
+require (
+	github.com/PuerkitoBio/goquery v1.9.2
+	golang.org/x/image v0.18.0
+)
 
 require (
 	github.com/andybalholm/cascadia v1.3.3 // indirect
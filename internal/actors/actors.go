@@ -0,0 +1,152 @@
+package actors
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// Aliases 是别名查找表：key 为规范化后的写法（去空白、小写），value 为规范名。
+// 规范名本身也会收录，便于把大小写/空白不同的写法统一。
+type Aliases map[string]string
+
+// NewAliases 由 {"规范名": ["别名", ...]} 构造 Aliases；同一写法指向不同规范名时报错。
+func NewAliases(raw map[string][]string) (Aliases, error) {
+	return Aliases(nil).Merge(raw)
+}
+
+// Merge 返回合并了 raw 的新 Aliases；raw 中出现的规范名会先移除 a 中指向它的旧条目，
+// 即同一规范名以 raw 为准（例如 avmc.json 内联表覆盖别名文件）。
+func (a Aliases) Merge(raw map[string][]string) (Aliases, error) {
+	if len(a) == 0 && len(raw) == 0 {
+		return nil, nil
+	}
+	out := make(Aliases, len(a)+len(raw))
+	for k, v := range a {
+		if _, redefined := raw[v]; !redefined {
+			out[k] = v
+		}
+	}
+	added := make(map[string]string, len(raw))
+	for canon, names := range raw {
+		canon = strings.TrimSpace(canon)
+		if canon == "" {
+			return nil, fmt.Errorf("规范名不能为空")
+		}
+		for _, n := range append([]string{canon}, names...) {
+			key := nameKey(n)
+			if key == "" {
+				continue
+			}
+			if prev, ok := added[key]; ok && prev != canon {
+				return nil, fmt.Errorf("别名 %q 同时指向 %q 和 %q", strings.TrimSpace(n), prev, canon)
+			}
+			added[key] = canon
+			out[key] = canon
+		}
+	}
+	return out, nil
+}
+
+// LoadAliasFile 读取别名文件（JSON 对象 {"规范名": ["别名", ...]}）。
+func LoadAliasFile(path string) (map[string][]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取别名文件失败：%w", err)
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("别名文件不是有效的 JSON 对象：%w", err)
+	}
+	return raw, nil
+}
+
+// Lookup 返回 name 对应的规范名；未收录时 ok=false。
+func (a Aliases) Lookup(name string) (string, bool) {
+	v, ok := a[nameKey(name)]
+	return v, ok
+}
+
+// Options 是演员名规范化规则。零值表示不做任何改写（与引入别名表前一致）。
+type Options struct {
+	Aliases Aliases
+	// StripParens 为 true 时剥离名字末尾的括号别名，例如 "三上悠亜（鬼頭桃菜）" => "三上悠亜"；
+	// 括号内的名字仍参与别名表查找。
+	StripParens bool
+}
+
+// parenRE 匹配末尾的一组全角/半角括号：分组 1 为主名，分组 2 为括号内的别名。
+var parenRE = regexp.MustCompile(`^(.*?)\s*[（(]([^（）()]*)[）)]\s*$`)
+
+// Normalize 按规则改写演员名（保持顺序、去空白、去重），并返回实际发生的改名。
+// 查找顺序：完整名字 → 括号外主名 → 括号内别名（后两者仅在 StripParens 时生效）。
+// 改写后重名的演员合并为一条：保留第一条，缺失的头像/演员页由后续条目补齐。
+func Normalize(in []domain.Actor, opt Options) ([]domain.Actor, []domain.ActorRename) {
+	if len(in) == 0 {
+		return in, nil
+	}
+	out := make([]domain.Actor, 0, len(in))
+	seen := make(map[string]int, len(in))
+	var renames []domain.ActorRename
+	for _, a := range in {
+		n := strings.TrimSpace(a.Name)
+		if n == "" {
+			continue
+		}
+		to := opt.canonical(n)
+		if to != n {
+			renames = append(renames, domain.ActorRename{From: n, To: to})
+		}
+		if i, ok := seen[to]; ok {
+			if out[i].Thumb == "" {
+				out[i].Thumb = a.Thumb
+			}
+			if out[i].URL == "" {
+				out[i].URL = a.URL
+			}
+			continue
+		}
+		seen[to] = len(out)
+		a.Name = to
+		out = append(out, a)
+	}
+	return out, renames
+}
+
+func (o Options) canonical(n string) string {
+	if v, ok := o.Aliases.Lookup(n); ok {
+		return v
+	}
+	if !o.StripParens {
+		return n
+	}
+	m := parenRE.FindStringSubmatch(n)
+	if m == nil || strings.TrimSpace(m[1]) == "" {
+		return n
+	}
+	base := strings.TrimSpace(m[1])
+	if v, ok := o.Aliases.Lookup(base); ok {
+		return v
+	}
+	for _, alt := range strings.FieldsFunc(m[2], isAliasSep) {
+		if v, ok := o.Aliases.Lookup(alt); ok {
+			return v
+		}
+	}
+	return base
+}
+
+func isAliasSep(r rune) bool {
+	switch r {
+	case '、', ',', '，', '/', '／':
+		return true
+	default:
+		return false
+	}
+}
+
+func nameKey(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
@@ -0,0 +1,108 @@
+package actors
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func TestNormalize_ZeroOptionsKeepsNames(t *testing.T) {
+	in := named(" 三上悠亜（鬼頭桃菜） ", "A", "A", "")
+	got, renames := Normalize(in, Options{})
+	if want := []string{"三上悠亜（鬼頭桃菜）", "A"}; !reflect.DeepEqual(domain.ActorNames(got), want) {
+		t.Fatalf("期望 %v，实际 %v", want, got)
+	}
+	if len(renames) != 0 {
+		t.Fatalf("零值规则不应改名，实际 %v", renames)
+	}
+}
+
+func TestNormalize_AliasesAndParens(t *testing.T) {
+	a, err := NewAliases(map[string][]string{
+		"三上悠亜": {"鬼頭桃菜", "Yua Mikami"},
+		"河北彩花": {"河北彩伽"},
+	})
+	if err != nil {
+		t.Fatalf("NewAliases 失败：%v", err)
+	}
+	opt := Options{Aliases: a, StripParens: true}
+
+	in := named(
+		"yua  mikami", // 大小写/空白不敏感
+		"三上悠亜（鬼頭桃菜）",  // 主名命中
+		"某人(河北彩伽)",    // 括号内别名命中
+		"新人（別名A、別名B）", // 未收录：只剥离括号
+		"（仅括号）",       // 主名为空：保持原样
+		"河北彩花",        // 规范名：不算改名
+	)
+	got, renames := Normalize(in, opt)
+	if want := []string{"三上悠亜", "河北彩花", "新人", "（仅括号）"}; !reflect.DeepEqual(domain.ActorNames(got), want) {
+		t.Fatalf("期望 %v，实际 %v", want, got)
+	}
+	wantRenames := []domain.ActorRename{
+		{From: "yua  mikami", To: "三上悠亜"},
+		{From: "三上悠亜（鬼頭桃菜）", To: "三上悠亜"},
+		{From: "某人(河北彩伽)", To: "河北彩花"},
+		{From: "新人（別名A、別名B）", To: "新人"},
+	}
+	if !reflect.DeepEqual(renames, wantRenames) {
+		t.Fatalf("改名记录：期望 %v，实际 %v", wantRenames, renames)
+	}
+
+	// 不剥离括号时，带括号的完整写法只在别名表里收录才改写。
+	got, _ = Normalize(named("三上悠亜（鬼頭桃菜）"), Options{Aliases: a})
+	if want := []string{"三上悠亜（鬼頭桃菜）"}; !reflect.DeepEqual(domain.ActorNames(got), want) {
+		t.Fatalf("StripParens=false：期望 %v，实际 %v", want, got)
+	}
+}
+
+func TestNormalize_MergesDuplicateDetails(t *testing.T) {
+	a, err := NewAliases(map[string][]string{"A": {"a2"}})
+	if err != nil {
+		t.Fatalf("NewAliases 失败：%v", err)
+	}
+	got, _ := Normalize([]domain.Actor{
+		{Name: "A", URL: "https://x/star/a"},
+		{Name: "a2", Thumb: "https://x/a.jpg", URL: "https://x/star/a2"},
+	}, Options{Aliases: a})
+	want := []domain.Actor{{Name: "A", Thumb: "https://x/a.jpg", URL: "https://x/star/a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("期望 %+v，实际 %+v", want, got)
+	}
+}
+
+func named(names ...string) []domain.Actor {
+	out := make([]domain.Actor, 0, len(names))
+	for _, n := range names {
+		out = append(out, domain.Actor{Name: n})
+	}
+	return out
+}
+
+func TestAliases_MergeAndConflict(t *testing.T) {
+	if _, err := NewAliases(map[string][]string{"A": {"x"}, "B": {"X"}}); err == nil {
+		t.Fatalf("同一别名指向两个规范名应报错")
+	}
+	if _, err := NewAliases(map[string][]string{" ": {"x"}}); err == nil {
+		t.Fatalf("空规范名应报错")
+	}
+
+	base, err := NewAliases(map[string][]string{"A": {"old"}, "C": {"c2"}})
+	if err != nil {
+		t.Fatalf("NewAliases 失败：%v", err)
+	}
+	merged, err := base.Merge(map[string][]string{"A": {"new"}})
+	if err != nil {
+		t.Fatalf("Merge 失败：%v", err)
+	}
+	if _, ok := merged.Lookup("old"); ok {
+		t.Fatalf("内联重新定义的规范名应替换文件中的别名")
+	}
+	if v, ok := merged.Lookup("new"); !ok || v != "A" {
+		t.Fatalf("期望 new => A，实际 %q %v", v, ok)
+	}
+	if v, ok := merged.Lookup("C2"); !ok || v != "C" {
+		t.Fatalf("未重新定义的条目应保留，实际 %q %v", v, ok)
+	}
+}
@@ -0,0 +1,2 @@
+// Package actors 负责演员名的规范化：别名表（同一演员的不同写法归并为一个名字）与括号别名剥离。
+package actors
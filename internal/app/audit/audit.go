@@ -0,0 +1,436 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/John-Robertt/AVMC/internal/app/planner"
+	"github.com/John-Robertt/AVMC/internal/code"
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/imgx"
+	"github.com/John-Robertt/AVMC/internal/nfo"
+	"github.com/John-Robertt/AVMC/internal/scan"
+)
+
+// Options 让 audit 与 run 使用同一套扫描/规范化规则。零值 = 默认规则。
+type Options struct {
+	// VideoExts 与 scan.video_exts 一致（小写、含 '.'）；为空时使用 scan.DefaultVideoExts。
+	VideoExts []string
+	// Code 用于识别非规范目录名（例如 ABP-01，应为 ABP-001）。
+	Code code.Normalizer
+}
+
+// Scan 以默认规则检查 <root>/out/（见 ScanWithOptions）。
+func Scan(root string) (domain.AuditReport, error) {
+	return ScanWithOptions(root, Options{Code: code.DefaultNormalizer()})
+}
+
+// ScanWithOptions 遍历 <root>/out/，逐个条目检查 sidecar / NFO / 图片 / 视频 / 残留临时文件。
+//
+// 约束：只读（ReadDir + ReadFile），不做任何修改；out/ 不存在时返回空报告。
+func ScanWithOptions(root string, opt Options) (domain.AuditReport, error) {
+	videoExts := opt.VideoExts
+	if len(videoExts) == 0 {
+		videoExts = scan.DefaultVideoExts
+	}
+
+	rep := domain.AuditReport{
+		Path:      root,
+		StartedAt: time.Now().UTC(),
+		Items:     make([]domain.AuditItem, 0, 128),
+	}
+
+	outDir := filepath.Join(root, "out")
+	entries, err := os.ReadDir(outDir)
+	if err != nil && !os.IsNotExist(err) {
+		return domain.AuditReport{}, err
+	}
+
+	for _, e := range entries {
+		rel := filepath.Join("out", e.Name())
+		if e.IsDir() && e.Name() == domain.ActorsDir {
+			continue // actors.portraits=library 的默认头像目录
+		}
+		if !e.IsDir() {
+			rep.Items = append(rep.Items, domain.AuditItem{
+				Dir: rel,
+				Issues: []domain.AuditIssue{{
+					Kind: domain.AuditNotDir,
+					File: rel,
+					Msg:  "out/ 下只应包含 <CODE>/ 目录；该文件会导致同名 CODE 的 target_conflict",
+				}},
+			})
+			continue
+		}
+
+		c, ok := code.ParseOutDirName(e.Name())
+		if !ok {
+			rep.Items = append(rep.Items, domain.AuditItem{
+				Dir: rel,
+				Issues: []domain.AuditIssue{{
+					Kind: domain.AuditNotCodeDir,
+					File: rel,
+					Msg:  "目录名不是合法 CODE；请重命名或移出 out/",
+				}},
+			})
+			continue
+		}
+
+		issues, err := checkDir(filepath.Join(outDir, e.Name()), rel, c, videoExts)
+		if err != nil {
+			return domain.AuditReport{}, err
+		}
+		if canon := opt.Code.Canonical(c); canon != c {
+			issues = append(issues, domain.AuditIssue{
+				Kind: domain.AuditNonCanonicalDir,
+				File: rel,
+				Msg:  fmt.Sprintf("目录名不是规范 CODE（应为 %s）；run 仍会复用该目录，建议重命名", canon),
+			})
+		}
+		rep.Items = append(rep.Items, domain.AuditItem{
+			Code:   string(c),
+			Dir:    rel,
+			Issues: issues,
+		})
+	}
+
+	rep.FinishedAt = time.Now().UTC()
+	rep.Finalize()
+	return rep, nil
+}
+
+func checkDir(dir, rel string, code domain.Code, videoExts []string) ([]domain.AuditIssue, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]os.DirEntry, len(entries))
+	issues := make([]domain.AuditIssue, 0, 4)
+	hasVideo := false
+	for _, e := range entries {
+		names[e.Name()] = e
+		if e.IsDir() {
+			continue
+		}
+		if isTempName(e.Name()) {
+			issues = append(issues, domain.AuditIssue{
+				Kind:       domain.AuditStrayTemp,
+				File:       filepath.Join(rel, e.Name()),
+				Repairable: true,
+				Msg:        "原子写残留的临时文件（上次写入被中断）",
+			})
+			continue
+		}
+		if scan.HasExt(videoExts, strings.ToLower(filepath.Ext(e.Name()))) {
+			hasVideo = true
+		}
+	}
+	if !hasVideo {
+		issues = append(issues, domain.AuditIssue{
+			Kind: domain.AuditNoVideo,
+			File: rel,
+			Msg:  "目录内没有视频文件",
+		})
+	}
+
+	nfoName := string(code) + ".nfo"
+	if _, ok := names[nfoName]; !ok {
+		issues = append(issues, domain.AuditIssue{
+			Kind:       domain.AuditMissingNFO,
+			File:       filepath.Join(rel, nfoName),
+			Repairable: true,
+			Msg:        "缺少 NFO",
+		})
+	} else if is, ok := checkNFO(filepath.Join(dir, nfoName), filepath.Join(rel, nfoName), code); !ok {
+		issues = append(issues, is)
+	}
+
+	for _, img := range []struct {
+		name    string
+		missing string
+	}{
+		{"poster.jpg", domain.AuditMissingPoster},
+		{"fanart.jpg", domain.AuditMissingFanart},
+	} {
+		if _, ok := names[img.name]; !ok {
+			issues = append(issues, domain.AuditIssue{
+				Kind:       img.missing,
+				File:       filepath.Join(rel, img.name),
+				Repairable: true,
+				Msg:        "缺少 " + img.name,
+			})
+			continue
+		}
+		if is, ok := checkImage(filepath.Join(dir, img.name), filepath.Join(rel, img.name)); !ok {
+			issues = append(issues, is)
+		}
+	}
+
+	// 可选 artwork 是否存在取决于配置，缺失不算问题；但已有的文件必须是有效图片。
+	for _, name := range []string{"thumb.jpg", "landscape.jpg"} {
+		if _, ok := names[name]; !ok {
+			continue
+		}
+		if is, ok := checkImage(filepath.Join(dir, name), filepath.Join(rel, name)); !ok {
+			issues = append(issues, is)
+		}
+	}
+	for _, sub := range []struct {
+		name  string
+		match func(string) bool
+	}{
+		{domain.ExtrafanartDir, domain.IsExtrafanartName},
+		{domain.ActorsDir, func(name string) bool { return strings.EqualFold(filepath.Ext(name), ".jpg") }},
+	} {
+		if e, ok := names[sub.name]; !ok || !e.IsDir() {
+			continue
+		}
+		is, err := checkArtDir(filepath.Join(dir, sub.name), filepath.Join(rel, sub.name), sub.match)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, is...)
+	}
+
+	return issues, nil
+}
+
+// checkArtDir 检查 extrafanart/、.actors/ 这类图片子目录：match 命中的文件必须是有效图片，另报告残留临时文件。
+func checkArtDir(dir, rel string, match func(string) bool) ([]domain.AuditIssue, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var issues []domain.AuditIssue
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch {
+		case isTempName(e.Name()):
+			issues = append(issues, domain.AuditIssue{
+				Kind:       domain.AuditStrayTemp,
+				File:       filepath.Join(rel, e.Name()),
+				Repairable: true,
+				Msg:        "原子写残留的临时文件（上次写入被中断）",
+			})
+		case match(e.Name()):
+			if is, ok := checkImage(filepath.Join(dir, e.Name()), filepath.Join(rel, e.Name())); !ok {
+				issues = append(issues, is)
+			}
+		}
+	}
+	return issues, nil
+}
+
+func checkNFO(abs, rel string, code domain.Code) (domain.AuditIssue, bool) {
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return domain.AuditIssue{Kind: domain.AuditNFOInvalid, File: rel, Msg: fmt.Sprintf("读取 NFO 失败：%v", err)}, false
+	}
+	if len(strings.TrimSpace(string(b))) == 0 {
+		return domain.AuditIssue{Kind: domain.AuditNFOEmpty, File: rel, Repairable: true, Msg: "NFO 为空文件"}, false
+	}
+
+	// 与 run 写出的 NFO 共用同一个解码器（兼容第三方刮削器的 <uniqueid type="num">/<id>）。
+	m, err := nfo.Decode(b)
+	if err != nil {
+		return domain.AuditIssue{Kind: domain.AuditNFOInvalid, File: rel, Msg: err.Error()}, false
+	}
+	if m.Code != code {
+		return domain.AuditIssue{Kind: domain.AuditNFONumMismatch, File: rel, Msg: fmt.Sprintf("NFO <num>=%q 与目录 CODE %s 不一致", m.Code, code)}, false
+	}
+	return domain.AuditIssue{}, true
+}
+
+func checkImage(abs, rel string) (domain.AuditIssue, bool) {
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return domain.AuditIssue{Kind: domain.AuditImageInvalid, File: rel, Repairable: true, Msg: fmt.Sprintf("读取图片失败：%v", err)}, false
+	}
+	if len(b) == 0 {
+		return domain.AuditIssue{Kind: domain.AuditImageEmpty, File: rel, Repairable: true, Msg: "图片为 0 字节"}, false
+	}
+	if err := imgx.Validate(b); err != nil {
+		return domain.AuditIssue{Kind: domain.AuditImageInvalid, File: rel, Repairable: true, Msg: fmt.Sprintf("图片无法解码：%v", err)}, false
+	}
+	return domain.AuditIssue{}, true
+}
+
+// isTempName 识别 fsx 原子写的临时文件名（"." + name + ".tmp-*"）。
+func isTempName(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp-")
+}
+
+// backupSuffix 是坏文件移到一旁时的后缀：备份名（.poster.jpg.tmp-audit）符合原子写临时文件的形态，
+// 进程中途退出留下的备份会在下一次 audit 中作为 stray_tmp 报告并清理。
+const backupSuffix = ".tmp-audit"
+
+// Backup 记录 Cleanup 移到一旁的坏文件（原路径 => 备份路径，均为绝对路径）。
+type Backup struct {
+	root  string
+	moved map[string]string
+}
+
+// Cleanup 为补齐运行腾出位置：删除残留临时文件，并把其余坏文件（空 NFO、0 字节/不可解码图片）
+// 改名移到同目录的备份名，让它们在随后的补齐计划中被视为“缺失”。只应在 apply 时调用；
+// 补齐运行结束后必须调用返回值的 Finish（出错时返回值同样有效，用于恢复已移走的文件）。
+//
+// 只处理 plans 覆盖的目录（PlanFixes 的结果，且已剔除 ignore 的 CODE）：被跳过的目录
+// （非规范目录名、被忽略的 CODE）不会有人补齐，移走坏文件只会让它们更糟。
+func Cleanup(root string, rep domain.AuditReport, plans []domain.ItemPlan) (*Backup, error) {
+	planned := make(map[string]struct{}, len(plans))
+	for _, p := range plans {
+		planned[filepath.Clean(p.OutDir)] = struct{}{}
+	}
+	bk := &Backup{root: root, moved: map[string]string{}}
+	var errs []error
+	for _, it := range rep.Items {
+		if _, ok := planned[filepath.Join(rep.Path, it.Dir)]; !ok {
+			continue
+		}
+		for _, is := range it.Issues {
+			abs := filepath.Join(root, is.File)
+			switch is.Kind {
+			case domain.AuditStrayTemp:
+				if err := os.Remove(abs); err != nil && !os.IsNotExist(err) {
+					errs = append(errs, err)
+				}
+			case domain.AuditNFOEmpty, domain.AuditImageEmpty, domain.AuditImageInvalid:
+				dir, name := filepath.Split(abs)
+				bak := filepath.Join(dir, "."+name+backupSuffix)
+				if err := os.Rename(abs, bak); err != nil {
+					if !os.IsNotExist(err) {
+						errs = append(errs, err)
+					}
+					continue
+				}
+				bk.moved[abs] = bak
+			}
+		}
+	}
+	return bk, errors.Join(errs...)
+}
+
+// Finish 在补齐运行之后处理 Cleanup 移走的坏文件：原位置已写入新文件的，删除备份；
+// 否则（补齐失败或没有产出该文件）把坏文件移回原处。返回被移回的文件（相对 root，按路径排序）。
+func (b *Backup) Finish() ([]string, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var (
+		restored []string
+		errs     []error
+	)
+	for abs, bak := range b.moved {
+		if _, err := os.Lstat(abs); err == nil {
+			if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := os.Rename(bak, abs); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rel, err := filepath.Rel(b.root, abs)
+		if err != nil {
+			rel = abs
+		}
+		restored = append(restored, rel)
+	}
+	b.moved = nil
+	sort.Strings(restored)
+	return restored, errors.Join(errs...)
+}
+
+// PlanFixes 为含可修复 sidecar 问题的 CODE 目录生成 zero-move 的补齐计划。
+//
+// 规则与 planner.PlanItem 一致（例如 poster 可由已有 fanart 生成，不必刮削）；
+// 损坏的可选 artwork 按原样重建（thumb/landscape 由 fanart 派生，extrafanart 至少补到损坏的序号），
+// 损坏的头像随 NFO 重新下载（已有 NFO 不会被覆盖；仅 actors.portraits=item 时会写回 .actors/）。
+// 只有残留临时文件的目录也生成一个无需补齐的计划，让 Cleanup 知道可以清理它。
+func PlanFixes(providerRequested string, rep domain.AuditReport) []domain.ItemPlan {
+	plans := make([]domain.ItemPlan, 0, len(rep.Items))
+	for _, it := range rep.Items {
+		c, ok := domain.ParseCode(it.Code)
+		if !ok || hasIssue(it, domain.AuditNonCanonicalDir) {
+			// 非规范目录名需要人工处理：补齐会用错误的 CODE 刮削。
+			continue
+		}
+
+		st := domain.OutState{
+			OutDir: filepath.Join(rep.Path, it.Dir), HasNFO: true, HasPoster: true, HasFanart: true,
+			HasThumb: true, HasLandscape: true, HasExtrafanart: true,
+		}
+		var opt planner.Options
+		for _, is := range it.Issues {
+			switch is.Kind {
+			case domain.AuditMissingNFO, domain.AuditNFOEmpty:
+				st.HasNFO = false
+			case domain.AuditMissingPoster:
+				st.HasPoster = false
+			case domain.AuditMissingFanart:
+				st.HasFanart = false
+			case domain.AuditImageEmpty, domain.AuditImageInvalid:
+				markBrokenImage(&st, &opt, it.Dir, is.File)
+			}
+		}
+
+		p, err := planner.PlanItemWithOptions(providerRequested, nil, domain.WorkItem{Code: c}, st, opt)
+		if err != nil {
+			continue
+		}
+		if p.Need.Any() || hasIssue(it, domain.AuditStrayTemp) {
+			plans = append(plans, p)
+		}
+	}
+	planner.SortPlans(plans)
+	return plans
+}
+
+// markBrokenImage 把损坏的图片（file 为相对 root 的路径，位于 dir 下）映射为需要重建的 sidecar。
+func markBrokenImage(st *domain.OutState, opt *planner.Options, dir, file string) {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return
+	}
+	sub, name := filepath.Split(rel)
+	switch filepath.Clean(sub) {
+	case ".":
+		switch name {
+		case "poster.jpg":
+			st.HasPoster = false
+		case "fanart.jpg":
+			st.HasFanart = false
+		case "thumb.jpg":
+			st.HasThumb, opt.Thumb = false, true
+		case "landscape.jpg":
+			st.HasLandscape, opt.Landscape = false, true
+		}
+	case domain.ExtrafanartDir:
+		n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "fanart"), ".jpg"))
+		st.HasExtrafanart = false
+		if n > opt.Extrafanart {
+			opt.Extrafanart = n
+		}
+	case domain.ActorsDir:
+		st.HasNFO = false
+	}
+}
+
+func hasIssue(it domain.AuditItem, kind string) bool {
+	for _, is := range it.Issues {
+		if is.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+package audit
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func TestScan_ReportsIssuesPerDir(t *testing.T) {
+	root := t.TempDir()
+
+	// 完整目录：无问题。
+	okDir := filepath.Join(root, "out", "AAA-001")
+	write(t, filepath.Join(okDir, "AAA-001.mp4"), []byte("v"))
+	write(t, filepath.Join(okDir, "AAA-001.nfo"), []byte(`<movie><num>AAA-001</num></movie>`))
+	write(t, filepath.Join(okDir, "poster.jpg"), mustJPEG(t))
+	write(t, filepath.Join(okDir, "fanart.jpg"), mustJPEG(t))
+
+	// 问题目录：num 不一致 + poster 0 字节 + fanart 缺失 + 无视频 + 残留临时文件。
+	badDir := filepath.Join(root, "out", "BBB-002")
+	write(t, filepath.Join(badDir, "BBB-002.nfo"), []byte(`<movie><num>BBB-003</num></movie>`))
+	write(t, filepath.Join(badDir, "poster.jpg"), nil)
+	write(t, filepath.Join(badDir, ".fanart.jpg.tmp-123"), []byte("x"))
+
+	// 非 CODE 目录。
+	if err := os.MkdirAll(filepath.Join(root, "out", "misc"), 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	// 全库共享头像目录（actors.portraits=library）不是问题。
+	write(t, filepath.Join(root, "out", domain.ActorsDir, "A.jpg"), mustJPEG(t))
+
+	rep, err := Scan(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if rep.Summary.Dirs != 3 || rep.Summary.OK != 1 || rep.Summary.WithIssues != 2 {
+		t.Fatalf("summary 不符合预期：%+v", rep.Summary)
+	}
+	if rep.Items[0].Code != "AAA-001" || len(rep.Items[0].Issues) != 0 {
+		t.Fatalf("完整目录不应有问题：%+v", rep.Items[0])
+	}
+
+	got := map[string]bool{}
+	for _, is := range rep.Items[1].Issues {
+		got[is.Kind] = is.Repairable
+	}
+	want := map[string]bool{
+		domain.AuditNFONumMismatch: false,
+		domain.AuditImageEmpty:     true,
+		domain.AuditMissingFanart:  true,
+		domain.AuditNoVideo:        false,
+		domain.AuditStrayTemp:      true,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("issues 不符合预期：%+v", rep.Items[1].Issues)
+	}
+	for k, v := range want {
+		if r, ok := got[k]; !ok || r != v {
+			t.Fatalf("issue %s 缺失或 repairable 不符：%+v", k, rep.Items[1].Issues)
+		}
+	}
+	if rep.Items[2].Code != "" || rep.Items[2].Issues[0].Kind != domain.AuditNotCodeDir {
+		t.Fatalf("非 CODE 目录应报告 not_code_dir：%+v", rep.Items[2])
+	}
+}
+
+func TestCleanupAndPlanFixes(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "out", "BBB-002")
+	write(t, filepath.Join(dir, "BBB-002.mp4"), []byte("v"))
+	write(t, filepath.Join(dir, "BBB-002.nfo"), []byte(`<movie><num>BBB-002</num></movie>`))
+	write(t, filepath.Join(dir, "fanart.jpg"), mustJPEG(t))
+	write(t, filepath.Join(dir, "poster.jpg"), []byte("<html>not an image</html>"))
+	write(t, filepath.Join(dir, ".poster.jpg.tmp-1"), []byte("x"))
+
+	rep, err := Scan(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+
+	plans := PlanFixes("javbus", rep)
+	if len(plans) != 1 {
+		t.Fatalf("期望 1 个补齐计划，实际 %d", len(plans))
+	}
+	p := plans[0]
+	if len(p.Moves) != 0 || !p.Need.NeedPoster || p.Need.NeedScrape || p.Need.NeedFanart || p.Need.NeedNFO {
+		t.Fatalf("只需由已有 fanart 重新生成 poster：%+v", p)
+	}
+
+	bk, err := Cleanup(root, rep, plans)
+	if err != nil {
+		t.Fatalf("Cleanup 不期望错误：%v", err)
+	}
+	for _, name := range []string{"poster.jpg", ".poster.jpg.tmp-1"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("期望 %s 被移走，Stat err=%v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".poster.jpg"+backupSuffix)); err != nil {
+		t.Fatalf("坏文件应先保留为备份：%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "fanart.jpg")); err != nil {
+		t.Fatalf("正常文件不应被删除：%v", err)
+	}
+
+	// 补齐写入了新 poster：Finish 删除备份，不恢复。
+	good := mustJPEG(t)
+	write(t, filepath.Join(dir, "poster.jpg"), good)
+	restored, err := bk.Finish()
+	if err != nil || len(restored) != 0 {
+		t.Fatalf("不应恢复任何文件：%v err=%v", restored, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".poster.jpg"+backupSuffix)); !os.IsNotExist(err) {
+		t.Fatalf("新文件写入后备份应被删除，Stat err=%v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "poster.jpg")); !bytes.Equal(b, good) {
+		t.Fatalf("新 poster 不应被覆盖")
+	}
+}
+
+func TestCleanup_OptionalArtworkRestoredWhenNotFixed(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "out", "EEE-005")
+	write(t, filepath.Join(dir, "EEE-005.mp4"), []byte("v"))
+	write(t, filepath.Join(dir, "EEE-005.nfo"), []byte(`<movie><num>EEE-005</num></movie>`))
+	write(t, filepath.Join(dir, "fanart.jpg"), mustJPEG(t))
+	write(t, filepath.Join(dir, "poster.jpg"), mustJPEG(t))
+	write(t, filepath.Join(dir, "landscape.jpg"), mustJPEG(t))
+	write(t, filepath.Join(dir, "thumb.jpg"), nil)
+	write(t, filepath.Join(dir, domain.ExtrafanartDir, "fanart1.jpg"), mustJPEG(t))
+	write(t, filepath.Join(dir, domain.ExtrafanartDir, "fanart2.jpg"), []byte("<html/>"))
+	write(t, filepath.Join(dir, domain.ActorsDir, "A.jpg"), []byte("<html/>"))
+
+	rep, err := Scan(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	var files []string
+	for _, is := range rep.Items[0].Issues {
+		files = append(files, is.File)
+	}
+	want := []string{
+		filepath.Join("out", "EEE-005", "thumb.jpg"),
+		filepath.Join("out", "EEE-005", domain.ActorsDir, "A.jpg"),
+		filepath.Join("out", "EEE-005", domain.ExtrafanartDir, "fanart2.jpg"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("期望只报告损坏的可选 artwork：%+v", rep.Items[0].Issues)
+	}
+
+	plans := PlanFixes("javbus", rep)
+	if len(plans) != 1 {
+		t.Fatalf("期望 1 个补齐计划，实际 %d", len(plans))
+	}
+	p := plans[0]
+	if !p.Need.NeedThumb || p.Need.NeedLandscape || !p.Need.NeedExtrafanart || p.ExtrafanartMax != 2 || !p.Need.NeedNFO || p.Need.NeedPoster || p.Need.NeedFanart {
+		t.Fatalf("期望重建 thumb、extrafanart 到第 2 张、并随 NFO 重新下载头像：%+v", p)
+	}
+
+	bk, err := Cleanup(root, rep, plans)
+	if err != nil {
+		t.Fatalf("Cleanup 不期望错误：%v", err)
+	}
+	// 进程在此中断时，备份作为残留临时文件被下一次 audit 报告。
+	mid, err := Scan(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	stray := 0
+	for _, is := range mid.Items[0].Issues {
+		if is.Kind == domain.AuditStrayTemp {
+			stray++
+		}
+	}
+	if stray != 3 {
+		t.Fatalf("期望 3 个备份被报告为 stray_tmp：%+v", mid.Items[0].Issues)
+	}
+
+	// 只补齐了 thumb：其余坏文件移回原处，不留空缺。
+	write(t, filepath.Join(dir, "thumb.jpg"), mustJPEG(t))
+	restored, err := bk.Finish()
+	if err != nil {
+		t.Fatalf("Finish 不期望错误：%v", err)
+	}
+	wantRestored := []string{
+		filepath.Join("out", "EEE-005", domain.ActorsDir, "A.jpg"),
+		filepath.Join("out", "EEE-005", domain.ExtrafanartDir, "fanart2.jpg"),
+	}
+	if !reflect.DeepEqual(restored, wantRestored) {
+		t.Fatalf("恢复列表不符合预期：%v", restored)
+	}
+	for _, f := range wantRestored {
+		if b, err := os.ReadFile(filepath.Join(root, f)); err != nil || string(b) != "<html/>" {
+			t.Fatalf("%s 应恢复为原文件：%q err=%v", f, b, err)
+		}
+	}
+	after, err := Scan(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(after.Items[0].Issues) != 2 {
+		t.Fatalf("期望只剩两个未修复的坏图片：%+v", after.Items[0].Issues)
+	}
+}
+
+func TestCleanup_SkipsUnplannedDirs(t *testing.T) {
+	root := t.TempDir()
+	// 非规范目录名：不生成补齐计划，坏图片与临时文件都应原样保留。
+	odd := filepath.Join(root, "out", "ABP-01")
+	write(t, filepath.Join(odd, "ABP-01.mp4"), []byte("v"))
+	write(t, filepath.Join(odd, "poster.jpg"), []byte("<html/>"))
+	write(t, filepath.Join(odd, ".fanart.jpg.tmp-1"), []byte("x"))
+	// 被 overrides 忽略的 CODE：计划在调用方被剔除，同样不清理。
+	ignored := filepath.Join(root, "out", "CCC-003")
+	write(t, filepath.Join(ignored, "CCC-003.mp4"), []byte("v"))
+	write(t, filepath.Join(ignored, "fanart.jpg"), []byte{})
+	// 只有临时文件的目录：生成无需补齐的计划并被清理。
+	tmpOnly := filepath.Join(root, "out", "DDD-004")
+	write(t, filepath.Join(tmpOnly, "DDD-004.mp4"), []byte("v"))
+	write(t, filepath.Join(tmpOnly, "DDD-004.nfo"), []byte(`<movie><num>DDD-004</num></movie>`))
+	write(t, filepath.Join(tmpOnly, "fanart.jpg"), mustJPEG(t))
+	write(t, filepath.Join(tmpOnly, "poster.jpg"), mustJPEG(t))
+	write(t, filepath.Join(tmpOnly, ".poster.jpg.tmp-1"), []byte("x"))
+
+	rep, err := Scan(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	var plans []domain.ItemPlan
+	for _, p := range PlanFixes("javbus", rep) {
+		if p.Code != "CCC-003" {
+			plans = append(plans, p)
+		}
+	}
+	if len(plans) != 1 || plans[0].Code != "DDD-004" || plans[0].Need.Any() {
+		t.Fatalf("期望只剩 DDD-004 的空计划：%+v", plans)
+	}
+	if _, err := Cleanup(root, rep, plans); err != nil {
+		t.Fatalf("Cleanup 不期望错误：%v", err)
+	}
+	for _, p := range []string{
+		filepath.Join(odd, "poster.jpg"), filepath.Join(odd, ".fanart.jpg.tmp-1"), filepath.Join(ignored, "fanart.jpg"),
+	} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("未计划的目录不应被清理：%s %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpOnly, ".poster.jpg.tmp-1")); !os.IsNotExist(err) {
+		t.Fatalf("期望临时文件被删除，Stat err=%v", err)
+	}
+}
+
+func TestScan_NonCanonicalDirNotFixed(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "out", "ABP-01")
+	write(t, filepath.Join(dir, "ABP-01.mp4"), []byte("v"))
+
+	rep, err := Scan(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	found := false
+	for _, is := range rep.Items[0].Issues {
+		if is.Kind == domain.AuditNonCanonicalDir && !is.Repairable {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("期望 non_canonical_dir：%+v", rep.Items[0].Issues)
+	}
+	if plans := PlanFixes("javbus", rep); len(plans) != 0 {
+		t.Fatalf("非规范目录不应生成补齐计划：%+v", plans)
+	}
+}
+
+func write(t *testing.T, path string, b []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatalf("写入文件失败：%v", err)
+	}
+}
+
+func mustJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 4)), nil); err != nil {
+		t.Fatalf("encode jpeg 失败：%v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,2 @@
+// Package audit 检查 <path>/out/ 的一致性，并为可修复问题生成补齐计划。
+package audit
@@ -2,23 +2,39 @@ package app
 
 import (
 	"errors"
+	"path/filepath"
 	"sort"
 
 	"github.com/John-Robertt/AVMC/internal/code"
 	"github.com/John-Robertt/AVMC/internal/domain"
 )
 
-// GroupByCode 把视频文件按 CODE 分组为 WorkItem（WorkItem 只存 file index）。
+// GroupByCode 以默认规范化规则分组（见 GroupByCodeWith）。
+func GroupByCode(files []domain.VideoFile) (items []domain.WorkItem, unmatched []domain.Unmatched, err error) {
+	return GroupByCodeWith(files, code.DefaultNormalizer())
+}
+
+// GroupByCodeWith 以给定规范化规则分组，不使用覆盖规则（见 GroupByCodeWithOverrides）。
+func GroupByCodeWith(files []domain.VideoFile, n code.Normalizer) (items []domain.WorkItem, unmatched []domain.Unmatched, err error) {
+	return GroupByCodeWithOverrides(files, n, domain.Overrides{})
+}
+
+// GroupByCodeWithOverrides 把视频文件按规范化后的 CODE 分组为 WorkItem（WorkItem 只存 file index）。
+// ov.Files 命中的文件直接使用指定的 CODE，不再从文件名识别（ignore 由调用方处理）。
 //
 // - items 稳定排序：按 Code 字典序
 // - item 内 FileIdx 稳定排序：按 RelPath 字典序
-func GroupByCode(files []domain.VideoFile) (items []domain.WorkItem, unmatched []domain.Unmatched, err error) {
+func GroupByCodeWithOverrides(files []domain.VideoFile, n code.Normalizer, ov domain.Overrides) (items []domain.WorkItem, unmatched []domain.Unmatched, err error) {
 	index := make(map[domain.Code]int, 128)
 	items = make([]domain.WorkItem, 0, 128)
 	unmatched = make([]domain.Unmatched, 0, 32)
 
 	for i := range files {
-		c, e := code.Extract(files[i])
+		c, forced := ov.Files[filepath.ToSlash(files[i].RelPath)]
+		var e error
+		if !forced {
+			c, e = code.ExtractWith(files[i], n)
+		}
 		if e != nil {
 			var ue *code.UnmatchedError
 			if errors.As(e, &ue) {
@@ -56,3 +72,21 @@ func GroupByCode(files []domain.VideoFile) (items []domain.WorkItem, unmatched [
 	}
 	return items, unmatched, nil
 }
+
+// AppendCodes 为 codes 中尚未出现在 items 里的 CODE 追加 zero-file 的 WorkItem（用于补齐已整理的 out/ 目录）。
+// 返回值仍按 Code 字典序稳定排序。
+func AppendCodes(items []domain.WorkItem, codes []domain.Code) []domain.WorkItem {
+	seen := make(map[domain.Code]struct{}, len(items))
+	for _, it := range items {
+		seen[it.Code] = struct{}{}
+	}
+	for _, c := range codes {
+		if _, ok := seen[c]; ok {
+			continue
+		}
+		seen[c] = struct{}{}
+		items = append(items, domain.WorkItem{Code: c})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return string(items[i].Code) < string(items[j].Code) })
+	return items
+}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/John-Robertt/AVMC/internal/code"
 	"github.com/John-Robertt/AVMC/internal/domain"
 )
 
@@ -32,6 +33,22 @@ func TestGroupByCode_MergeSameCode(t *testing.T) {
 	}
 }
 
+func TestGroupByCode_MergePaddingVariants(t *testing.T) {
+	files := []domain.VideoFile{
+		{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "ABP-01.mp4"), RelPath: "a.mp4", Base: "ABP-01"},
+		{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "ABP-001.mkv"), RelPath: "b.mkv", Base: "ABP-001"},
+		{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "abp-0001.avi"), RelPath: "c.avi", Base: "abp-0001"},
+	}
+
+	items, unmatched, err := GroupByCode(files)
+	if err != nil || len(unmatched) != 0 {
+		t.Fatalf("不期望错误/unmatched：%v %v", err, unmatched)
+	}
+	if len(items) != 1 || items[0].Code != "ABP-001" || len(items[0].FileIdx) != 3 {
+		t.Fatalf("补零变体应合并为 ABP-001：%+v", items)
+	}
+}
+
 func TestGroupByCode_Unmatched(t *testing.T) {
 	files := []domain.VideoFile{
 		{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "hello.mp4"), RelPath: "hello.mp4", Base: "hello"},
@@ -49,3 +66,35 @@ func TestGroupByCode_Unmatched(t *testing.T) {
 		t.Fatalf("期望 1 个 unmatched，实际 %d", len(unmatched))
 	}
 }
+
+func TestAppendCodes_DedupAndSorted(t *testing.T) {
+	items := []domain.WorkItem{{Code: "BBB-002", FileIdx: []int{0}}}
+
+	got := AppendCodes(items, []domain.Code{"CCC-003", "BBB-002", "AAA-001"})
+	if len(got) != 3 {
+		t.Fatalf("期望 3 个 item，实际 %d：%+v", len(got), got)
+	}
+	if got[0].Code != "AAA-001" || got[1].Code != "BBB-002" || got[2].Code != "CCC-003" {
+		t.Fatalf("items 未按 Code 排序：%+v", got)
+	}
+	// 已存在的 CODE 保留原 FileIdx；新追加的为 zero-file。
+	if len(got[1].FileIdx) != 1 || len(got[0].FileIdx) != 0 || len(got[2].FileIdx) != 0 {
+		t.Fatalf("FileIdx 不符合预期：%+v", got)
+	}
+}
+
+func TestGroupByCodeWithOverrides_ForcedFileCode(t *testing.T) {
+	files := []domain.VideoFile{
+		{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "hello.mp4"), RelPath: filepath.Join("in", "hello.mp4"), Base: "hello"},
+		{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "CAWD-895.mp4"), RelPath: "CAWD-895.mp4", Base: "CAWD-895"},
+	}
+	ov := domain.Overrides{Files: map[string]domain.Code{"in/hello.mp4": "ABP-001", "CAWD-895.mp4": "SSIS-001"}}
+
+	items, unmatched, err := GroupByCodeWithOverrides(files, code.DefaultNormalizer(), ov)
+	if err != nil || len(unmatched) != 0 {
+		t.Fatalf("不期望错误/unmatched：%v %v", err, unmatched)
+	}
+	if len(items) != 2 || items[0].Code != "ABP-001" || items[1].Code != "SSIS-001" {
+		t.Fatalf("强制 CODE 应优先于文件名识别：%+v", items)
+	}
+}
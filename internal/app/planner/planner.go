@@ -7,13 +7,34 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/John-Robertt/AVMC/internal/code"
 	"github.com/John-Robertt/AVMC/internal/domain"
 )
 
-// ReadOutState 读取 out/<CODE>/ 的现状（只做 ReadDir，不读文件内容）。
-// 若 outDir 不存在，返回空状态且不报错。
-func ReadOutState(root string, code domain.Code) (domain.OutState, error) {
-	outDir := filepath.Join(root, "out", string(code))
+// ReadOutState 读取 out/<CODE>/ 的现状（见 ReadOutStatePreferred）。
+func ReadOutState(root string, c domain.Code) (domain.OutState, error) {
+	return ReadOutStatePreferred(root, c, string(c))
+}
+
+// ReadOutStatePreferred 以默认规范化规则读取该 CODE 的 out 目录现状（见 ReadOutStateWith）。
+func ReadOutStatePreferred(root string, c domain.Code, preferred string) (domain.OutState, error) {
+	dirs, err := IndexOutDirs(root, code.DefaultNormalizer())
+	if err != nil {
+		return domain.OutState{}, err
+	}
+	return ReadOutStateWith(root, c, preferred, dirs)
+}
+
+// ReadOutStateWith 读取该 CODE 的 out 目录现状（只做 stat/ReadDir，不读文件内容）。
+//
+// 目录查找顺序：out/<CODE>、out/<preferred>、其余 marker 后缀变体（domain.MarkerSuffixes）、
+// 再到 dirs 中目录名规范化后等于 CODE 的目录（规范化规则变更前建立的 out/ABP-01/ 等）；
+// 都不存在时 OutDir 为 out/<preferred>，返回空状态且不报错。
+func ReadOutStateWith(root string, c domain.Code, preferred string, dirs OutDirs) (domain.OutState, error) {
+	outDir, alias, err := findOutDir(root, c, preferred, dirs)
+	if err != nil {
+		return domain.OutState{}, err
+	}
 	st := domain.OutState{
 		OutDir:        outDir,
 		ExistingNames: map[string]struct{}{},
@@ -31,7 +52,11 @@ func ReadOutState(root string, code domain.Code) (domain.OutState, error) {
 		st.ExistingNames[e.Name()] = struct{}{}
 	}
 
-	if _, ok := st.ExistingNames[string(code)+".nfo"]; ok {
+	if _, ok := st.ExistingNames[string(c)+".nfo"]; ok {
+		st.HasNFO = true
+	}
+	if _, ok := st.ExistingNames[string(alias)+".nfo"]; ok && alias != "" {
+		// 非规范目录中按旧 CODE 命名的 NFO（out/ABP-01/ABP-01.nfo）同样视为已有。
 		st.HasNFO = true
 	}
 	if _, ok := st.ExistingNames["poster.jpg"]; ok {
@@ -40,52 +65,379 @@ func ReadOutState(root string, code domain.Code) (domain.OutState, error) {
 	if _, ok := st.ExistingNames["fanart.jpg"]; ok {
 		st.HasFanart = true
 	}
+	if _, ok := st.ExistingNames["thumb.jpg"]; ok {
+		st.HasThumb = true
+	}
+	if _, ok := st.ExistingNames["landscape.jpg"]; ok {
+		st.HasLandscape = true
+	}
+	if _, ok := st.ExistingNames[domain.ExtrafanartDir]; ok {
+		// 空目录（旧版本在 provider 没有样品图时创建）不算已满足：刮削结果带上 sample_urls 后仍可补齐。
+		has, err := hasGalleryImage(filepath.Join(outDir, domain.ExtrafanartDir))
+		if err != nil {
+			return domain.OutState{}, err
+		}
+		st.HasExtrafanart = has
+	}
 
 	return st, nil
 }
 
+// hasGalleryImage 报告 extrafanart 目录中是否至少有一张 fanartN.jpg；不是目录时返回 false。
+func hasGalleryImage(dir string) (bool, error) {
+	fi, err := os.Stat(dir)
+	if err != nil || !fi.IsDir() {
+		if err == nil || os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() && domain.IsExtrafanartName(e.Name()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// findOutDir 返回该 CODE 已存在的 out 目录；都不存在时返回 out/<preferred>。
+// 通过 dirs 找到非规范目录时，alias 是该目录名中的 CODE（例如 ABP-01），否则为空。
+func findOutDir(root string, c domain.Code, preferred string, dirs OutDirs) (dir string, alias domain.Code, err error) {
+	names := make([]string, 0, len(domain.MarkerSuffixes)+1)
+	names = append(names, string(c), preferred)
+	for _, s := range domain.MarkerSuffixes {
+		names = append(names, string(c)+s)
+	}
+	for _, n := range names {
+		p := filepath.Join(root, "out", n)
+		fi, err := os.Stat(p)
+		if err == nil {
+			if fi.IsDir() {
+				return p, "", nil
+			}
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+	}
+	for _, name := range dirs[c] {
+		if a, ok := code.ParseOutDirName(name); ok && a != c {
+			return filepath.Join(root, "out", name), a, nil
+		}
+	}
+	return filepath.Join(root, "out", preferred), "", nil
+}
+
+// OutDirs 把规范 CODE 映射到 out/ 下对应的目录名（按名字排序）；同一 CODE 可能有多个目录，
+// 例如规范名 ABP-001、带 marker 后缀的 ABP-001-C 与规范化规则变更前建立的 ABP-01。
+type OutDirs map[domain.Code][]string
+
+// IndexOutDirs 按 n 把 <root>/out/ 下目录名可解析为 CODE（可带 marker 后缀）的目录归到规范 CODE 下。
+// 非目录与非 CODE 目录直接忽略；out/ 不存在时返回空。
+func IndexOutDirs(root string, n code.Normalizer) (OutDirs, error) {
+	entries, err := os.ReadDir(filepath.Join(root, "out"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return OutDirs{}, nil
+		}
+		return nil, err
+	}
+	dirs := make(OutDirs, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		c, ok := code.ParseOutDirName(e.Name())
+		if !ok {
+			continue
+		}
+		canon := n.Canonical(c)
+		dirs[canon] = append(dirs[canon], e.Name())
+	}
+	return dirs, nil
+}
+
+// ListOutCodes 以默认规范化规则列出 out/ 下的 CODE 目录（见 ListOutCodesWith）。
+func ListOutCodes(root string) ([]domain.Code, error) {
+	return ListOutCodesWith(root, code.DefaultNormalizer())
+}
+
+// ListOutCodesWith 列出 <root>/out/ 下 CODE 目录对应的规范 CODE（按 CODE 排序、去重）。
+// 非规范目录名（例如 ABP-01）按 n 规范化为 ABP-001：run 会复用该目录，不会再建一个规范名目录。
+// 非目录与非 CODE 目录直接忽略；out/ 不存在时返回空。
+func ListOutCodesWith(root string, n code.Normalizer) ([]domain.Code, error) {
+	dirs, err := IndexOutDirs(root, n)
+	if err != nil {
+		return nil, err
+	}
+	return dirs.Codes(), nil
+}
+
+// Codes 返回 dirs 中的全部规范 CODE（按 CODE 排序）。
+func (dirs OutDirs) Codes() []domain.Code {
+	codes := make([]domain.Code, 0, len(dirs))
+	for c := range dirs {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return string(codes[i]) < string(codes[j]) })
+	return codes
+}
+
+// Options 控制可选 sidecar 的规划（零值 = 只规划 nfo/poster/fanart）。
+type Options struct {
+	Thumb     bool // thumb.jpg（由 fanart 派生）
+	Landscape bool // landscape.jpg（由 fanart 派生）
+
+	// Extrafanart 是 extrafanart/ 下最多下载的样品图张数；0 表示不规划。
+	Extrafanart int
+
+	// Dupes 控制同一分段存在多个版本时的处理（零值 = 全部保留且不标记）。
+	Dupes Dupes
+}
+
+// Dupes 是重复版本的处理规则（见 domain.Dupes* 常量）。
+type Dupes struct {
+	Mode   string // 空串等价于 keep_all
+	Prefer string // 空串等价于 resolution
+	// Dir 是 keep_best 的隔离根目录（绝对路径）；次选版本移到 <Dir>/<CODE>/。
+	Dir string
+	// Probe 返回视频的流信息（按分辨率比较时使用）；nil 或解析失败时退化为比较文件大小。
+	Probe func(path string) (domain.MediaInfo, error)
+}
+
+// ItemMarkers 返回 item 内所有视频文件名 marker 的并集（用于在规划前决定带后缀的目录名）。
+func ItemMarkers(files []domain.VideoFile, item domain.WorkItem) domain.Markers {
+	var m domain.Markers
+	for _, idx := range item.FileIdx {
+		if idx >= 0 && idx < len(files) {
+			m = m.Merge(code.ParseMarkers(files[idx].Base))
+		}
+	}
+	return m
+}
+
 // PlanItem 基于 WorkItem + OutState 生成确定性的执行计划（不做任何写入/移动）。
 func PlanItem(providerRequested string, files []domain.VideoFile, item domain.WorkItem, st domain.OutState) (domain.ItemPlan, error) {
+	return PlanItemWithOptions(providerRequested, files, item, st, Options{})
+}
+
+// PlanItemWithOptions 与 PlanItem 相同，但按 opt 额外规划可选 artwork。
+func PlanItemWithOptions(providerRequested string, files []domain.VideoFile, item domain.WorkItem, st domain.OutState, opt Options) (domain.ItemPlan, error) {
 	used := make(map[string]struct{}, len(st.ExistingNames)+len(item.FileIdx))
 	for n := range st.ExistingNames {
 		used[n] = struct{}{}
 	}
 
-	moves := make([]domain.MovePlan, 0, len(item.FileIdx))
 	for _, idx := range item.FileIdx {
 		if idx < 0 || idx >= len(files) {
 			return domain.ItemPlan{}, fmt.Errorf("非法 file index：%d", idx)
 		}
+	}
+	order, parts, dupes := classifyVersions(files, item.FileIdx, opt.Dupes)
+
+	var quarantineDir string
+	var quarantineUsed map[string]struct{}
+	moves := make([]domain.MovePlan, 0, len(item.FileIdx))
+	var markers domain.Markers
+	for _, idx := range order {
+		f := files[idx]
+		dir, dirUsed := st.OutDir, used
+		if dupes[idx] == domain.DuplicateQuarantined {
+			if opt.Dupes.Dir == "" {
+				return domain.ItemPlan{}, fmt.Errorf("dupes.mode=%s 需要隔离目录", domain.DupesKeepBest)
+			}
+			if quarantineUsed == nil {
+				quarantineDir = filepath.Join(opt.Dupes.Dir, string(item.Code))
+				names, err := existingNames(quarantineDir)
+				if err != nil {
+					return domain.ItemPlan{}, fmt.Errorf("读取隔离目录失败：%w", err)
+				}
+				quarantineUsed = names
+			}
+			dir, dirUsed = quarantineDir, quarantineUsed
+		}
 
-		srcAbs := files[idx].AbsPath
-		name := filepath.Base(srcAbs) // 尽量保留原文件名（含扩展名大小写）
-		dstName := allocName(name, used)
-		used[dstName] = struct{}{}
+		name := filepath.Base(f.AbsPath) // 尽量保留原文件名（含扩展名大小写）
+		dstName := allocName(name, dirUsed)
+		dirUsed[dstName] = struct{}{}
 
+		mk := code.ParseMarkers(f.Base)
+		if dupes[idx] != domain.DuplicateQuarantined {
+			// 被隔离的版本不进入 out/，其 marker 也不写入 NFO。
+			markers = markers.Merge(mk)
+		}
 		moves = append(moves, domain.MovePlan{
-			SrcAbs: srcAbs,
-			DstAbs: filepath.Join(st.OutDir, dstName),
+			SrcAbs:    f.AbsPath,
+			DstAbs:    filepath.Join(dir, dstName),
+			Markers:   mk,
+			Hash:      f.Hash,
+			Part:      parts[idx],
+			Duplicate: dupes[idx],
 		})
+
+		// 伴随文件紧跟在视频之后，并与视频同步改名（ABC-123__2.mp4 => ABC-123__2.chs.srt）。
+		dstBase := strings.TrimSuffix(dstName, filepath.Ext(dstName))
+		for _, c := range f.Companions {
+			cname := filepath.Base(c)
+			if len(cname) > len(f.Base) {
+				cname = dstBase + cname[len(f.Base):]
+			}
+			cname = allocName(cname, dirUsed)
+			dirUsed[cname] = struct{}{}
+			moves = append(moves, domain.MovePlan{
+				SrcAbs:    c,
+				DstAbs:    filepath.Join(dir, cname),
+				Markers:   mk,
+				Part:      parts[idx],
+				Duplicate: dupes[idx],
+			})
+		}
 	}
 
 	needNFO := !st.HasNFO
 	needPoster := !st.HasPoster
 	needFanart := !st.HasFanart
+	needThumb := opt.Thumb && !st.HasThumb
+	needLandscape := opt.Landscape && !st.HasLandscape
+	needExtra := opt.Extrafanart > 0 && !st.HasExtrafanart
 
 	return domain.ItemPlan{
 		Code:              item.Code,
 		ProviderRequested: providerRequested,
+		OutDir:            st.OutDir,
 		Moves:             moves,
+		Markers:           markers,
 		Need: domain.SidecarNeed{
-			// poster 由 fanart 裁切得到：仅当需要 NFO 或 fanart 时才必须刮削。
-			NeedScrape: needNFO || needFanart,
-			NeedNFO:    needNFO,
-			NeedPoster: needPoster,
-			NeedFanart: needFanart,
+			// poster/thumb/landscape 由 fanart 派生：仅当需要 NFO、fanart 或样品图 URL 时才必须刮削。
+			NeedScrape:      needNFO || needFanart || needExtra,
+			NeedNFO:         needNFO,
+			NeedPoster:      needPoster,
+			NeedFanart:      needFanart,
+			NeedThumb:       needThumb,
+			NeedLandscape:   needLandscape,
+			NeedExtrafanart: needExtra,
 		},
+		ExtrafanartMax: opt.Extrafanart,
 	}, nil
 }
 
+// classifyVersions 按分段序号分组，区分“多分段”与“同一分段的多个版本”。
+//
+// 返回：处理顺序（keep_best/mark 时最佳版本排在次选版本之前，使其优先占用原文件名）、
+// 每个文件的分段序号、以及多版本标记（keep_all 或只有一个版本时为空）。
+// keep_best 下若组内有分段无法确定的文件（末尾 -C，见 code.PartAmbiguous），该组退回 mark：
+// 宁可多留一个版本，也不把真正的分段隔离出去。
+func classifyVersions(files []domain.VideoFile, idxs []int, d Dupes) (order []int, parts map[int]int, dupes map[int]string) {
+	parts = make(map[int]int, len(idxs))
+	dupes = make(map[int]string, len(idxs))
+	groups := map[int][]int{}
+	for _, idx := range idxs {
+		p := code.ParsePart(files[idx].Base)
+		parts[idx] = p
+		groups[p] = append(groups[p], idx)
+	}
+	if d.Mode != domain.DupesKeepBest && d.Mode != domain.DupesMark {
+		return append([]int(nil), idxs...), parts, dupes
+	}
+
+	px := pixelCache{files: files, probe: d.Probe, memo: map[int]int{}}
+	for _, g := range groups {
+		if len(g) < 2 {
+			continue
+		}
+		loser := domain.DuplicateAlternate
+		if d.Mode == domain.DupesKeepBest && !anyPartAmbiguous(files, g) {
+			loser = domain.DuplicateQuarantined
+		}
+		best := g[0]
+		for _, idx := range g[1:] {
+			if betterVersion(files, idx, best, d, px.pixels) {
+				best = idx
+			}
+		}
+		for _, idx := range g {
+			dupes[idx] = loser
+		}
+		dupes[best] = domain.DuplicateBest
+	}
+
+	order = make([]int, 0, len(idxs))
+	for _, idx := range idxs {
+		if !isLoser(dupes[idx]) {
+			order = append(order, idx)
+		}
+	}
+	for _, idx := range idxs {
+		if isLoser(dupes[idx]) {
+			order = append(order, idx)
+		}
+	}
+	return order, parts, dupes
+}
+
+func isLoser(dup string) bool {
+	return dup == domain.DuplicateAlternate || dup == domain.DuplicateQuarantined
+}
+
+func anyPartAmbiguous(files []domain.VideoFile, g []int) bool {
+	for _, idx := range g {
+		if code.PartAmbiguous(files[idx].Base) {
+			return true
+		}
+	}
+	return false
+}
+
+// betterVersion 判断文件 a 是否严格优于 b：按分辨率（像素数）再按大小；完全相同时保留先出现的文件。
+func betterVersion(files []domain.VideoFile, a, b int, d Dupes, pixels func(int) int) bool {
+	if d.Prefer != domain.DupesPreferSize && d.Probe != nil {
+		if pa, pb := pixels(a), pixels(b); pa != pb {
+			return pa > pb
+		}
+	}
+	return files[a].Size > files[b].Size
+}
+
+// pixelCache 记住每个文件的像素数：同一组内多次比较时每个文件只探测一次。
+type pixelCache struct {
+	files []domain.VideoFile
+	probe func(string) (domain.MediaInfo, error)
+	memo  map[int]int
+}
+
+func (c pixelCache) pixels(idx int) int {
+	if n, ok := c.memo[idx]; ok {
+		return n
+	}
+	n := 0
+	if mi, err := c.probe(c.files[idx].AbsPath); err == nil {
+		n = mi.Width * mi.Height
+	}
+	c.memo[idx] = n
+	return n
+}
+
+// existingNames 列出目录下已有的文件名；目录不存在时返回空集合。
+func existingNames(dir string) (map[string]struct{}, error) {
+	names := map[string]struct{}{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, err
+	}
+	for _, e := range entries {
+		names[e.Name()] = struct{}{}
+	}
+	return names, nil
+}
+
 func allocName(name string, used map[string]struct{}) string {
 	if _, ok := used[name]; !ok {
 		return name
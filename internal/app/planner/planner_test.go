@@ -95,6 +95,45 @@ func TestPlanItem_NameConflictDeterministic(t *testing.T) {
 	}
 }
 
+func TestPlanItem_CompanionsRenamedWithVideo(t *testing.T) {
+	root := t.TempDir()
+	code, _ := domain.ParseCode("CAWD-895")
+
+	outDir := filepath.Join(root, "out", string(code))
+	write(t, filepath.Join(outDir, "A.mp4"))
+
+	st, err := ReadOutState(root, code)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+
+	in := filepath.Join(root, "in")
+	files := []domain.VideoFile{{
+		AbsPath:    filepath.Join(in, "A.mp4"),
+		Base:       "A",
+		Ext:        ".mp4",
+		Companions: []string{filepath.Join(in, "A.chs.srt"), filepath.Join(in, "A.ass")},
+	}}
+	plan, err := PlanItem("javbus", files, domain.WorkItem{Code: code, FileIdx: []int{0}}, st)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+
+	want := []domain.MovePlan{
+		{SrcAbs: filepath.Join(in, "A.mp4"), DstAbs: filepath.Join(outDir, "A__2.mp4")},
+		{SrcAbs: filepath.Join(in, "A.chs.srt"), DstAbs: filepath.Join(outDir, "A__2.chs.srt")},
+		{SrcAbs: filepath.Join(in, "A.ass"), DstAbs: filepath.Join(outDir, "A__2.ass")},
+	}
+	if len(plan.Moves) != len(want) {
+		t.Fatalf("moves 不符合预期：%+v", plan.Moves)
+	}
+	for i := range want {
+		if plan.Moves[i] != want[i] {
+			t.Fatalf("move[%d] 期望 %+v，实际 %+v", i, want[i], plan.Moves[i])
+		}
+	}
+}
+
 func write(t *testing.T, path string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -104,3 +143,240 @@ func write(t *testing.T, path string) {
 		t.Fatalf("写入文件失败：%v", err)
 	}
 }
+
+func TestPlanItemWithOptions_OptionalArtwork(t *testing.T) {
+	root := t.TempDir()
+	code, _ := domain.ParseCode("CAWD-895")
+
+	outDir := filepath.Join(root, "out", string(code))
+	write(t, filepath.Join(outDir, string(code)+".nfo"))
+	write(t, filepath.Join(outDir, "poster.jpg"))
+	write(t, filepath.Join(outDir, "fanart.jpg"))
+	write(t, filepath.Join(outDir, "thumb.jpg"))
+
+	st, err := ReadOutState(root, code)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	item := domain.WorkItem{Code: code}
+
+	// 未开启：与 PlanItem 相同。
+	plan, err := PlanItemWithOptions("javbus", nil, item, st, Options{})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if plan.Need.Any() {
+		t.Fatalf("未开启可选 artwork 时不应有任何需求：%+v", plan.Need)
+	}
+
+	// landscape 由已有 fanart 派生（不刮削）；thumb 已存在；extrafanart 需要刮削样品图。
+	plan, err = PlanItemWithOptions("javbus", nil, item, st, Options{Thumb: true, Landscape: true, Extrafanart: 5})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	want := domain.SidecarNeed{NeedScrape: true, NeedLandscape: true, NeedExtrafanart: true}
+	if plan.Need != want || plan.ExtrafanartMax != 5 {
+		t.Fatalf("need 不符合预期：got=%+v max=%d want=%+v", plan.Need, plan.ExtrafanartMax, want)
+	}
+}
+
+func TestReadOutState_ExtrafanartNeedsImage(t *testing.T) {
+	root := t.TempDir()
+	code, _ := domain.ParseCode("ABP-123")
+	dir := filepath.Join(root, "out", string(code), domain.ExtrafanartDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+
+	// 空目录（旧版本在没有样品图时创建）或只有无关文件：不算已有。
+	write(t, filepath.Join(dir, "notes.txt"))
+	st, err := ReadOutState(root, code)
+	if err != nil || st.HasExtrafanart {
+		t.Fatalf("没有 fanartN.jpg 时不应视为已有：%+v err=%v", st, err)
+	}
+
+	write(t, filepath.Join(dir, "fanart1.jpg"))
+	st, err = ReadOutState(root, code)
+	if err != nil || !st.HasExtrafanart {
+		t.Fatalf("有 fanart1.jpg 时应视为已有：%+v err=%v", st, err)
+	}
+}
+
+func TestListOutCodes_CanonicalizesDirs(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"ABP-001", "ABP-01-C", "ABP-02", "misc"} {
+		if err := os.MkdirAll(filepath.Join(root, "out", name), 0o755); err != nil {
+			t.Fatalf("创建目录失败：%v", err)
+		}
+	}
+
+	codes, err := ListOutCodes(root)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(codes) != 2 || codes[0] != "ABP-001" || codes[1] != "ABP-002" {
+		t.Fatalf("非规范目录名应按规范 CODE 列出且去重：%v", codes)
+	}
+}
+
+func TestReadOutState_ReusesShortPaddedDir(t *testing.T) {
+	// 规范化（ABP-01 => ABP-001）之前建立的目录应被复用，而不是再建一个 out/ABP-001/。
+	root := t.TempDir()
+	old := filepath.Join(root, "out", "ABP-01")
+	write(t, filepath.Join(old, "ABP-01.nfo"))
+	write(t, filepath.Join(old, "poster.jpg"))
+
+	code, _ := domain.ParseCode("ABP-001")
+	st, err := ReadOutState(root, code)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if st.OutDir != old || !st.HasNFO || !st.HasPoster || st.HasFanart {
+		t.Fatalf("应复用短位数目录并读取其现状：%+v", st)
+	}
+	plan, err := PlanItem("javbus", nil, domain.WorkItem{Code: code}, st)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if plan.OutDir != old || plan.Need.NeedNFO || !plan.Need.NeedFanart {
+		t.Fatalf("计划应落在已有目录且只补缺失的 sidecar：%+v", plan)
+	}
+
+	// 规范名目录存在时优先使用规范名目录。
+	canon := filepath.Join(root, "out", "ABP-001")
+	if err := os.MkdirAll(canon, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	if st, err := ReadOutState(root, code); err != nil || st.OutDir != canon {
+		t.Fatalf("应优先使用规范名目录：%+v err=%v", st, err)
+	}
+}
+
+func TestPlanItemWithOptions_Dupes(t *testing.T) {
+	root := t.TempDir()
+	code, _ := domain.ParseCode("ABC-123")
+	st := domain.OutState{OutDir: filepath.Join(root, "out", "ABC-123"), ExistingNames: map[string]struct{}{}}
+	in := filepath.Join(root, "in")
+	files := []domain.VideoFile{
+		{AbsPath: filepath.Join(in, "ABC-123.avi"), Base: "ABC-123", Ext: ".avi", Size: 900, Companions: []string{filepath.Join(in, "ABC-123.srt")}},
+		{AbsPath: filepath.Join(in, "x", "ABC-123.mkv"), Base: "ABC-123", Ext: ".mkv", Size: 500},
+		{AbsPath: filepath.Join(in, "ABC-123-cd1.mp4"), Base: "ABC-123-cd1", Ext: ".mp4", Size: 1},
+		{AbsPath: filepath.Join(in, "ABC-123-cd2.mp4"), Base: "ABC-123-cd2", Ext: ".mp4", Size: 1},
+	}
+	item := domain.WorkItem{Code: code, FileIdx: []int{0, 1, 2, 3}}
+	// .mkv 分辨率更高；.avi 无法解析（按 0 像素）。
+	probe := func(p string) (domain.MediaInfo, error) {
+		if filepath.Ext(p) == ".mkv" {
+			return domain.MediaInfo{Width: 1920, Height: 1080}, nil
+		}
+		return domain.MediaInfo{}, os.ErrInvalid
+	}
+	byDst := func(p domain.ItemPlan) map[string]domain.MovePlan {
+		m := map[string]domain.MovePlan{}
+		for _, mv := range p.Moves {
+			rel, _ := filepath.Rel(root, mv.DstAbs)
+			m[filepath.ToSlash(rel)] = mv
+		}
+		return m
+	}
+
+	// keep_all（默认）：与旧行为一致，只标记分段。
+	p, err := PlanItemWithOptions("javbus", files, item, st, Options{})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	got := byDst(p)
+	if got["out/ABC-123/ABC-123.avi"].Duplicate != "" || got["out/ABC-123/ABC-123.mkv"].Duplicate != "" || got["out/ABC-123/ABC-123-cd2.mp4"].Part != 2 {
+		t.Fatalf("keep_all 不应标记多版本：%+v", got)
+	}
+
+	// keep_best + resolution：.mkv 胜出，.avi 及其字幕隔离到 dupes/ABC-123/；cd1/cd2 是分段，不是重复。
+	dupesDir := filepath.Join(root, "dupes")
+	p, err = PlanItemWithOptions("javbus", files, item, st, Options{Dupes: Dupes{Mode: domain.DupesKeepBest, Dir: dupesDir, Probe: probe}})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	got = byDst(p)
+	if len(got) != 5 ||
+		got["out/ABC-123/ABC-123.mkv"].Duplicate != domain.DuplicateBest ||
+		got["dupes/ABC-123/ABC-123.avi"].Duplicate != domain.DuplicateQuarantined ||
+		got["dupes/ABC-123/ABC-123.srt"].Duplicate != domain.DuplicateQuarantined ||
+		got["out/ABC-123/ABC-123-cd1.mp4"].Duplicate != "" || got["out/ABC-123/ABC-123-cd1.mp4"].Part != 1 {
+		t.Fatalf("keep_best 结果不符合预期：%+v", got)
+	}
+
+	// mark + size：.avi 更大，最佳版本优先占用原文件名；次选版本仍在 out/ 中按 __2 去冲突。
+	files[1].AbsPath = filepath.Join(in, "x", "ABC-123.avi")
+	p, err = PlanItemWithOptions("javbus", files, item, st, Options{Dupes: Dupes{Mode: domain.DupesMark, Prefer: domain.DupesPreferSize, Probe: probe}})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	got = byDst(p)
+	if got["out/ABC-123/ABC-123.avi"].SrcAbs != files[0].AbsPath || got["out/ABC-123/ABC-123.avi"].Duplicate != domain.DuplicateBest ||
+		got["out/ABC-123/ABC-123__2.avi"].Duplicate != domain.DuplicateAlternate {
+		t.Fatalf("mark 结果不符合预期：%+v", got)
+	}
+	p, err = PlanItemWithOptions("javbus", files, domain.WorkItem{Code: code, FileIdx: []int{1, 0}}, st, Options{Dupes: Dupes{Mode: domain.DupesMark, Prefer: domain.DupesPreferSize}})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if p.Moves[0].SrcAbs != files[0].AbsPath || filepath.Base(p.Moves[0].DstAbs) != "ABC-123.avi" {
+		t.Fatalf("最佳版本应排在最前并占用原文件名：%+v", p.Moves)
+	}
+}
+
+func TestPlanItemWithOptions_DupesSuffixParts(t *testing.T) {
+	root := t.TempDir()
+	code, _ := domain.ParseCode("ABC-123")
+	st := domain.OutState{OutDir: filepath.Join(root, "out", "ABC-123"), ExistingNames: map[string]struct{}{}}
+	in := filepath.Join(root, "in")
+	video := func(base string, size int64) domain.VideoFile {
+		return domain.VideoFile{AbsPath: filepath.Join(in, base+".mp4"), Base: base, Ext: ".mp4", Size: size}
+	}
+	probes := map[string]int{}
+	probe := func(p string) (domain.MediaInfo, error) {
+		probes[p]++
+		return domain.MediaInfo{Width: 1920, Height: 1080}, nil
+	}
+	opt := Options{Dupes: Dupes{Mode: domain.DupesKeepBest, Dir: filepath.Join(root, "dupes"), Probe: probe}}
+
+	// -A/-B、-1/-2 是分段，不是重复版本：keep_best 不得隔离。
+	for _, bases := range [][]string{{"ABC-123-A", "ABC-123-B"}, {"ABC-123-1", "ABC-123-2"}} {
+		files := []domain.VideoFile{video(bases[0], 2), video(bases[1], 1)}
+		p, err := PlanItemWithOptions("javbus", files, domain.WorkItem{Code: code, FileIdx: []int{0, 1}}, st, opt)
+		if err != nil {
+			t.Fatalf("不期望错误：%v", err)
+		}
+		for i, mv := range p.Moves {
+			if mv.Duplicate != "" || mv.Part != i+1 || filepath.Dir(mv.DstAbs) != st.OutDir {
+				t.Fatalf("%v：分段不应被当作重复版本：%+v", bases, p.Moves)
+			}
+		}
+	}
+
+	// -C 无法判定是字幕还是第 3 段：该组退回 mark，两份都留在 out/。
+	files := []domain.VideoFile{video("ABC-123", 1), video("ABC-123-C", 2), video("ABC-123-cd1", 3)}
+	files = append(files, video("ABC-123 cd1", 4), video("ABC-123.cd1", 5))
+	p, err := PlanItemWithOptions("javbus", files, domain.WorkItem{Code: code, FileIdx: []int{0, 1, 2, 3, 4}}, st, opt)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	dup := map[string]string{}
+	for _, mv := range p.Moves {
+		dup[filepath.Base(mv.SrcAbs)] = mv.Duplicate
+		if mv.Part == 0 && filepath.Dir(mv.DstAbs) != st.OutDir {
+			t.Fatalf("分段不确定时不应隔离：%+v", mv)
+		}
+	}
+	if dup["ABC-123-C.mp4"] != domain.DuplicateBest || dup["ABC-123.mp4"] != domain.DuplicateAlternate ||
+		dup["ABC-123.cd1.mp4"] != domain.DuplicateBest || dup["ABC-123-cd1.mp4"] != domain.DuplicateQuarantined {
+		t.Fatalf("多版本标记不符合预期：%+v", dup)
+	}
+
+	// 每个文件只探测一次。
+	for path, n := range probes {
+		if n != 1 {
+			t.Fatalf("%s 被探测了 %d 次", path, n)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package run
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"image"
 	"image/color"
 	"image/jpeg"
@@ -10,10 +11,15 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/John-Robertt/AVMC/internal/actors"
 	"github.com/John-Robertt/AVMC/internal/config"
 	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/cache"
 	"github.com/John-Robertt/AVMC/internal/provider"
 )
 
@@ -202,3 +208,722 @@ func mustFanartJPEG(t *testing.T, w, h int) []byte {
 	}
 	return buf.Bytes()
 }
+
+func TestExecute_FillOut_RepairsSidecarsWithoutVideos(t *testing.T) {
+	root := t.TempDir()
+	outDir := filepath.Join(root, "out", "CAWD-895")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "CAWD-895.nfo"), []byte("<movie/>"), 0o644); err != nil {
+		t.Fatalf("写入 nfo 失败：%v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "fanart.jpg"), mustFanartJPEG(t, 40, 20), 0o644); err != nil {
+		t.Fatalf("写入 fanart 失败：%v", err)
+	}
+
+	reg, err := provider.NewRegistry(stubProvider{name: "javbus"}, stubProvider{name: "javdb"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1}
+
+	// 未开启 fill_out：没有源视频就没有工作单元。
+	if rr := Execute(context.Background(), eff, reg); len(rr.Items) != 0 {
+		t.Fatalf("未开启 fill_out 不应产生 item：%+v", rr.Items)
+	}
+
+	eff.FillOut = true
+	rr := Execute(context.Background(), eff, reg)
+	if len(rr.Items) != 1 || rr.Items[0].Status != domain.StatusProcessed || len(rr.Items[0].Files) != 0 {
+		t.Fatalf("期望 1 个 zero-move 的 processed item：%+v", rr.Items)
+	}
+	if rr.Items[0].ProviderUsed != "" {
+		t.Fatalf("只缺 poster 时不应刮削：%+v", rr.Items[0])
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "poster.jpg")); err != nil {
+		t.Fatalf("期望由已有 fanart 生成 poster：%v", err)
+	}
+
+	// 再跑一次：sidecar 已齐全 => skipped。
+	rr = Execute(context.Background(), eff, reg)
+	if len(rr.Items) != 1 || rr.Items[0].Status != domain.StatusSkipped {
+		t.Fatalf("重跑应 skipped：%+v", rr.Items)
+	}
+}
+
+func TestExecute_Apply_MovesCompanions(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "in")
+	for _, name := range []string{"CAWD-895.mp4", "CAWD-895.chs.srt"} {
+		if err := os.MkdirAll(in, 0o755); err != nil {
+			t.Fatalf("创建目录失败：%v", err)
+		}
+		if err := os.WriteFile(filepath.Join(in, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入文件失败：%v", err)
+		}
+	}
+
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1}
+	outDir := filepath.Join(root, "out", "CAWD-895")
+
+	// 伴随文件移动失败（源已消失）=> 视频回滚，二者都有独立的 file 结果。
+	p := domain.ItemPlan{
+		Code:              "CAWD-895",
+		ProviderRequested: "javbus",
+		Moves: []domain.MovePlan{
+			{SrcAbs: filepath.Join(in, "CAWD-895.mp4"), DstAbs: filepath.Join(outDir, "CAWD-895.mp4")},
+			{SrcAbs: filepath.Join(in, "gone.srt"), DstAbs: filepath.Join(outDir, "gone.srt")},
+		},
+	}
+	rs := execPlans(context.Background(), eff, provider.Registry{}, []domain.ItemPlan{p}, nil, nil, cache.New(root, false), map[string]string{}, nil)
+	if len(rs) != 1 {
+		t.Fatalf("期望 1 个结果：%+v", rs)
+	}
+	item := rs[0]
+	if item.Status != domain.StatusFailed || item.ErrorCode != domain.ErrCodeMoveFailed {
+		t.Fatalf("期望 move_failed：%+v", item)
+	}
+	if len(item.Files) != 2 || item.Files[0].Status != domain.FileStatusRolledBack || item.Files[1].Status != domain.FileStatusFailed {
+		t.Fatalf("file 结果不符合预期：%+v", item.Files)
+	}
+	if _, err := os.Stat(filepath.Join(in, "CAWD-895.mp4")); err != nil {
+		t.Fatalf("视频应回滚到原位置：%v", err)
+	}
+
+	// 正常运行：字幕随视频移动。
+	if err := os.WriteFile(filepath.Join(outDir, "CAWD-895.nfo"), []byte("<movie/>"), 0o644); err != nil {
+		t.Fatalf("写入 nfo 失败：%v", err)
+	}
+	for _, name := range []string{"poster.jpg", "fanart.jpg"} {
+		if err := os.WriteFile(filepath.Join(outDir, name), mustFanartJPEG(t, 40, 20), 0o644); err != nil {
+			t.Fatalf("写入图片失败：%v", err)
+		}
+	}
+	reg, err := provider.NewRegistry(stubProvider{name: "javbus"}, stubProvider{name: "javdb"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	rr := Execute(context.Background(), eff, reg)
+	if len(rr.Items) != 1 || rr.Items[0].Status != domain.StatusProcessed || len(rr.Items[0].Files) != 2 {
+		t.Fatalf("期望视频与字幕各一条 file 结果：%+v", rr.Items)
+	}
+	for _, f := range rr.Items[0].Files {
+		if f.Status != domain.FileStatusMoved {
+			t.Fatalf("期望全部 moved：%+v", rr.Items[0].Files)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "CAWD-895.chs.srt")); err != nil {
+		t.Fatalf("字幕应随视频移动：%v", err)
+	}
+}
+
+func TestExecute_Apply_MarkersToNFOAndFolderSuffix(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "in")
+	if err := os.MkdirAll(in, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	for _, name := range []string{"CAWD-895-C.mp4", "CAWD-896.mp4"} {
+		if err := os.WriteFile(filepath.Join(in, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入视频失败：%v", err)
+		}
+	}
+	// CAWD-896 已有不带后缀的目录：必须复用，不能再建 CAWD-896-C。
+	if err := os.MkdirAll(filepath.Join(root, "out", "CAWD-896"), 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T", Genres: []string{"剧情"}, FanartURL: img.URL + "/f.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1, MarkerFolderSuffix: true}
+	rr := Execute(context.Background(), eff, reg)
+	if rr.Summary.Processed != 2 {
+		t.Fatalf("期望 2 个 processed：%+v", rr.Items)
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, "out", "CAWD-895-C", "CAWD-895.nfo"))
+	if err != nil {
+		t.Fatalf("期望写入带后缀的目录：%v", err)
+	}
+	if !bytes.Contains(b, []byte("<genre>中文字幕</genre>")) || !bytes.Contains(b, []byte("<tag>中文字幕</tag>")) {
+		t.Fatalf("NFO 缺少 marker 标签：%s", b)
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "CAWD-895-C", "CAWD-895-C.mp4")); err != nil {
+		t.Fatalf("视频应移动到带后缀的目录：%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "CAWD-896", "CAWD-896.mp4")); err != nil {
+		t.Fatalf("已存在的目录应被复用：%v", err)
+	}
+}
+
+func TestExecute_Apply_OptionalArtwork(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "CAWD-895.mp4")
+	if err := os.WriteFile(in, []byte("x"), 0o644); err != nil {
+		t.Fatalf("写入视频失败：%v", err)
+	}
+
+	fanartBytes := mustFanartJPEG(t, 40, 20)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(fanartBytes)
+	}))
+	defer img.Close()
+
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{
+			Title:      "T",
+			FanartURL:  img.URL + "/fanart.jpg",
+			SampleURLs: []string{img.URL + "/s1.jpg", img.URL + "/s2.jpg", img.URL + "/s3.jpg"},
+		}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+
+	rr := Execute(context.Background(), config.EffectiveConfig{
+		Path:           root,
+		Provider:       "javbus",
+		Apply:          true,
+		Concurrency:    1,
+		Thumb:          true,
+		Landscape:      true,
+		ExtrafanartMax: 2,
+	}, reg)
+	if rr.Summary.Failed != 0 {
+		t.Fatalf("不期望失败：%+v", rr.Items)
+	}
+
+	outDir := filepath.Join(root, "out", "CAWD-895")
+	for _, name := range []string{"thumb.jpg", "landscape.jpg", "extrafanart/fanart1.jpg", "extrafanart/fanart2.jpg"} {
+		if _, err := os.Stat(filepath.Join(outDir, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("期望写出 %s：%v", name, err)
+		}
+	}
+	// 上限 2：第 3 张样品图不下载。
+	if _, err := os.Stat(filepath.Join(outDir, "extrafanart", "fanart3.jpg")); !os.IsNotExist(err) {
+		t.Fatalf("超过上限的样品图不应下载，Stat err=%v", err)
+	}
+}
+
+func TestExecute_Apply_ExtrafanartWithoutSamples(t *testing.T) {
+	// provider 没有样品图（例如旧缓存缺少 sample_urls）：不创建空的 extrafanart/，下次仍会尝试补齐。
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "CAWD-895.mp4"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("写入视频失败：%v", err)
+	}
+	fanartBytes := mustFanartJPEG(t, 40, 20)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(fanartBytes)
+	}))
+	defer img.Close()
+
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T", FanartURL: img.URL + "/fanart.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	rr := Execute(context.Background(), config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1, ExtrafanartMax: 2}, reg)
+	if rr.Summary.Failed != 0 || rr.Summary.Processed != 1 {
+		t.Fatalf("没有样品图不应失败：%+v", rr.Items)
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "CAWD-895", "extrafanart")); !os.IsNotExist(err) {
+		t.Fatalf("没有样品图时不应创建 extrafanart/：%v", err)
+	}
+}
+
+func TestExecute_Apply_Overrides(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "in")
+	if err := os.MkdirAll(in, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	for _, name := range []string{"CAWD-895.mp4", "odd.mp4"} {
+		if err := os.WriteFile(filepath.Join(in, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入视频失败：%v", err)
+		}
+	}
+
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "错误作品", FanartURL: img.URL + "/f.jpg"}},
+		stubProvider{name: "javdb", meta: domain.MovieMeta{Title: "javdb 标题", Studio: "S", Actors: []domain.Actor{{Name: "三上悠亜（鬼頭桃菜）"}}, FanartURL: img.URL + "/f.jpg"}},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+
+	title := "覆盖标题"
+	rr := Execute(context.Background(), config.EffectiveConfig{
+		Path:        root,
+		Provider:    "javbus",
+		Apply:       true,
+		Concurrency: 1,
+		Overrides: domain.Overrides{
+			Codes: map[domain.Code]domain.CodeOverride{
+				"CAWD-895": {Ignore: true},
+				"ABP-001":  {Provider: "javdb", Meta: &domain.MetaOverride{Title: &title}},
+			},
+			Files: map[string]domain.Code{"in/odd.mp4": "ABP-001"},
+		},
+		Actors: actors.Options{StripParens: true},
+	}, reg)
+
+	if len(rr.Items) != 2 || rr.Summary.Processed != 1 || rr.Summary.Skipped != 1 {
+		t.Fatalf("summary 不符合预期：%+v items=%+v", rr.Summary, rr.Items)
+	}
+	abp, cawd := rr.Items[0], rr.Items[1]
+	if abp.Code != "ABP-001" || abp.ProviderUsed != "javdb" || len(abp.Attempts) != 1 {
+		t.Fatalf("固定 provider 未生效：%+v", abp)
+	}
+	if got := strings.Join(abp.Overrides, ","); got != "file_code,provider,meta.title" {
+		t.Fatalf("overrides 不符合预期：%q", got)
+	}
+	if len(abp.ActorRenames) != 1 || abp.ActorRenames[0].To != "三上悠亜" {
+		t.Fatalf("actor_renames 不符合预期：%+v", abp.ActorRenames)
+	}
+	if cawd.Status != domain.StatusSkipped || len(cawd.Overrides) != 1 || cawd.Overrides[0] != domain.OverrideIgnore || cawd.Files[0].Status != domain.FileStatusIgnored {
+		t.Fatalf("ignore 条目不符合预期：%+v", cawd)
+	}
+	if _, err := os.Stat(filepath.Join(in, "CAWD-895.mp4")); err != nil {
+		t.Fatalf("被忽略的视频应原地保留：%v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, "out", "ABP-001", "ABP-001.nfo"))
+	if err != nil {
+		t.Fatalf("读取 NFO 失败：%v", err)
+	}
+	if !strings.Contains(string(b), "覆盖标题") || !strings.Contains(string(b), "<studio>S</studio>") || strings.Contains(string(b), "鬼頭桃菜") {
+		t.Fatalf("NFO 应包含覆盖后的字段与其余刮削字段：%s", b)
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "ABP-001", "odd.mp4")); err != nil {
+		t.Fatalf("强制 CODE 的视频应移动到 out/ABP-001：%v", err)
+	}
+}
+
+// thumbStubProvider 模拟详情页不含头像、需要再抓演员页的 provider（例如 JavDB）。
+type thumbStubProvider struct {
+	stubProvider
+	thumbs map[string]string
+}
+
+func (p thumbStubProvider) ActorThumb(ctx context.Context, actorURL string, c *http.Client) (string, error) {
+	return p.thumbs[actorURL], nil
+}
+
+func TestExecute_Apply_ActorPortraits(t *testing.T) {
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	meta := domain.MovieMeta{
+		Title:     "T",
+		FanartURL: img.URL + "/f.jpg",
+		Actors: []domain.Actor{
+			{Name: "Yua Mikami", Thumb: img.URL + "/a.jpg"},
+			{Name: "B", URL: "https://example.test/actors/b"},
+			{Name: "NoThumb"},
+		},
+	}
+	reg, err := provider.NewRegistry(
+		thumbStubProvider{stubProvider: stubProvider{name: "javbus", meta: meta}, thumbs: map[string]string{"https://example.test/actors/b": img.URL + "/b.jpg"}},
+		stubProvider{name: "javdb", meta: domain.MovieMeta{Title: "T2"}},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+
+	for _, mode := range []string{domain.PortraitsItem, domain.PortraitsLibrary} {
+		root := t.TempDir()
+		in := filepath.Join(root, "in")
+		if err := os.MkdirAll(in, 0o755); err != nil {
+			t.Fatalf("创建目录失败：%v", err)
+		}
+		if err := os.WriteFile(filepath.Join(in, "CAWD-895.mp4"), []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入视频失败：%v", err)
+		}
+
+		eff := config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1, Portraits: mode}
+		dir, ref := filepath.Join(root, "out", "CAWD-895", domain.ActorsDir), ".actors/"
+		if mode == domain.PortraitsLibrary {
+			eff.PortraitDir = filepath.Join(root, "people")
+			dir, ref = eff.PortraitDir, eff.PortraitDir+string(filepath.Separator)
+		}
+		rr := Execute(context.Background(), eff, reg)
+		if rr.Summary.Processed != 1 || rr.Summary.Failed != 0 {
+			t.Fatalf("%s：summary 不符合预期：%+v items=%+v", mode, rr.Summary, rr.Items)
+		}
+		for _, name := range []string{"Yua_Mikami.jpg", "B.jpg"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Fatalf("%s：头像 %s 应已下载：%v", mode, name, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "NoThumb.jpg")); !os.IsNotExist(err) {
+			t.Fatalf("%s：没有头像的演员不应生成文件，err=%v", mode, err)
+		}
+
+		b, err := os.ReadFile(filepath.Join(root, "out", "CAWD-895", "CAWD-895.nfo"))
+		if err != nil {
+			t.Fatalf("读取 NFO 失败：%v", err)
+		}
+		if !strings.Contains(string(b), "<thumb>"+ref+"Yua_Mikami.jpg</thumb>") || !strings.Contains(string(b), "<thumb>"+ref+"B.jpg</thumb>") {
+			t.Fatalf("%s：NFO 应引用本地头像：%s", mode, b)
+		}
+	}
+}
+
+// minimalMP4 构造只有 ftyp + moov（mvhd + 一个 avc1 视频轨）的 MP4 头部，足够 mediax 解析。
+func minimalMP4(seconds uint32, w, h uint16) []byte {
+	box := func(typ string, parts ...[]byte) []byte {
+		body := bytes.Join(parts, nil)
+		b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+		return append(append(b, typ...), body...)
+	}
+	mvhd := box("mvhd", make([]byte, 12), binary.BigEndian.AppendUint32(nil, 1), binary.BigEndian.AppendUint32(nil, seconds), make([]byte, 80))
+	avc1 := box("avc1", make([]byte, 24), binary.BigEndian.AppendUint16(nil, w), binary.BigEndian.AppendUint16(nil, h), make([]byte, 50))
+	stsd := box("stsd", make([]byte, 4), binary.BigEndian.AppendUint32(nil, 1), avc1)
+	hdlr := box("hdlr", make([]byte, 8), []byte("vide"), make([]byte, 12))
+	trak := box("trak", box("mdia", hdlr, box("minf", box("stbl", stsd))))
+	return bytes.Join([][]byte{box("ftyp", []byte("isom"), make([]byte, 4)), box("moov", mvhd, trak), box("mdat", make([]byte, 64))}, nil)
+}
+
+func TestExecute_Apply_MediaFileInfo(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "in")
+	if err := os.MkdirAll(in, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	if err := os.WriteFile(filepath.Join(in, "CAWD-895.mp4"), minimalMP4(7200, 1920, 1080), 0o644); err != nil {
+		t.Fatalf("写入视频失败：%v", err)
+	}
+	if err := os.WriteFile(filepath.Join(in, "CAWD-895.srt"), []byte("1\n"), 0o644); err != nil {
+		t.Fatalf("写入字幕失败：%v", err)
+	}
+
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T", FanartURL: img.URL + "/f.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	rr := Execute(context.Background(), config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1}, reg)
+	if rr.Summary.Processed != 1 {
+		t.Fatalf("期望 1 个 processed：%+v", rr.Items)
+	}
+
+	want := domain.MediaInfo{Container: "mp4", DurationS: 7200, VideoCodec: "h264", Width: 1920, Height: 1080}
+	var probed int
+	for _, f := range rr.Items[0].Files {
+		if f.Media == nil {
+			continue
+		}
+		probed++
+		if *f.Media != want || !strings.HasSuffix(f.Src, ".mp4") {
+			t.Fatalf("report 中的 media 不符合预期：%+v", f)
+		}
+	}
+	if probed != 1 {
+		t.Fatalf("只有视频应带 media：%+v", rr.Items[0].Files)
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, "out", "CAWD-895", "CAWD-895.nfo"))
+	if err != nil {
+		t.Fatalf("读取 NFO 失败：%v", err)
+	}
+	for _, s := range []string{"<runtime>120</runtime>", "<codec>h264</codec>", "<width>1920</width>", "<durationinseconds>7200</durationinseconds>"} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Fatalf("NFO 缺少 %s：%s", s, b)
+		}
+	}
+}
+
+func TestExecute_Apply_DupesKeepBest(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "in")
+	if err := os.MkdirAll(in, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	for name, b := range map[string][]byte{
+		"CAWD-895.mkv": minimalMP4(7200, 1920, 1080), // 魔数决定容器，扩展名不影响解析
+		"CAWD-895.avi": bytes.Repeat([]byte("x"), 4096),
+		"CAWD-895.srt": []byte("1\n"),
+	} {
+		if err := os.WriteFile(filepath.Join(in, name), b, 0o644); err != nil {
+			t.Fatalf("写入文件失败：%v", err)
+		}
+	}
+
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T", FanartURL: img.URL + "/f.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	eff := config.EffectiveConfig{
+		Path: root, Provider: "javbus", Apply: true, Concurrency: 1,
+		DupesMode: domain.DupesKeepBest, DupesDir: filepath.Join(root, "dupes"),
+	}
+	rr := Execute(context.Background(), eff, reg)
+	if rr.Summary.Processed != 1 {
+		t.Fatalf("期望 1 个 processed：%+v", rr.Items)
+	}
+	for _, p := range []string{"out/CAWD-895/CAWD-895.mkv", "dupes/CAWD-895/CAWD-895.avi", "dupes/CAWD-895/CAWD-895.srt"} {
+		if _, err := os.Stat(filepath.Join(root, p)); err != nil {
+			t.Fatalf("期望 %s 存在：%v", p, err)
+		}
+	}
+	dups := map[string]string{}
+	for _, f := range rr.Items[0].Files {
+		dups[f.Dst] = f.Duplicate
+	}
+	if dups[filepath.Join("out", "CAWD-895", "CAWD-895.mkv")] != domain.DuplicateBest || dups[filepath.Join("dupes", "CAWD-895", "CAWD-895.avi")] != domain.DuplicateQuarantined {
+		t.Fatalf("report 中的多版本标记不符合预期：%+v", rr.Items[0].Files)
+	}
+
+	// 再跑一次：隔离目录被排除，不会把次选版本搬回 out/。
+	rr = Execute(context.Background(), eff, reg)
+	if len(rr.Items) != 0 || len(rr.Skipped) != 0 {
+		t.Fatalf("隔离目录不应被扫描：items=%+v skipped=%+v", rr.Items, rr.Skipped)
+	}
+}
+
+func TestExecute_Apply_HashSameContent(t *testing.T) {
+	root := t.TempDir()
+	video := bytes.Repeat([]byte("abc"), 50000) // > 128KiB：首尾采样不重叠
+	for _, p := range []string{"a/SSIS-001.mp4", "b/SSIS-001.mp4", "c/SSIS-001.mp4"} {
+		abs := filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatalf("创建目录失败：%v", err)
+		}
+		if err := os.WriteFile(abs, video, 0o644); err != nil {
+			t.Fatalf("写入文件失败：%v", err)
+		}
+	}
+
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T", FanartURL: img.URL + "/f.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1, Hash: true}
+	rr := Execute(context.Background(), eff, reg)
+	if rr.Summary.Processed != 1 || len(rr.Items[0].Files) != 1 {
+		t.Fatalf("同内容只应整理一份：%+v", rr.Items)
+	}
+	if f := rr.Items[0].Files[0]; f.Hash == "" || f.Src != filepath.Join("a", "SSIS-001.mp4") {
+		t.Fatalf("期望保留字典序最小的路径并记录 hash：%+v", f)
+	}
+	if len(rr.Skipped) != 2 || rr.Skipped[0].Reason != domain.SkipReasonSameContent || rr.Skipped[0].Detail != filepath.Join("a", "SSIS-001.mp4") {
+		t.Fatalf("skipped 不符合预期：%+v", rr.Skipped)
+	}
+
+	// 再跑一次：留在源目录的副本与 out/ 中的文件同内容，不会再移动出 "__2"。
+	rr = Execute(context.Background(), eff, reg)
+	if len(rr.Items) != 0 {
+		t.Fatalf("已在 out/ 中的内容不应再生成工作单元：%+v", rr.Items)
+	}
+	want := filepath.Join("out", "SSIS-001", "SSIS-001.mp4")
+	if len(rr.Skipped) != 2 || rr.Skipped[0].Path != filepath.Join("b", "SSIS-001.mp4") || rr.Skipped[0].Detail != want {
+		t.Fatalf("期望 b/SSIS-001.mp4 因与 %s 同内容被跳过：%+v", want, rr.Skipped)
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "SSIS-001", "SSIS-001__2.mp4")); err == nil {
+		t.Fatalf("不应产生重复文件")
+	}
+}
+
+// gatedProvider 在刮削 release 对应的 CODE 时关闭 fetched，fanart 地址按 CODE 区分。
+type gatedProvider struct {
+	stubProvider
+	imgBase string
+	release domain.Code
+	fetched chan struct{}
+}
+
+func (p gatedProvider) Fetch(ctx context.Context, code domain.Code, c *http.Client) ([]byte, string, error) {
+	if code == p.release {
+		close(p.fetched)
+	}
+	return p.stubProvider.Fetch(ctx, code, c)
+}
+
+func (p gatedProvider) Parse(code domain.Code, html []byte, pageURL string) (domain.MovieMeta, error) {
+	m, err := p.stubProvider.Parse(code, html, pageURL)
+	m.FanartURL = p.imgBase + "/" + string(code) + ".jpg"
+	return m, err
+}
+
+func TestExecute_Apply_PipelineImageDoesNotBlockMeta(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"ABP-001.mp4", "ABP-002.mp4"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入文件失败：%v", err)
+		}
+	}
+
+	// ABP-001 的 fanart 下载要等到 ABP-002 已开始刮削才返回：
+	// 若图片与元数据共用一个 worker，这里会一直等到超时。
+	fetched := make(chan struct{})
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "ABP-001") {
+			select {
+			case <-fetched:
+			case <-time.After(3 * time.Second):
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	release, _ := domain.ParseCode("ABP-002")
+	reg, err := provider.NewRegistry(
+		gatedProvider{stubProvider: stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T"}}, imgBase: img.URL, release: release, fetched: fetched},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	eff := config.EffectiveConfig{
+		Path: root, Provider: "javbus", Apply: true, Concurrency: 1,
+		Pools: config.Pools{Meta: 1, Image: 1, FS: 1},
+	}
+	rr := Execute(context.Background(), eff, reg)
+	if rr.Summary.Processed != 2 {
+		t.Fatalf("期望 2 个 processed（图片阶段不应阻塞元数据阶段）：%+v", rr.Items)
+	}
+	for _, p := range []string{"out/ABP-001/ABP-001.mp4", "out/ABP-001/fanart.jpg", "out/ABP-002/ABP-002.mp4"} {
+		if _, err := os.Stat(filepath.Join(root, p)); err != nil {
+			t.Fatalf("期望 %s 存在：%v", p, err)
+		}
+	}
+}
+
+func TestExecute_Apply_DedupSharedFetches(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"ABP-001.mp4", "ABP-002.mp4"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入文件失败：%v", err)
+		}
+	}
+
+	var hits atomic.Int32
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	// 两个条目指向同一张图片：本次运行内只下载一次。
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T", FanartURL: img.URL + "/shared.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 2}
+	rr := Execute(context.Background(), eff, reg)
+	if rr.Summary.Processed != 2 {
+		t.Fatalf("期望 2 个 processed：%+v", rr.Items)
+	}
+	if hits.Load() != 1 || rr.Summary.FetchesSaved != 1 {
+		t.Fatalf("期望只下载 1 次、fetches_saved=1：hits=%d summary=%+v", hits.Load(), rr.Summary)
+	}
+	// 图片临时文件写在 cache/downloads/，运行结束后清理。
+	if _, err := os.Stat(filepath.Join(root, "cache", "downloads")); !os.IsNotExist(err) {
+		t.Fatalf("运行结束后不应残留 cache/downloads/：%v", err)
+	}
+}
+
+func TestExecute_Apply_DownloadRejectsNonImage(t *testing.T) {
+	// 200 状态码的 HTML 错误页与超过上限的图片都应在下载阶段失败，不写 fanart.jpg、不移动视频。
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/blocked.jpg" {
+			_, _ = w.Write([]byte("<!DOCTYPE html><html><body>rate limited</body></html>"))
+			return
+		}
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	for _, tc := range []struct {
+		url      string
+		maxBytes int64
+		want     string
+	}{
+		{img.URL + "/blocked.jpg", 0, "text/html"},
+		{img.URL + "/big.jpg", int64(len(fanart) - 1), "大小上限"},
+	} {
+		root := t.TempDir()
+		src := filepath.Join(root, "ABP-001.mp4")
+		if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入文件失败：%v", err)
+		}
+		reg, err := provider.NewRegistry(
+			stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "T", FanartURL: tc.url}},
+			stubProvider{name: "javdb"},
+		)
+		if err != nil {
+			t.Fatalf("不期望错误：%v", err)
+		}
+		eff := config.EffectiveConfig{Path: root, Provider: "javbus", Apply: true, Concurrency: 1, DownloadMaxBytes: tc.maxBytes}
+		rr := Execute(context.Background(), eff, reg)
+		if len(rr.Items) != 1 || rr.Items[0].ErrorCode != domain.ErrCodeFetchFailed || !strings.Contains(rr.Items[0].ErrorMsg, tc.want) {
+			t.Fatalf("%s：期望 fetch_failed（%s）：%+v", tc.url, tc.want, rr.Items)
+		}
+		if _, err := os.Stat(filepath.Join(root, "out", "ABP-001", "fanart.jpg")); err == nil {
+			t.Fatalf("%s：不应写入 fanart.jpg", tc.url)
+		}
+		if _, err := os.Stat(src); err != nil {
+			t.Fatalf("%s：视频不应被移动：%v", tc.url, err)
+		}
+	}
+}
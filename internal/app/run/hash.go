@@ -0,0 +1,110 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/fsx"
+)
+
+// contentKey 是同内容判定的键：快速指纹本身包含 size，这里再显式带上以免不同长度的文件误判。
+type contentKey struct {
+	size int64
+	hash string
+}
+
+// hashFiles 为每个视频计算快速指纹（scan.hash=true），并剔除与更早文件内容相同的后续路径
+// （硬链接、复制到多个目录的同一文件）。files 已按 RelPath 排序，保留字典序最小的那份；
+// 被剔除的文件记为 skipped（reason=same_content，detail 为保留的那份）。
+// 读取失败的文件不带指纹照常参与整理：指纹只是附加校验，不应让整理失败。
+func hashFiles(files []domain.VideoFile) ([]domain.VideoFile, []domain.SkippedFile) {
+	seen := make(map[contentKey]string, len(files))
+	kept := files[:0]
+	var skipped []domain.SkippedFile
+	for _, f := range files {
+		h, err := fsx.QuickHash(f.AbsPath)
+		if err != nil {
+			kept = append(kept, f)
+			continue
+		}
+		f.Hash = h
+		k := contentKey{size: f.Size, hash: h}
+		if first, ok := seen[k]; ok {
+			skipped = append(skipped, domain.SkippedFile{
+				Path:   f.RelPath,
+				Reason: domain.SkipReasonSameContent,
+				Detail: first,
+			})
+			continue
+		}
+		seen[k] = f.RelPath
+		kept = append(kept, f)
+	}
+	return kept, skipped
+}
+
+// dropSameAsOut 从工作单元中剔除与 outDir 内已有文件内容相同的视频（上次运行已整理过、
+// 源目录里留下的副本或硬链接）：再次移动只会产生 "__2" 这样的重复文件。
+// 只对与候选视频大小相同的 out 文件计算指纹；outDir 不存在时原样返回。
+func dropSameAsOut(root string, files []domain.VideoFile, it domain.WorkItem, outDir string) (domain.WorkItem, []domain.SkippedFile, error) {
+	sizes := map[int64]struct{}{}
+	for _, idx := range it.FileIdx {
+		if files[idx].Hash != "" {
+			sizes[files[idx].Size] = struct{}{}
+		}
+	}
+	if len(sizes) == 0 {
+		return it, nil, nil
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return it, nil, nil
+		}
+		return it, nil, err
+	}
+	existing := map[contentKey]string{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return it, nil, err
+		}
+		if _, ok := sizes[fi.Size()]; !ok {
+			continue
+		}
+		abs := filepath.Join(outDir, e.Name())
+		h, err := fsx.QuickHash(abs)
+		if err != nil {
+			return it, nil, err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			rel = abs
+		}
+		existing[contentKey{size: fi.Size(), hash: h}] = rel
+	}
+	if len(existing) == 0 {
+		return it, nil, nil
+	}
+
+	out := domain.WorkItem{Code: it.Code, FileIdx: make([]int, 0, len(it.FileIdx))}
+	var skipped []domain.SkippedFile
+	for _, idx := range it.FileIdx {
+		f := files[idx]
+		if rel, ok := existing[contentKey{size: f.Size, hash: f.Hash}]; ok && f.Hash != "" {
+			skipped = append(skipped, domain.SkippedFile{
+				Path:   f.RelPath,
+				Reason: domain.SkipReasonSameContent,
+				Detail: rel,
+			})
+			continue
+		}
+		out.FileIdx = append(out.FileIdx, idx)
+	}
+	return out, skipped, nil
+}
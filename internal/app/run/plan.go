@@ -0,0 +1,226 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/John-Robertt/AVMC/internal/config"
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/cache"
+	"github.com/John-Robertt/AVMC/internal/infra/fsx"
+	"github.com/John-Robertt/AVMC/internal/provider"
+)
+
+// Plan 执行 scan/group/plan，并以 dry-run 语义解析元数据（不落盘、不下载图片、不移动）。
+// 启用 translate 时标题在此翻译并写入 Resolved，apply 直接使用冻结的译文。
+// 返回可审阅的计划文件（只包含 dry-run 未失败的 item）与对应的 dry-run 报告。
+func Plan(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, obs Observer) (domain.PlanFile, domain.RunReport) {
+	eff.Apply = false
+	started := time.Now().UTC()
+
+	if obs != nil {
+		obs.OnStart(eff)
+	}
+
+	pf := domain.PlanFile{
+		Version:   domain.PlanFileVersion,
+		Path:      eff.Path,
+		CreatedAt: started,
+		Items:     []domain.ItemPlan{},
+	}
+	rr := domain.RunReport{
+		Path:      eff.Path,
+		DryRun:    true,
+		StartedAt: started,
+		Items:     make([]domain.ItemResult, 0, 128),
+	}
+
+	metaClient, images, failed, ok := newClients(eff)
+	if !ok {
+		rr.Items = append(rr.Items, failed)
+		rr.FinishedAt = time.Now().UTC()
+		rr.Finalize()
+		return pf, rr
+	}
+	defer images.Close()
+
+	store := cache.New(eff.Path, true)
+
+	plans, absToRel, ok := prepare(eff, &rr, obs)
+	if !ok {
+		rr.FinishedAt = time.Now().UTC()
+		rr.Finalize()
+		return pf, rr
+	}
+	fingerprint(plans)
+
+	// 失败的 item 只出现在报告里；计划文件只保留可以原样执行的部分（按规划顺序）。
+	keep := make([]bool, len(plans))
+	for _, r := range runPlans(ctx, eff, reg, plans, metaClient, images, store, absToRel, obs, true) {
+		rr.Items = append(rr.Items, r.res)
+		if r.res.Status == domain.StatusFailed {
+			continue
+		}
+		plans[r.idx].Resolved = r.resolved
+		keep[r.idx] = true
+	}
+	for i := range plans {
+		if keep[i] {
+			pf.Items = append(pf.Items, plans[i])
+		}
+	}
+	rr.Summary.FetchesSaved = fetchesSaved(metaClient, images)
+
+	rr.FinishedAt = time.Now().UTC()
+	rr.Finalize()
+	return pf, rr
+}
+
+// fingerprint 记录每个源文件的 size/mtime；stat 失败时保持零值（apply 时必然判定为已变化）。
+func fingerprint(plans []domain.ItemPlan) {
+	for i := range plans {
+		for j := range plans[i].Moves {
+			mv := &plans[i].Moves[j]
+			fi, err := os.Stat(mv.SrcAbs)
+			if err != nil {
+				continue
+			}
+			mv.SrcSize = fi.Size()
+			mv.SrcModNano = fi.ModTime().UnixNano()
+		}
+	}
+}
+
+// ApplyPlan 以 apply 语义严格执行 plan 文件：不重新扫描/规划/刮削。
+// 任一源文件 size/mtime 与规划时不同（或已不存在）的 item 会被拒绝（source_changed），其余 item 照常执行。
+func ApplyPlan(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, pf domain.PlanFile, obs Observer) domain.RunReport {
+	eff.Apply = true
+	started := time.Now().UTC()
+
+	if obs != nil {
+		obs.OnStart(eff)
+	}
+
+	rr := domain.RunReport{
+		Path:      eff.Path,
+		DryRun:    false,
+		StartedAt: started,
+		Items:     make([]domain.ItemResult, 0, len(pf.Items)),
+	}
+
+	if err := checkPlanFile(eff, pf); err != nil {
+		rr.Items = append(rr.Items, syntheticFailed(domain.ErrCodePlanInvalid, err.Error()))
+		rr.FinishedAt = time.Now().UTC()
+		rr.Finalize()
+		return rr
+	}
+
+	metaClient, images, failed, ok := newClients(eff)
+	if !ok {
+		rr.Items = append(rr.Items, failed)
+		rr.FinishedAt = time.Now().UTC()
+		rr.Finalize()
+		return rr
+	}
+	defer images.Close()
+
+	store := cache.New(eff.Path, false)
+
+	ready := make([]domain.ItemPlan, 0, len(pf.Items))
+	for _, p := range pf.Items {
+		if code, msg := verifyPlan(eff, p); code != "" {
+			item := domain.ItemResult{
+				Code:              string(p.Code),
+				ProviderRequested: p.ProviderRequested,
+				Candidates:        []string{},
+				Attempts:          []domain.ProviderAttempt{},
+				Files:             buildFileResults(eff, p, map[string]string{}),
+			}
+			failItem(&item, code, msg)
+			rr.Items = append(rr.Items, item)
+			continue
+		}
+		ready = append(ready, p)
+	}
+
+	rr.Items = append(rr.Items, execPlans(ctx, eff, reg, ready, metaClient, images, store, map[string]string{}, obs)...)
+	rr.Summary.FetchesSaved = fetchesSaved(metaClient, images)
+
+	rr.FinishedAt = time.Now().UTC()
+	rr.Finalize()
+	return rr
+}
+
+// checkPlanFile 校验 plan 文件整体是否可用于当前配置。
+func checkPlanFile(eff config.EffectiveConfig, pf domain.PlanFile) error {
+	if pf.Version != domain.PlanFileVersion {
+		return fmt.Errorf("不支持的 plan 文件版本：%d（当前为 %d）", pf.Version, domain.PlanFileVersion)
+	}
+	if filepath.Clean(pf.Path) != filepath.Clean(eff.Path) {
+		return fmt.Errorf("plan 文件的 path（%s）与当前 path（%s）不一致", pf.Path, eff.Path)
+	}
+	return nil
+}
+
+// verifyPlan 在执行前重新核对单个 item：路径必须仍在 <path> 与 <path>/out/ 之内（隔离的次选版本在 dupes.dir 之内）、
+// 源文件指纹不变、目标不存在。返回空 code 表示可执行。
+func verifyPlan(eff config.EffectiveConfig, p domain.ItemPlan) (code, msg string) {
+	outRoot := filepath.Join(eff.Path, "out")
+	outDir := p.OutDir
+	if outDir == "" {
+		outDir = filepath.Join(outRoot, string(p.Code))
+	}
+	if !isStrictlyUnder(outRoot, outDir) {
+		return domain.ErrCodePlanInvalid, fmt.Sprintf("目标目录不在 out/ 之内：%s", outDir)
+	}
+	if p.Need.NeedScrape && p.Resolved == nil {
+		return domain.ErrCodePlanInvalid, "计划需要刮削但未携带已解析的元数据；请重新运行 avmc plan"
+	}
+
+	for _, mv := range p.Moves {
+		dstDir := filepath.Clean(outDir)
+		if mv.Duplicate == domain.DuplicateQuarantined && eff.DupesDir != "" {
+			dstDir = filepath.Join(eff.DupesDir, string(p.Code))
+		}
+		if filepath.Dir(mv.DstAbs) != dstDir {
+			return domain.ErrCodePlanInvalid, fmt.Sprintf("目标路径不在目标目录之内：%s", mv.DstAbs)
+		}
+		if !isStrictlyUnder(eff.Path, mv.SrcAbs) || isStrictlyUnder(outRoot, mv.SrcAbs) {
+			return domain.ErrCodePlanInvalid, fmt.Sprintf("源路径不在 path 之内或位于 out/ 下：%s", mv.SrcAbs)
+		}
+
+		fi, err := os.Stat(mv.SrcAbs)
+		if err != nil {
+			return domain.ErrCodeSourceChanged, fmt.Sprintf("源文件在规划后已不可用：%v", err)
+		}
+		if fi.Size() != mv.SrcSize || fi.ModTime().UnixNano() != mv.SrcModNano {
+			return domain.ErrCodeSourceChanged, fmt.Sprintf("源文件在规划后已被修改（size/mtime 不一致）：%s", mv.SrcAbs)
+		}
+		if mv.Hash != "" {
+			if h, err := fsx.QuickHash(mv.SrcAbs); err != nil || h != mv.Hash {
+				return domain.ErrCodeSourceChanged, fmt.Sprintf("源文件在规划后内容已变化（hash 不一致）：%s", mv.SrcAbs)
+			}
+		}
+
+		if _, err := os.Lstat(mv.DstAbs); err == nil {
+			return domain.ErrCodeTargetConflict, fmt.Sprintf("目标文件在规划后已存在：%s", mv.DstAbs)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return domain.ErrCodeIOFailed, fmt.Sprintf("检查目标文件失败：%v", err)
+		}
+	}
+	return "", ""
+}
+
+// isStrictlyUnder 判断 p 是否位于 root 之下（不含 root 本身）。
+func isStrictlyUnder(root, p string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(p))
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,190 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/config"
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/provider"
+)
+
+func TestPlanThenApplyPlan_RefusesChangedSources(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "in")
+	if err := os.MkdirAll(in, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	for _, name := range []string{"CAWD-895.mp4", "ABP-123.mp4"} {
+		if err := os.WriteFile(filepath.Join(in, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入视频失败：%v", err)
+		}
+	}
+
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "规划时标题", FanartURL: img.URL + "/f.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Concurrency: 2}
+
+	pf, rr := Plan(context.Background(), eff, reg, nil)
+	if rr.Summary.Failed != 0 || !rr.DryRun {
+		t.Fatalf("plan 报告不符合预期：%+v", rr)
+	}
+	if _, err := os.Stat(filepath.Join(root, "cache")); !os.IsNotExist(err) {
+		t.Fatalf("plan 不应创建 cache/，但 Stat err=%v", err)
+	}
+	if len(pf.Items) != 2 || pf.Version != domain.PlanFileVersion {
+		t.Fatalf("plan 文件不符合预期：%+v", pf)
+	}
+	for _, p := range pf.Items {
+		if p.Resolved == nil || p.Resolved.Meta.Title != "规划时标题" || p.Moves[0].SrcSize != 1 || p.Moves[0].SrcModNano == 0 {
+			t.Fatalf("plan item 应携带元数据与指纹：%+v", p)
+		}
+	}
+
+	// 经 JSON 往返（与 CLI 一致），期间修改其中一个源文件。
+	b, err := json.Marshal(pf)
+	if err != nil {
+		t.Fatalf("序列化失败：%v", err)
+	}
+	var loaded domain.PlanFile
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		t.Fatalf("反序列化失败：%v", err)
+	}
+	changed := filepath.Join(in, "ABP-123.mp4")
+	if err := os.WriteFile(changed, []byte("xyz"), 0o644); err != nil {
+		t.Fatalf("修改视频失败：%v", err)
+	}
+
+	// apply 不应重新刮削：换一个返回不同标题的 registry。
+	reg2, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "重新刮削标题"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	out := ApplyPlan(context.Background(), eff, reg2, loaded, nil)
+	if out.DryRun || out.Summary.Processed != 1 || out.Summary.Failed != 1 {
+		t.Fatalf("期望 1 个成功、1 个拒绝：%+v", out.Items)
+	}
+	for _, it := range out.Items {
+		switch it.Code {
+		case "ABP-123":
+			if it.ErrorCode != domain.ErrCodeSourceChanged || it.Files[0].Status != domain.FileStatusFailed {
+				t.Fatalf("源文件已修改的 item 应被拒绝：%+v", it)
+			}
+		case "CAWD-895":
+			if it.Status != domain.StatusProcessed || it.Files[0].Status != domain.FileStatusMoved {
+				t.Fatalf("未修改的 item 应正常执行：%+v", it)
+			}
+		}
+	}
+	if _, err := os.Stat(changed); err != nil {
+		t.Fatalf("被拒绝的视频不应移动：%v", err)
+	}
+	nfoBytes, err := os.ReadFile(filepath.Join(root, "out", "CAWD-895", "CAWD-895.nfo"))
+	if err != nil {
+		t.Fatalf("读取 NFO 失败：%v", err)
+	}
+	if !strings.Contains(string(nfoBytes), "规划时标题") {
+		t.Fatalf("NFO 应使用计划中的元数据：%s", nfoBytes)
+	}
+}
+
+func TestApplyPlan_RejectsInvalidPlans(t *testing.T) {
+	root := t.TempDir()
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Concurrency: 1}
+
+	rr := ApplyPlan(context.Background(), eff, provider.Registry{}, domain.PlanFile{Version: 99, Path: root}, nil)
+	if len(rr.Items) != 1 || rr.Items[0].ErrorCode != domain.ErrCodePlanInvalid {
+		t.Fatalf("未知版本应返回 plan_invalid：%+v", rr.Items)
+	}
+
+	// 目标越出 out/：拒绝执行。
+	src := filepath.Join(root, "CAWD-895.mp4")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatalf("写入视频失败：%v", err)
+	}
+	pf := domain.PlanFile{Version: domain.PlanFileVersion, Path: root, Items: []domain.ItemPlan{{
+		Code:   "CAWD-895",
+		OutDir: filepath.Join(root, "elsewhere"),
+		Moves:  []domain.MovePlan{{SrcAbs: src, DstAbs: filepath.Join(root, "elsewhere", "CAWD-895.mp4")}},
+	}}}
+	rr = ApplyPlan(context.Background(), eff, provider.Registry{}, pf, nil)
+	if len(rr.Items) != 1 || rr.Items[0].ErrorCode != domain.ErrCodePlanInvalid {
+		t.Fatalf("越界计划应返回 plan_invalid：%+v", rr.Items)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Fatalf("越界计划不应移动视频：%v", err)
+	}
+}
+
+func TestPlanThenApplyPlan_FreezesTranslation(t *testing.T) {
+	root := t.TempDir()
+	in := filepath.Join(root, "in")
+	if err := os.MkdirAll(in, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	if err := os.WriteFile(filepath.Join(in, "CAWD-895.mp4"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("写入视频失败：%v", err)
+	}
+
+	fanart := mustFanartJPEG(t, 200, 100)
+	img := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(fanart)
+	}))
+	defer img.Close()
+
+	reg, err := provider.NewRegistry(
+		stubProvider{name: "javbus", meta: domain.MovieMeta{Title: "原題", FanartURL: img.URL + "/f.jpg"}},
+		stubProvider{name: "javdb"},
+	)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	tr := &stubTranslator{out: "译名"}
+	eff := config.EffectiveConfig{Path: root, Provider: "javbus", Concurrency: 1, Translator: tr}
+
+	pf, rr := Plan(context.Background(), eff, reg, nil)
+	if rr.Summary.Failed != 0 || len(pf.Items) != 1 {
+		t.Fatalf("plan 不符合预期：%+v", rr.Items)
+	}
+	if m := pf.Items[0].Resolved.Meta; m.Title != "译名" || m.OriginalTitle != "原題" || tr.calls != 1 {
+		t.Fatalf("plan 应冻结译文：%+v calls=%d", m, tr.calls)
+	}
+
+	// apply 使用计划中的译文：后端即使出错也不会被调用。
+	tr2 := &stubTranslator{err: errors.New("不应被调用")}
+	eff.Translator = tr2
+	out := ApplyPlan(context.Background(), eff, reg, pf, nil)
+	if out.Summary.Processed != 1 || tr2.calls != 0 {
+		t.Fatalf("apply 不应重新翻译：%+v calls=%d", out.Items, tr2.calls)
+	}
+	nfoBytes, err := os.ReadFile(filepath.Join(root, "out", "CAWD-895", "CAWD-895.nfo"))
+	if err != nil {
+		t.Fatalf("读取 NFO 失败：%v", err)
+	}
+	if !strings.Contains(string(nfoBytes), "译名") {
+		t.Fatalf("NFO 应使用计划中的译文：%s", nfoBytes)
+	}
+}
@@ -5,15 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/John-Robertt/AVMC/internal/actors"
 	"github.com/John-Robertt/AVMC/internal/app"
 	"github.com/John-Robertt/AVMC/internal/app/planner"
 	"github.com/John-Robertt/AVMC/internal/config"
@@ -22,6 +23,7 @@ import (
 	"github.com/John-Robertt/AVMC/internal/infra/fsx"
 	"github.com/John-Robertt/AVMC/internal/infra/httpx"
 	"github.com/John-Robertt/AVMC/internal/infra/imgx"
+	"github.com/John-Robertt/AVMC/internal/infra/mediax"
 	"github.com/John-Robertt/AVMC/internal/nfo"
 	"github.com/John-Robertt/AVMC/internal/provider"
 	"github.com/John-Robertt/AVMC/internal/scan"
@@ -48,50 +50,74 @@ func ExecuteWithObserver(ctx context.Context, eff config.EffectiveConfig, reg pr
 		Items:     make([]domain.ItemResult, 0, 128),
 	}
 
-	metaClient, err := httpx.NewMetaClient(eff.ProxyURL)
-	if err != nil {
-		rr.Items = append(rr.Items, syntheticFailed(domain.ErrCodeConfigInvalid, fmt.Sprintf("proxy.url 无效：%v", err)))
+	metaClient, images, failed, ok := newClients(eff)
+	if !ok {
+		rr.Items = append(rr.Items, failed)
 		rr.FinishedAt = time.Now().UTC()
 		rr.Finalize()
 		return rr
 	}
+	defer images.Close()
 
-	var imageClient *http.Client
-	if eff.Apply {
-		ic, e := httpx.NewImageClient(eff.ProxyURL, eff.ImageProxy)
-		if e != nil {
-			rr.Items = append(rr.Items, syntheticFailed(domain.ErrCodeConfigInvalid, e.Error()))
-			rr.FinishedAt = time.Now().UTC()
-			rr.Finalize()
-			return rr
-		}
-		imageClient = ic
+	store := cache.New(eff.Path, !eff.Apply)
+
+	plans, absToRel, ok := prepare(eff, &rr, obs)
+	if !ok {
+		rr.FinishedAt = time.Now().UTC()
+		rr.Finalize()
+		return rr
 	}
 
-	store := cache.New(eff.Path, !eff.Apply)
+	rr.Items = append(rr.Items, execPlans(ctx, eff, reg, plans, metaClient, images, store, absToRel, obs)...)
+	rr.Summary.FetchesSaved = fetchesSaved(metaClient, images)
+
+	rr.FinishedAt = time.Now().UTC()
+	rr.Finalize()
+	return rr
+}
 
+// prepare 是 scan/group/plan 三个阶段：unmatched 与规划失败直接写入 rr，返回待执行的计划。
+// ok=false 表示遇到全局失败（已写入 rr），调用方应直接收尾。
+func prepare(eff config.EffectiveConfig, rr *domain.RunReport, obs Observer) (plans []domain.ItemPlan, absToRel map[string]string, ok bool) {
 	scanStarted := time.Now()
-	files, err := scan.ScanVideos(eff.Path, eff.ExcludeDirs)
+	scanned, err := scan.Scan(eff.Path, scanOptions(eff))
 	if err != nil {
 		rr.Items = append(rr.Items, syntheticFailed(domain.ErrCodeIOFailed, fmt.Sprintf("扫描失败：%v", err)))
-		rr.FinishedAt = time.Now().UTC()
-		rr.Finalize()
-		return rr
+		return nil, nil, false
+	}
+	files := scanned.Files
+	rr.Skipped = scanned.Skipped
+	if eff.Hash {
+		// 指纹只读首尾各 64KiB；计入 scan 阶段耗时。
+		var same []domain.SkippedFile
+		files, same = hashFiles(files)
+		rr.Skipped = append(rr.Skipped, same...)
 	}
 	scanDur := time.Since(scanStarted)
 
-	absToRel := make(map[string]string, len(files))
+	absToRel = make(map[string]string, len(files))
 	for i := range files {
 		absToRel[files[i].AbsPath] = files[i].RelPath
 	}
 
 	groupStarted := time.Now()
-	items, unmatched, err := app.GroupByCode(files)
+	items, unmatched, err := app.GroupByCodeWithOverrides(files, eff.Code, eff.Overrides)
 	if err != nil {
 		rr.Items = append(rr.Items, syntheticFailed(domain.ErrCodeIOFailed, fmt.Sprintf("分组失败：%v", err)))
-		rr.FinishedAt = time.Now().UTC()
-		rr.Finalize()
-		return rr
+		return nil, nil, false
+	}
+	// out/ 目录按规范 CODE 建索引：规范化规则变更前建立的 out/ABP-01/ 同样归到 ABP-001 并被复用。
+	outDirs, err := planner.IndexOutDirs(eff.Path, eff.Code)
+	if err != nil {
+		rr.Items = append(rr.Items, syntheticFailed(domain.ErrCodeIOFailed, fmt.Sprintf("读取 out/ 失败：%v", err)))
+		return nil, nil, false
+	}
+	outCodes := 0
+	if eff.FillOut {
+		// 已整理的 out/<CODE>/ 也作为工作单元（zero move），让缺失的 sidecar 无需新视频即可补齐。
+		codes := outDirs.Codes()
+		outCodes = len(codes)
+		items = app.AppendCodes(items, codes)
 	}
 	groupDur := time.Since(groupStarted)
 
@@ -100,9 +126,11 @@ func ExecuteWithObserver(ctx context.Context, eff config.EffectiveConfig, reg pr
 		obs.OnPhaseDone("scan", map[string]any{
 			"files":     len(files),
 			"unmatched": len(unmatched),
+			"skipped":   len(rr.Skipped),
 		}, scanDur)
 		obs.OnPhaseDone("group", map[string]any{
-			"codes": len(items),
+			"codes":     len(items),
+			"out_codes": outCodes,
 		}, groupDur)
 	}
 
@@ -112,21 +140,58 @@ func ExecuteWithObserver(ctx context.Context, eff config.EffectiveConfig, reg pr
 	}
 
 	planStarted := time.Now()
-	plans := make([]domain.ItemPlan, 0, len(items))
+	plans = make([]domain.ItemPlan, 0, len(items))
 	for _, it := range items {
-		st, e := planner.ReadOutState(eff.Path, it.Code)
+		ov, hasOv := eff.Overrides.ForCode(it.Code)
+		applied := itemOverrides(eff.Overrides, files, it)
+		if hasOv && ov.Ignore {
+			rr.Items = append(rr.Items, ignoredItem(eff.Provider, it, files, absToRel, append(applied, domain.OverrideIgnore)))
+			continue
+		}
+		providerRequested := eff.Provider
+		if hasOv && ov.Provider != "" {
+			providerRequested = ov.Provider
+		}
+
+		dirName := string(it.Code)
+		if eff.MarkerFolderSuffix {
+			dirName += planner.ItemMarkers(files, it).Suffix()
+		}
+		st, e := planner.ReadOutStateWith(eff.Path, it.Code, dirName, outDirs)
 		if e != nil {
-			rr.Items = append(rr.Items, failedPlanItem(eff.Provider, it, files, absToRel, domain.ErrCodeIOFailed, fmt.Sprintf("读取 out 状态失败：%v", e)))
+			rr.Items = append(rr.Items, failedPlanItem(providerRequested, it, files, absToRel, domain.ErrCodeIOFailed, fmt.Sprintf("读取 out 状态失败：%v", e)))
 			continue
 		}
-		p, e := planner.PlanItem(eff.Provider, files, it, st)
+		if eff.Hash {
+			had := len(it.FileIdx)
+			var same []domain.SkippedFile
+			it, same, e = dropSameAsOut(eff.Path, files, it, st.OutDir)
+			if e != nil {
+				rr.Items = append(rr.Items, failedPlanItem(providerRequested, it, files, absToRel, domain.ErrCodeIOFailed, fmt.Sprintf("读取 out 状态失败：%v", e)))
+				continue
+			}
+			rr.Skipped = append(rr.Skipped, same...)
+			if had > 0 && len(it.FileIdx) == 0 && !eff.FillOut {
+				// 视频都已在 out/ 中：本单元无事可做（补齐 sidecar 由 fill_out 负责）。
+				continue
+			}
+		}
+		p, e := planner.PlanItemWithOptions(providerRequested, files, it, st, planOptions(eff))
 		if e != nil {
-			rr.Items = append(rr.Items, failedPlanItem(eff.Provider, it, files, absToRel, domain.ErrCodeIOFailed, fmt.Sprintf("规划失败：%v", e)))
+			rr.Items = append(rr.Items, failedPlanItem(providerRequested, it, files, absToRel, domain.ErrCodeIOFailed, fmt.Sprintf("规划失败：%v", e)))
 			continue
 		}
+		p.Overrides = applied
+		if hasOv {
+			attachOverride(&p, ov)
+		}
 		plans = append(plans, p)
 	}
 	planDur := time.Since(planStarted)
+	if eff.Hash {
+		// same_content 追加自两处；与扫描结果一样按路径排序，保证报告稳定。
+		sort.Slice(rr.Skipped, func(i, j int) bool { return rr.Skipped[i].Path < rr.Skipped[j].Path })
+	}
 
 	if obs != nil {
 		var needScrape, needNFO, needFanart, needPoster, moves int
@@ -157,66 +222,243 @@ func ExecuteWithObserver(ctx context.Context, eff config.EffectiveConfig, reg pr
 		}, planDur)
 	}
 
-	// 执行阶段：按 CODE 并发（worker pool），item 内串行。
-	workers := eff.Concurrency
-	if workers < 1 {
-		workers = 1
+	return plans, absToRel, true
+}
+
+// scanOptions 把配置中的扫描过滤规则映射为 scan.Options。
+func scanOptions(eff config.EffectiveConfig) scan.Options {
+	excludeDirs := eff.ExcludeDirs
+	if eff.DupesDir != "" {
+		// 隔离目录中的次选版本不能在下一次运行时被重新扫描进 out/。
+		excludeDirs = append(append([]string(nil), excludeDirs...), eff.DupesDir)
+	}
+	return scan.Options{
+		ExcludeDirs:     excludeDirs,
+		VideoExts:       eff.VideoExts,
+		MinSize:         eff.MinSizeBytes,
+		ExcludePatterns: eff.ExcludePatterns,
+	}
+}
+
+// planOptions 把配置中的可选 artwork 开关与多版本规则映射为 planner.Options。
+func planOptions(eff config.EffectiveConfig) planner.Options {
+	return planner.Options{
+		Thumb:       eff.Thumb,
+		Landscape:   eff.Landscape,
+		Extrafanart: eff.ExtrafanartMax,
+		Dupes: planner.Dupes{
+			Mode:   eff.DupesMode,
+			Prefer: eff.DupesPrefer,
+			Dir:    eff.DupesDir,
+			Probe:  mediax.Probe,
+		},
 	}
+}
+
+// ExecutePlans 跳过 scan/group/plan，直接执行调用方给定的计划（例如 audit --fix 的“只补齐 sidecar”）。
+// dry-run/apply 语义与 ExecuteWithObserver 完全一致。
+func ExecutePlans(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, plans []domain.ItemPlan, obs Observer) domain.RunReport {
+	started := time.Now().UTC()
 
 	if obs != nil {
-		obs.OnPhaseDone("exec", map[string]any{
-			"workers":     workers,
-			"total_items": len(plans),
-		}, 0)
+		obs.OnStart(eff)
+	}
+
+	rr := domain.RunReport{
+		Path:      eff.Path,
+		DryRun:    !eff.Apply,
+		StartedAt: started,
+		Items:     make([]domain.ItemResult, 0, len(plans)),
+	}
+
+	metaClient, images, failed, ok := newClients(eff)
+	if !ok {
+		rr.Items = append(rr.Items, failed)
+		rr.FinishedAt = time.Now().UTC()
+		rr.Finalize()
+		return rr
+	}
+	defer images.Close()
+
+	store := cache.New(eff.Path, !eff.Apply)
+	rr.Items = append(rr.Items, execPlans(ctx, eff, reg, plans, metaClient, images, store, map[string]string{}, obs)...)
+	rr.Summary.FetchesSaved = fetchesSaved(metaClient, images)
+
+	rr.FinishedAt = time.Now().UTC()
+	rr.Finalize()
+	return rr
+}
+
+// newClients 构造 meta client 与图片下载器；失败时返回可直接写入报告的合成条目。
+// 图片下载器仅 apply 需要（dry-run 不下载图片），调用方用完后 Close 清理临时文件。
+// 同一次运行内的重复请求只发出一次，节省的次数写入 summary.fetches_saved：
+//   - meta client 包一层 httpx.Dedup（多个条目指向同一详情页、降级 provider 重复搜索）；
+//   - 图片走 httpx.Downloads（流式写入 cache/downloads/，按 URL 复用已下载的临时文件，保留总量有上限），
+//     不经过会整体读入内存的 Dedup。
+func newClients(eff config.EffectiveConfig) (metaClient *http.Client, images *httpx.Downloads, failed domain.ItemResult, ok bool) {
+	mc, err := httpx.NewMetaClient(eff.ProxyURL)
+	if err != nil {
+		return nil, nil, syntheticFailed(domain.ErrCodeConfigInvalid, fmt.Sprintf("proxy.url 无效：%v", err)), false
+	}
+	mc.Transport = httpx.NewDedup(mc.Transport)
+	if !eff.Apply {
+		return mc, nil, domain.ItemResult{}, true
+	}
+	ic, err := httpx.NewImageClient(eff.ProxyURL, eff.ImageProxy)
+	if err != nil {
+		return nil, nil, syntheticFailed(domain.ErrCodeConfigInvalid, err.Error()), false
+	}
+	dl := httpx.NewDownloads(ic)
+	dl.Dir = cache.New(eff.Path, false).DownloadDir()
+	return mc, dl, domain.ItemResult{}, true
+}
+
+// fetchesSaved 汇总 meta client 与图片下载器因合并/复用而省下的请求数。
+func fetchesSaved(metaClient *http.Client, images *httpx.Downloads) int {
+	n := images.Saved()
+	if metaClient != nil {
+		if d, ok := metaClient.Transport.(*httpx.Dedup); ok {
+			n += d.Saved()
+		}
 	}
+	return n
+}
 
-	type execResult struct {
-		code domain.Code
-		res  domain.ItemResult
-		dur  time.Duration
+// execPlans 是执行阶段：按 CODE 并发，meta/image/fs 三段流水线（见 runPlans），item 内各步骤顺序不变。
+func execPlans(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, plans []domain.ItemPlan, metaClient *http.Client, images *httpx.Downloads, store cache.Store, absToRel map[string]string, obs Observer) []domain.ItemResult {
+	rs := runPlans(ctx, eff, reg, plans, metaClient, images, store, absToRel, obs, false)
+	out := make([]domain.ItemResult, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, r.res)
 	}
+	return out
+}
+
+// execResult 是单个计划的执行结果；idx 指向 plans 下标，resolved 是本次成功刮削的元数据（否则为 nil）。
+type execResult struct {
+	idx      int
+	code     domain.Code
+	res      domain.ItemResult
+	resolved *domain.ResolvedMeta
+	dur      time.Duration
+}
 
-	jobs := make(chan domain.ItemPlan)
+// runPlans 是 execPlans 的底层实现：额外返回每个计划的解析结果（plan 文件工作流需要）。
+// 返回顺序为完成顺序。freeze=true（生成 plan 文件）时 dry-run 也翻译标题，译文随解析结果一起冻结。
+//
+// 执行是三段流水线，每段一个独立大小的 worker pool（eff.Pools），段间是有界队列：
+//   - meta：刮削与标题翻译（代理/站点限速不会拖住本地移动）
+//   - image：图片下载与 sidecar 写入（图片 CDN 慢不会拖住元数据请求）
+//   - fs：视频移动
+//
+// 单个 item 仍按 meta → image → fs 依次推进，任一阶段得出结论（跳过/失败/dry-run）即直接产出结果，
+// 因此“sidecar 失败禁止移动、移动最后一步”的保证不变。
+func runPlans(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, plans []domain.ItemPlan, metaClient *http.Client, images *httpx.Downloads, store cache.Store, absToRel map[string]string, obs Observer, freeze bool) []execResult {
+	pools := poolSizes(eff)
+
+	if obs != nil {
+		obs.OnPhaseDone("exec", map[string]any{
+			"workers":       pools.Meta,
+			"image_workers": pools.Image,
+			"fs_workers":    pools.FS,
+			"total_items":   len(plans),
+		}, 0)
+	}
+
+	jobs := make(chan int)
+	imageQ := make(chan *itemRun, pools.Image)
+	fsQ := make(chan *itemRun, pools.FS)
 	results := make(chan execResult, len(plans))
 
-	var wg sync.WaitGroup
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for p := range jobs {
-				oneStarted := time.Now()
-				r := execOne(ctx, eff, p, reg, metaClient, imageClient, store, absToRel)
-				results <- execResult{
-					code: p.Code,
-					res:  r,
-					dur:  time.Since(oneStarted),
-				}
+	// emit 把已有结论的 item 交给结果通道，否则送入下一段队列。
+	emit := func(r *itemRun, next chan<- *itemRun) {
+		if r.done || next == nil {
+			results <- execResult{
+				idx:      r.idx,
+				code:     r.p.Code,
+				res:      r.item,
+				resolved: r.resolved,
+				dur:      time.Since(r.started),
 			}
-		}()
+			return
+		}
+		next <- r
 	}
 
+	metaWG := startPool(pools.Meta, func() {
+		for idx := range jobs {
+			r := newItemRun(eff, plans[idx], absToRel)
+			r.idx = idx
+			r.freeze = freeze
+			metaStage(ctx, eff, reg, metaClient, store, r)
+			emit(r, imageQ)
+		}
+	})
+	imageWG := startPool(pools.Image, func() {
+		for r := range imageQ {
+			imageStage(ctx, eff, reg, metaClient, images, r)
+			emit(r, fsQ)
+		}
+	})
+	fsWG := startPool(pools.FS, func() {
+		for r := range fsQ {
+			fsStage(r)
+			emit(r, nil)
+		}
+	})
+
 	go func() {
-		for _, p := range plans {
-			jobs <- p
+		for i := range plans {
+			jobs <- i
 		}
 		close(jobs)
-		wg.Wait()
+		metaWG.Wait()
+		close(imageQ)
+		imageWG.Wait()
+		close(fsQ)
+		fsWG.Wait()
 		close(results)
 	}()
 
+	out := make([]execResult, 0, len(plans))
 	done := 0
 	for it := range results {
 		done++
-		rr.Items = append(rr.Items, it.res)
+		out = append(out, it)
 		if obs != nil {
 			obs.OnItemDone(done, len(plans), it.code, it.res, it.dur)
 		}
 	}
 
-	rr.FinishedAt = time.Now().UTC()
-	rr.Finalize()
-	return rr
+	return out
+}
+
+// poolSizes 返回各阶段 worker 数；未设置（0）的按 Concurrency 兜底，至少为 1。
+func poolSizes(eff config.EffectiveConfig) config.Pools {
+	def := eff.Concurrency
+	if def < 1 {
+		def = 1
+	}
+	p := eff.Pools
+	for _, n := range []*int{&p.Meta, &p.Image, &p.FS} {
+		if *n < 1 {
+			*n = def
+		}
+	}
+	return p
+}
+
+// startPool 启动 n 个执行 work 的 goroutine，返回可等待其全部退出的 WaitGroup。
+func startPool(n int, work func()) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	return &wg
 }
 
 func unmatchedItem(u domain.Unmatched) domain.ItemResult {
@@ -273,6 +515,57 @@ func failedPlanItem(providerRequested string, it domain.WorkItem, files []domain
 	return out
 }
 
+// itemOverrides 返回规划前已生效的覆盖项：item 内任一文件的 CODE 由 overrides.files 指定时记为 file_code。
+func itemOverrides(ov domain.Overrides, files []domain.VideoFile, it domain.WorkItem) []string {
+	for _, idx := range it.FileIdx {
+		if idx < 0 || idx >= len(files) {
+			continue
+		}
+		if _, ok := ov.Files[filepath.ToSlash(files[idx].RelPath)]; ok {
+			return []string{domain.OverrideFileCode}
+		}
+	}
+	return nil
+}
+
+// ApplyOverrides 把覆盖规则附加到调用方给定的计划上（例如 audit --fix），并剔除被标记为 ignore 的 CODE。
+func ApplyOverrides(ov domain.Overrides, plans []domain.ItemPlan) []domain.ItemPlan {
+	out := make([]domain.ItemPlan, 0, len(plans))
+	for _, p := range plans {
+		if co, ok := ov.ForCode(p.Code); ok {
+			if co.Ignore {
+				continue
+			}
+			attachOverride(&p, co)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// attachOverride 记录 CODE 级覆盖规则：固定 provider 时同步 provider_requested。
+func attachOverride(p *domain.ItemPlan, ov domain.CodeOverride) {
+	p.Override = &ov
+	if ov.Provider != "" {
+		p.ProviderRequested = ov.Provider
+		p.Overrides = append(p.Overrides, domain.OverrideProvider)
+	}
+	if ov.URL != "" {
+		p.Overrides = append(p.Overrides, domain.OverrideURL)
+	}
+}
+
+// ignoredItem 是被 overrides 标记为 ignore 的 CODE：不刮削、不写入、不移动，文件原地保留。
+func ignoredItem(providerRequested string, it domain.WorkItem, files []domain.VideoFile, absToRel map[string]string, applied []string) domain.ItemResult {
+	out := failedPlanItem(providerRequested, it, files, absToRel, "", "")
+	out.Status = domain.StatusSkipped
+	out.Overrides = applied
+	for i := range out.Files {
+		out.Files[i].Status = domain.FileStatusIgnored
+	}
+	return out
+}
+
 func syntheticFailed(code, msg string) domain.ItemResult {
 	return domain.ItemResult{
 		Code:              "",
@@ -288,65 +581,130 @@ func syntheticFailed(code, msg string) domain.ItemResult {
 	}
 }
 
-func execOne(ctx context.Context, eff config.EffectiveConfig, p domain.ItemPlan, reg provider.Registry, metaClient, imageClient *http.Client, store cache.Store, absToRel map[string]string) domain.ItemResult {
-	item := domain.ItemResult{
-		Code:              string(p.Code),
-		ProviderRequested: p.ProviderRequested,
-		ProviderUsed:      "",
-		Website:           "",
-		Status:            domain.StatusProcessed, // 失败时覆盖
-		ErrorCode:         "",
-		ErrorMsg:          "",
-		Candidates:        []string{},
-		Attempts:          []domain.ProviderAttempt{},
-		Files:             buildFileResults(eff, p, absToRel),
+// itemRun 是单个计划在 meta → image → fs 三个阶段之间传递的状态。
+// done=true 表示 item 已有结论（跳过、失败或 dry-run 结束），后续阶段直接透传。
+type itemRun struct {
+	idx      int
+	p        domain.ItemPlan
+	item     domain.ItemResult
+	resolved *domain.ResolvedMeta
+	meta     domain.MovieMeta
+	media    *domain.MediaInfo
+	outDir   string
+	done     bool
+	started  time.Time
+
+	// freeze 表示结果要写入 plan 文件：dry-run 也翻译标题，apply 时不再重新翻译。
+	freeze bool
+}
+
+func newItemRun(eff config.EffectiveConfig, p domain.ItemPlan, absToRel map[string]string) *itemRun {
+	outDir := p.OutDir
+	if outDir == "" {
+		outDir = filepath.Join(eff.Path, "out", string(p.Code))
+	}
+	return &itemRun{
+		p: p,
+		item: domain.ItemResult{
+			Code:              string(p.Code),
+			ProviderRequested: p.ProviderRequested,
+			ProviderUsed:      "",
+			Website:           "",
+			Status:            domain.StatusProcessed, // 失败时覆盖
+			ErrorCode:         "",
+			ErrorMsg:          "",
+			Candidates:        []string{},
+			Attempts:          []domain.ProviderAttempt{},
+			Files:             buildFileResults(eff, p, absToRel),
+			Overrides:         append([]string(nil), p.Overrides...),
+		},
+		outDir:  outDir,
+		started: time.Now(),
 	}
+}
 
-	needSidecar := p.Need.NeedNFO || p.Need.NeedPoster || p.Need.NeedFanart
-	if !needSidecar && len(p.Moves) == 0 {
+// metaStage 是网络元数据阶段：刮削（cache → fetch → parse）与标题翻译。dry-run 在此结束。
+func metaStage(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, metaClient *http.Client, store cache.Store, r *itemRun) {
+	p, item := r.p, &r.item
+	if !p.Need.Any() && len(p.Moves) == 0 {
 		item.Status = domain.StatusSkipped
-		return item
+		r.done = true
+		return
 	}
+	r.media = probeMedia(item, p)
 
 	// dry-run：只做 fetch+parse 验证；不落盘、不下载图片、不移动。
 	if !eff.Apply {
+		r.done = true
 		if p.Need.NeedScrape {
-			meta, used, website, html, attempts, err := scrape(ctx, store, reg, p.ProviderRequested, p.Code, metaClient, false)
-			_ = meta
-			_ = html
-			item.Attempts = attempts
+			res, err := resolve(ctx, store, reg, p, eff.Actors, metaClient, false)
+			item.Attempts = res.Attempts
 			if err != nil {
-				fillProviderError(&item, err)
-				return item
+				fillProviderError(item, err)
+				return
 			}
-			item.ProviderUsed = used
-			item.Website = website
+			item.ProviderUsed = res.ProviderUsed
+			item.Website = res.Website
+			item.Overrides = append(item.Overrides, res.Overrides...)
+			item.ActorRenames = res.ActorRenames
+			if r.freeze && p.Need.NeedNFO && !hasOverride(item.Overrides, "meta.title") {
+				m, err := translateTitle(ctx, eff.Translator, store, p.Code, res.Meta)
+				if err != nil {
+					failItem(item, domain.ErrCodeTranslateFailed, fmt.Sprintf("翻译标题失败：%v；可检查 translate 配置或暂时关闭翻译", err))
+					return
+				}
+				res.Meta = m
+			}
+			r.resolved = &res
 		}
-		return item
+		return
 	}
 
 	// apply：严格遵守“移动最后一步”。
-	var meta domain.MovieMeta
-	var used, website string
-	var html []byte
 	if p.Need.NeedScrape {
-		m, u, w, h, attempts, err := scrape(ctx, store, reg, p.ProviderRequested, p.Code, metaClient, true)
-		item.Attempts = attempts
+		res, err := resolve(ctx, store, reg, p, eff.Actors, metaClient, true)
+		item.Attempts = res.Attempts
 		if err != nil {
-			fillProviderError(&item, err)
+			fillProviderError(item, err)
 			// sidecar 未满足：禁止移动视频（文件状态保持 failed）
-			for i := range item.Files {
-				item.Files[i].Status = domain.FileStatusFailed
-			}
-			return item
+			failAllFiles(item)
+			r.done = true
+			return
 		}
-		meta, used, website, html = m, u, w, h
-		item.ProviderUsed = used
-		item.Website = website
-		_ = html
+		r.meta = res.Meta
+		item.ProviderUsed = res.ProviderUsed
+		item.Website = res.Website
+		item.Overrides = append(item.Overrides, res.Overrides...)
+		item.ActorRenames = res.ActorRenames
+		r.resolved = &res
 	}
 
-	outDir := filepath.Join(eff.Path, "out", string(p.Code))
+	// 标题翻译位于刮削与 NFO 之间；用户已通过 overrides 指定标题时不再翻译。
+	// plan 文件携带的 Resolved 已在规划时翻译并冻结，apply 原样使用。
+	if p.Need.NeedNFO && p.Resolved == nil && !hasOverride(item.Overrides, "meta.title") {
+		m, err := translateTitle(ctx, eff.Translator, store, p.Code, r.meta)
+		if err != nil {
+			failItem(item, domain.ErrCodeTranslateFailed, fmt.Sprintf("翻译标题失败：%v；可检查 translate 配置或暂时关闭翻译", err))
+			r.done = true
+			return
+		}
+		r.meta = m
+	}
+}
+
+// imageStage 是 sidecar 阶段（仅 apply）：图片下载/处理与 sidecar 写入（原子 + 不覆盖）。
+// 写入顺序固定为 头像 → NFO → fanart → poster → thumb → landscape → extrafanart；任何失败都禁止 move。
+func imageStage(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, metaClient *http.Client, images *httpx.Downloads, r *itemRun) {
+	if r.done {
+		return
+	}
+	if !writeSidecars(ctx, eff, reg, metaClient, images, r) {
+		r.done = true
+	}
+}
+
+func writeSidecars(ctx context.Context, eff config.EffectiveConfig, reg provider.Registry, metaClient *http.Client, images *httpx.Downloads, r *itemRun) bool {
+	p, item, outDir, meta := r.p, &r.item, r.outDir, r.meta
 	if err := ensureDir(outDir); err != nil {
 		item.Status = domain.StatusFailed
 		if fsx.IsPathTypeConflict(err) {
@@ -355,142 +713,138 @@ func execOne(ctx context.Context, eff config.EffectiveConfig, p domain.ItemPlan,
 			item.ErrorCode = domain.ErrCodeIOFailed
 		}
 		item.ErrorMsg = err.Error()
-		for i := range item.Files {
-			item.Files[i].Status = domain.FileStatusFailed
-		}
-		return item
+		failAllFiles(item)
+		return false
 	}
 
-	// sidecar 写入（原子 + 不覆盖）。任何失败都禁止 move。
 	if p.Need.NeedNFO {
-		b, err := nfo.Encode(meta)
-		if err != nil {
-			item.Status = domain.StatusFailed
-			item.ErrorCode = domain.ErrCodeIOFailed
-			item.ErrorMsg = fmt.Sprintf("生成 NFO 失败：%v", err)
-			failAllFiles(&item)
-			return item
-		}
-		if err := fsx.WriteFileAtomicNoOverwrite(outDir, string(p.Code)+".nfo", b); err != nil {
-			if errors.Is(err, os.ErrExist) {
-				// 已存在视为满足
-			} else if fsx.IsPathTypeConflict(err) {
-				item.Status = domain.StatusFailed
-				item.ErrorCode = domain.ErrCodeTargetConflict
-				item.ErrorMsg = err.Error()
-				failAllFiles(&item)
-				return item
-			} else {
-				item.Status = domain.StatusFailed
-				item.ErrorCode = domain.ErrCodeIOFailed
-				item.ErrorMsg = fmt.Sprintf("写入 NFO 失败：%v", err)
-				failAllFiles(&item)
-				return item
+		// 头像先于 NFO 落盘：NFO 的 <actor><thumb> 引用本地文件。
+		if eff.Portraits != "" {
+			m, ok := writePortraits(ctx, item, eff, reg, metaClient, images, outDir, meta)
+			if !ok {
+				return false
 			}
+			meta = m
+		}
+		opt := eff.NFO
+		opt.Tags, opt.Markers, opt.Provider, opt.Media = eff.Tags, p.Markers.Labels(), item.ProviderUsed, r.media
+		b, err := nfo.EncodeWithOptions(meta, opt)
+		if err != nil {
+			failItem(item, domain.ErrCodeIOFailed, fmt.Sprintf("生成 NFO 失败：%v", err))
+			return false
+		}
+		if !writeSidecar(item, outDir, string(p.Code)+".nfo", b, "NFO") {
+			return false
 		}
 	}
 
-	var fanartBytes []byte
+	// fanartSrc 是下载到（或本地已有）的原图，用于派生 poster；fanartBytes 是最终写入 fanart.jpg 的字节。
+	var fanartSrc, fanartBytes []byte
 
 	if p.Need.NeedFanart {
 		if stringsTrim(meta.FanartURL) == "" {
-			item.Status = domain.StatusFailed
-			item.ErrorCode = domain.ErrCodeParseFailed
-			item.ErrorMsg = "provider 未提供 fanart_url，无法下载 fanart.jpg"
-			failAllFiles(&item)
-			return item
+			failItem(item, domain.ErrCodeParseFailed, "provider 未提供 fanart_url，无法下载 fanart.jpg")
+			return false
 		}
-		b, err := download(ctx, imageClient, meta.FanartURL, meta.Website)
+		b, err := download(ctx, images, meta.FanartURL, meta.Website, eff.DownloadMaxBytes)
 		if err != nil {
-			item.Status = domain.StatusFailed
-			item.ErrorCode = domain.ErrCodeFetchFailed
-			item.ErrorMsg = fmt.Sprintf("下载 fanart 失败：%v", err)
-			failAllFiles(&item)
-			return item
-		}
-		fanartBytes = b
-		if err := fsx.WriteFileAtomicNoOverwrite(outDir, "fanart.jpg", b); err != nil {
-			if errors.Is(err, os.ErrExist) {
-				// ok
-			} else if fsx.IsPathTypeConflict(err) {
-				item.Status = domain.StatusFailed
-				item.ErrorCode = domain.ErrCodeTargetConflict
-				item.ErrorMsg = err.Error()
-				failAllFiles(&item)
-				return item
-			} else {
-				item.Status = domain.StatusFailed
-				item.ErrorCode = domain.ErrCodeIOFailed
-				item.ErrorMsg = fmt.Sprintf("写入 fanart 失败：%v", err)
-				failAllFiles(&item)
-				return item
-			}
+			failItem(item, domain.ErrCodeFetchFailed, fmt.Sprintf("下载 fanart 失败：%v", err))
+			return false
+		}
+		// 写入前先校验是真实图片（例如 200 状态码的 HTML 错误页），再按配置缩放/重编码。
+		out, err := imgx.Process(b, eff.FanartImage)
+		if err != nil {
+			failItem(item, domain.ErrCodeFetchFailed, fmt.Sprintf("下载的 fanart 不是有效图片：%v", err))
+			return false
+		}
+		fanartSrc, fanartBytes = b, out
+		if !writeSidecar(item, outDir, "fanart.jpg", out, "fanart") {
+			return false
 		}
 	}
+	// poster/thumb/landscape 都由 fanart 派生；fanart 已存在时从本地读取（apply 才会走到这里）。
+	needFromFanart := p.Need.NeedPoster || p.Need.NeedThumb || p.Need.NeedLandscape
+	if needFromFanart && len(fanartBytes) == 0 {
+		b, err := os.ReadFile(filepath.Join(outDir, "fanart.jpg"))
+		if err != nil {
+			failItem(item, domain.ErrCodeIOFailed, fmt.Sprintf("读取 fanart 失败，无法生成 poster/thumb/landscape：%v", err))
+			return false
+		}
+		fanartSrc, fanartBytes = b, b
+	}
 
-	// poster 由 fanart 的右半边裁切得到；不再单独下载 cover。
+	// poster 由 fanart 裁切得到（策略见 poster.strategy；标准双联封面取右半边）；不再单独下载 cover。
 	if p.Need.NeedPoster {
-		src := fanartBytes
-		if len(src) == 0 {
-			// fanart 已存在：从本地读取（apply 才会走到这里）。
-			b, err := os.ReadFile(filepath.Join(outDir, "fanart.jpg"))
-			if err != nil {
-				item.Status = domain.StatusFailed
-				item.ErrorCode = domain.ErrCodeIOFailed
-				item.ErrorMsg = fmt.Sprintf("读取 fanart 失败，无法生成 poster：%v", err)
-				failAllFiles(&item)
-				return item
-			}
-			src = b
+		b, err := imgx.Poster(fanartSrc, eff.PosterStrategy, eff.PosterImage)
+		if err != nil {
+			failItem(item, domain.ErrCodeIOFailed, fmt.Sprintf("生成 poster 失败：%v", err))
+			return false
 		}
+		if !writeSidecar(item, outDir, "poster.jpg", b, "poster") {
+			return false
+		}
+	}
 
-		b, err := imgx.PosterFromFanartRightHalfJPEG(src)
-		if err != nil {
-			item.Status = domain.StatusFailed
-			item.ErrorCode = domain.ErrCodeIOFailed
-			item.ErrorMsg = fmt.Sprintf("生成 poster 失败：%v", err)
-			failAllFiles(&item)
-			return item
-		}
-		if err := fsx.WriteFileAtomicNoOverwrite(outDir, "poster.jpg", b); err != nil {
-			if errors.Is(err, os.ErrExist) {
-				// ok
-			} else if fsx.IsPathTypeConflict(err) {
-				item.Status = domain.StatusFailed
-				item.ErrorCode = domain.ErrCodeTargetConflict
-				item.ErrorMsg = err.Error()
-				failAllFiles(&item)
-				return item
-			} else {
-				item.Status = domain.StatusFailed
-				item.ErrorCode = domain.ErrCodeIOFailed
-				item.ErrorMsg = fmt.Sprintf("写入 poster 失败：%v", err)
-				failAllFiles(&item)
-				return item
-			}
+	// thumb/landscape 是 Kodi/Emby 皮肤使用的横幅图：直接复用 fanart 原图。
+	if p.Need.NeedThumb && !writeSidecar(item, outDir, "thumb.jpg", fanartBytes, "thumb") {
+		return false
+	}
+	if p.Need.NeedLandscape && !writeSidecar(item, outDir, "landscape.jpg", fanartBytes, "landscape") {
+		return false
+	}
+
+	if p.Need.NeedExtrafanart {
+		if !writeExtrafanart(ctx, item, images, outDir, meta, p.ExtrafanartMax, eff.FanartImage, eff.DownloadMaxBytes) {
+			return false
 		}
 	}
 
-	// move：最后一步。中途失败 => 尝试回滚已移动文件。
+	return true
+}
+
+// fsStage 是移动阶段（仅 apply）：移动最后一步。中途失败 => 尝试回滚已移动文件。
+func fsStage(r *itemRun) {
+	if r.done {
+		return
+	}
+	r.done = true
+	p, item, outDir := r.p, &r.item, r.outDir
 	moved := make([]domain.MovePlan, 0, len(p.Moves))
 	for i := range p.Moves {
 		mv := p.Moves[i]
-		if err := fsx.Rename(mv.SrcAbs, mv.DstAbs); err != nil {
+		if mv.Hash != "" {
+			// scan.hash：移动前确认仍是规划时的那份内容（刮削期间可能被替换）。
+			if h, err := fsx.QuickHash(mv.SrcAbs); err != nil || h != mv.Hash {
+				item.Status = domain.StatusFailed
+				item.ErrorCode = domain.ErrCodeSourceChanged
+				item.ErrorMsg = fmt.Sprintf("源文件在规划后内容已变化（hash 不一致）：%s", mv.SrcAbs)
+				item.Files[i].Status = domain.FileStatusFailed
+				rollbackMoves(item, moved)
+				return
+			}
+		}
+		var err error
+		if dir := filepath.Dir(mv.DstAbs); dir != filepath.Clean(outDir) {
+			// 次选版本移入隔离目录（dupes.mode=keep_best）；目录按需创建。
+			err = ensureDir(dir)
+		}
+		if err == nil {
+			err = fsx.Rename(mv.SrcAbs, mv.DstAbs)
+		}
+		if err != nil {
 			item.Status = domain.StatusFailed
 			item.ErrorCode = domain.ErrCodeMoveFailed
 			item.ErrorMsg = err.Error()
 
 			// 失败文件标记 failed；之前成功的尝试回滚。
 			item.Files[i].Status = domain.FileStatusFailed
-			rollbackMoves(&item, moved)
-			return item
+			rollbackMoves(item, moved)
+			return
 		}
 
 		moved = append(moved, mv)
 		item.Files[i].Status = domain.FileStatusMoved
 	}
-
-	return item
 }
 
 func buildFileResults(eff config.EffectiveConfig, p domain.ItemPlan, absToRel map[string]string) []domain.FileResult {
@@ -514,14 +868,207 @@ func buildFileResults(eff config.EffectiveConfig, p domain.ItemPlan, absToRel ma
 		}
 
 		out = append(out, domain.FileResult{
-			Src:    src,
-			Dst:    dst,
-			Status: domain.FileStatusPlanned,
+			Src:       src,
+			Dst:       dst,
+			Status:    domain.FileStatusPlanned,
+			Hash:      mv.Hash,
+			Part:      mv.Part,
+			Duplicate: mv.Duplicate,
 		})
 	}
 	return out
 }
 
+// probeMedia 解析每个待移动文件的容器头部（只读头部字节，dry-run 同样执行），结果写入 FileResult.Media。
+// 返回写入 NFO 的汇总：流信息取第一个可解析的视频，时长为不同分段之和（同一分段的其他版本不重复计算，
+// 次选版本不参与）；没有可解析的视频时为 nil。
+// 非 MP4/Matroska（字幕、AVI 等）或解析失败时不记录：流信息只是补充，不影响整理。
+func probeMedia(item *domain.ItemResult, p domain.ItemPlan) *domain.MediaInfo {
+	var sum *domain.MediaInfo
+	seenParts := map[int]struct{}{}
+	for i, mv := range p.Moves {
+		mi, err := mediax.Probe(mv.SrcAbs)
+		if err != nil {
+			continue
+		}
+		item.Files[i].Media = &mi
+		if mv.Duplicate == domain.DuplicateAlternate || mv.Duplicate == domain.DuplicateQuarantined {
+			continue
+		}
+		if _, ok := seenParts[mv.Part]; ok {
+			continue
+		}
+		seenParts[mv.Part] = struct{}{}
+		if sum == nil {
+			cp := mi
+			sum = &cp
+			continue
+		}
+		sum.DurationS += mi.DurationS
+	}
+	return sum
+}
+
+func hasOverride(applied []string, name string) bool {
+	for _, a := range applied {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// failItem 把 item 标记为失败，并把所有文件标记为 failed（sidecar 未满足 => 禁止移动）。
+func failItem(item *domain.ItemResult, code, msg string) {
+	item.Status = domain.StatusFailed
+	item.ErrorCode = code
+	item.ErrorMsg = msg
+	failAllFiles(item)
+}
+
+// writeSidecar 原子写入且不覆盖；已存在视为满足。失败时填充 item 并返回 false。
+func writeSidecar(item *domain.ItemResult, dir, name string, b []byte, what string) bool {
+	err := fsx.WriteFileAtomicNoOverwrite(dir, name, b)
+	switch {
+	case err == nil, errors.Is(err, os.ErrExist):
+		return true
+	case fsx.IsPathTypeConflict(err):
+		failItem(item, domain.ErrCodeTargetConflict, err.Error())
+	default:
+		failItem(item, domain.ErrCodeIOFailed, fmt.Sprintf("写入 %s 失败：%v", what, err))
+	}
+	return false
+}
+
+// writePortraits 下载演员头像，并把 meta 中演员的 Thumb 改写为本地引用（供 NFO <actor><thumb> 使用）：
+// item 模式写入 out/<CODE>/.actors/（NFO 中为相对路径），library 模式写入共享目录（NFO 中为绝对路径）。
+// 已存在的头像直接复用（不覆盖）；没有头像的演员保持原样。任何下载/写入失败都禁止 move。
+func writePortraits(ctx context.Context, item *domain.ItemResult, eff config.EffectiveConfig, reg provider.Registry, metaClient *http.Client, images *httpx.Downloads, outDir string, meta domain.MovieMeta) (domain.MovieMeta, bool) {
+	dir := filepath.Join(outDir, domain.ActorsDir)
+	ref := func(name string) string { return domain.ActorsDir + "/" + name }
+	if eff.Portraits == domain.PortraitsLibrary {
+		dir = eff.PortraitDir
+		ref = func(name string) string { return filepath.Join(dir, name) }
+	}
+
+	// 详情页不含头像的 provider（例如 JavDB）需要再抓演员页。
+	var thumbs provider.ActorThumbFetcher
+	if pr, ok := reg.Get(item.ProviderUsed); ok {
+		thumbs, _ = pr.(provider.ActorThumbFetcher)
+	}
+
+	actors := append([]domain.Actor(nil), meta.Actors...)
+	dirReady := false
+	for i, a := range actors {
+		name := portraitFileName(a.Name)
+		if name == "" {
+			continue
+		}
+		if fi, err := os.Lstat(filepath.Join(dir, name)); err == nil && fi.Mode().IsRegular() {
+			actors[i].Thumb = ref(name)
+			continue
+		}
+
+		thumb := a.Thumb
+		if thumb == "" && a.URL != "" && thumbs != nil {
+			u, err := thumbs.ActorThumb(ctx, a.URL, metaClient)
+			if err != nil {
+				failItem(item, domain.ErrCodeFetchFailed, fmt.Sprintf("获取演员头像失败（%s）：%v", a.Name, err))
+				return meta, false
+			}
+			thumb = u
+		}
+		if thumb == "" {
+			continue
+		}
+
+		if !dirReady {
+			if err := ensureDir(dir); err != nil {
+				code := domain.ErrCodeIOFailed
+				if fsx.IsPathTypeConflict(err) {
+					code = domain.ErrCodeTargetConflict
+				}
+				failItem(item, code, err.Error())
+				return meta, false
+			}
+			dirReady = true
+		}
+		b, err := download(ctx, images, thumb, meta.Website, eff.DownloadMaxBytes)
+		if err != nil {
+			failItem(item, domain.ErrCodeFetchFailed, fmt.Sprintf("下载演员头像失败（%s）：%v", a.Name, err))
+			return meta, false
+		}
+		if _, err := imgx.Process(b, imgx.EncodeOptions{Format: imgx.FormatOriginal}); err != nil {
+			failItem(item, domain.ErrCodeFetchFailed, fmt.Sprintf("下载的演员头像不是有效图片（%s）：%v", a.Name, err))
+			return meta, false
+		}
+		if !writeSidecar(item, dir, name, b, domain.ActorsDir+"/"+name) {
+			return meta, false
+		}
+		actors[i].Thumb = ref(name)
+	}
+	meta.Actors = actors
+	return meta, true
+}
+
+// portraitFileName 按 Kodi .actors 约定生成头像文件名：空格换成 '_'，并替换文件名中的非法字符。
+func portraitFileName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, name) + ".jpg"
+}
+
+// writeExtrafanart 把样品图下载为 extrafanart/fanart1.jpg…（最多 max 张，按 opt 缩放/重编码）。
+// provider 没有样品图时不创建目录：只有至少一张 fanartN.jpg 才算已满足（见 planner），
+// 之后刮削结果带上样品图时仍会补齐。
+func writeExtrafanart(ctx context.Context, item *domain.ItemResult, images *httpx.Downloads, outDir string, meta domain.MovieMeta, max int, opt imgx.EncodeOptions, maxBytes int64) bool {
+	urls := meta.SampleURLs
+	if max >= 0 && len(urls) > max {
+		urls = urls[:max]
+	}
+	if len(urls) == 0 {
+		return true
+	}
+	dir := filepath.Join(outDir, domain.ExtrafanartDir)
+	if err := ensureDir(dir); err != nil {
+		code := domain.ErrCodeIOFailed
+		if fsx.IsPathTypeConflict(err) {
+			code = domain.ErrCodeTargetConflict
+		}
+		failItem(item, code, err.Error())
+		return false
+	}
+
+	for i, u := range urls {
+		name := fmt.Sprintf("fanart%d.jpg", i+1)
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			continue
+		}
+		b, err := download(ctx, images, u, meta.Website, maxBytes)
+		if err != nil {
+			failItem(item, domain.ErrCodeFetchFailed, fmt.Sprintf("下载 extrafanart 失败（%s）：%v", name, err))
+			return false
+		}
+		b, err = imgx.Process(b, opt)
+		if err != nil {
+			failItem(item, domain.ErrCodeFetchFailed, fmt.Sprintf("下载的 extrafanart 不是有效图片（%s）：%v", name, err))
+			return false
+		}
+		if !writeSidecar(item, dir, name, b, "extrafanart/"+name) {
+			return false
+		}
+	}
+	return true
+}
+
 func failAllFiles(item *domain.ItemResult) {
 	for i := range item.Files {
 		item.Files[i].Status = domain.FileStatusFailed
@@ -532,6 +1079,13 @@ func rollbackMoves(item *domain.ItemResult, moved []domain.MovePlan) {
 	// 回滚顺序：倒序（更符合栈语义）。
 	for i := len(moved) - 1; i >= 0; i-- {
 		mv := moved[i]
+		if mv.Hash != "" {
+			// 目标位置已不是我们移过去的那份内容：不动它，交给用户处理。
+			if h, err := fsx.QuickHash(mv.DstAbs); err != nil || h != mv.Hash {
+				item.Files[i].Status = domain.FileStatusFailed
+				continue
+			}
+		}
 		if err := fsx.Rename(mv.DstAbs, mv.SrcAbs); err == nil {
 			// moved[i] 对应 p.Moves[i]，file 结果顺序一致。
 			item.Files[i].Status = domain.FileStatusRolledBack
@@ -555,36 +1109,41 @@ func ensureDir(dir string) error {
 	return os.MkdirAll(dir, 0o755)
 }
 
-func download(ctx context.Context, c *http.Client, u string, referer string) ([]byte, error) {
-	if c == nil {
+// download 流式下载一张图片（见 httpx.Download）：超过 maxBytes（0 表示不限）或开头不是 JPEG/PNG/WebP
+// 时立即失败，中途断开按 Range 续传；校验通过后读回内存交给 imgx 处理。
+// 同一次运行内相同的图片尽量只下载一次（见 httpx.Downloads）：临时文件在 cache/downloads/ 下，
+// 保留总量有上限，运行结束时统一清理。
+func download(ctx context.Context, images *httpx.Downloads, u string, referer string, maxBytes int64) ([]byte, error) {
+	if images == nil {
 		return nil, errors.New("image client 为空")
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-	if err != nil {
-		return nil, err
-	}
 
 	// JavBus 的图片通常要求：
 	// - Referer 为详情页
 	// - Cookie 含 age=verified
 	//
 	// 这里把策略集中在下载层，避免让 provider/核心流程到处散落“站点特例”。
+	h := http.Header{}
 	if isJavbusURL(u) {
 		if strings.TrimSpace(referer) != "" {
-			req.Header.Set("Referer", referer)
+			h.Set("Referer", referer)
 		}
-		req.Header.Set("Cookie", "age=verified")
+		h.Set("Cookie", "age=verified")
 	}
 
-	resp, err := c.Do(req)
+	path, release, err := images.Get(ctx, u, httpx.DownloadOptions{
+		Header:   h,
+		MaxBytes: maxBytes,
+		Sniff: func(head []byte) error {
+			_, err := imgx.Sniff(head)
+			return err
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-	return io.ReadAll(resp.Body)
+	defer release()
+	return os.ReadFile(path)
 }
 
 func isJavbusURL(raw string) bool {
@@ -596,6 +1155,69 @@ func isJavbusURL(raw string) bool {
 	return host == "javbus.com" || strings.HasSuffix(host, ".javbus.com")
 }
 
+// resolve 返回 item 需要的元数据：计划已携带 Resolved（plan 文件工作流）时直接使用，否则刮削。
+// 刮削结果依次经过演员名规范化与字段覆盖（用户显式指定的演员不再改写）。
+func resolve(ctx context.Context, store cache.Store, reg provider.Registry, p domain.ItemPlan, al actors.Options, c *http.Client, allowWrite bool) (domain.ResolvedMeta, error) {
+	if p.Resolved != nil {
+		r := *p.Resolved
+		if r.Attempts == nil {
+			r.Attempts = []domain.ProviderAttempt{}
+		}
+		return r, nil
+	}
+
+	var (
+		meta          domain.MovieMeta
+		used, website string
+		attempts      []domain.ProviderAttempt
+		err           error
+	)
+	if ov := p.Override; ov != nil && ov.Provider != "" {
+		meta, website, attempts, err = scrapePinned(ctx, store, reg, *ov, p.Code, c, allowWrite)
+		used = ov.Provider
+	} else {
+		meta, used, website, _, attempts, err = scrape(ctx, store, reg, p.ProviderRequested, p.Code, c, allowWrite)
+	}
+	r := domain.ResolvedMeta{ProviderUsed: used, Website: website, Attempts: attempts, Meta: meta}
+	if err != nil {
+		r.ProviderUsed = ""
+		return r, err
+	}
+	// 演员名规范化与字段覆盖都在刮削之后应用：缓存始终保存 provider 的原始结果。
+	r.Meta.Actors, r.ActorRenames = actors.Normalize(r.Meta.Actors, al)
+	if p.Override != nil && p.Override.Meta != nil {
+		r.Meta, r.Overrides = p.Override.Meta.Apply(r.Meta)
+		if hasOverride(r.Overrides, "meta.actors") {
+			r.ActorRenames = nil
+		}
+	}
+	return r, nil
+}
+
+// scrapePinned 只使用覆盖规则指定的 provider（不降级）；固定 URL 时跳过缓存读取（旧缓存可能正是错误的作品）。
+func scrapePinned(ctx context.Context, store cache.Store, reg provider.Registry, ov domain.CodeOverride, code domain.Code, c *http.Client, allowWrite bool) (domain.MovieMeta, string, []domain.ProviderAttempt, error) {
+	if ov.URL == "" {
+		if b, ok, err := store.ReadProviderJSON(ov.Provider, code); err == nil && ok {
+			var meta domain.MovieMeta
+			if e := json.Unmarshal(b, &meta); e == nil {
+				return meta, meta.Website, []domain.ProviderAttempt{{Provider: ov.Provider, Stage: "ok"}}, nil
+			}
+		}
+	}
+
+	meta, website, html, trace, err := provider.FetchParsePinned(ctx, reg, ov.Provider, ov.URL, code, c)
+	if err != nil {
+		return domain.MovieMeta{}, "", attemptsFromTrace(trace), err
+	}
+	if allowWrite && !store.ReadOnly {
+		_ = store.WriteProviderHTML(ov.Provider, code, html)
+		if b, e := json.Marshal(meta); e == nil {
+			_ = store.WriteProviderJSON(ov.Provider, code, b)
+		}
+	}
+	return meta, website, attemptsFromTrace(trace), nil
+}
+
 func scrape(ctx context.Context, store cache.Store, reg provider.Registry, providerRequested string, code domain.Code, c *http.Client, allowWrite bool) (domain.MovieMeta, string, string, []byte, []domain.ProviderAttempt, error) {
 	// 先尝试 cache（只读），命中则不再打网络。
 	if b, ok, err := store.ReadProviderJSON(providerRequested, code); err == nil && ok {
@@ -0,0 +1,59 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/cache"
+	"github.com/John-Robertt/AVMC/internal/translate"
+)
+
+// translationEntry 是 cache/translations/<CODE>.json 的结构：原文或后端变化时缓存失效。
+type translationEntry struct {
+	Backend  string `json:"backend"`
+	Original string `json:"original"`
+	Title    string `json:"title"`
+}
+
+// translateTitle 把 meta.Title 翻译为目标语言，原文保存在 OriginalTitle。
+// 后端没有译文（translate.ErrNoTranslation）时保留原文；其它错误返回给调用方。
+func translateTitle(ctx context.Context, t translate.Translator, store cache.Store, code domain.Code, meta domain.MovieMeta) (domain.MovieMeta, error) {
+	original := strings.TrimSpace(meta.Title)
+	if t == nil || original == "" {
+		return meta, nil
+	}
+
+	if b, ok, err := store.ReadTranslation(code); err == nil && ok {
+		var e translationEntry
+		if json.Unmarshal(b, &e) == nil && e.Backend == t.Name() && e.Original == original && e.Title != "" {
+			return withTranslatedTitle(meta, original, e.Title), nil
+		}
+	}
+
+	title, err := t.Translate(ctx, original)
+	if errors.Is(err, translate.ErrNoTranslation) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, err
+	}
+
+	if !store.ReadOnly {
+		if b, e := json.Marshal(translationEntry{Backend: t.Name(), Original: original, Title: title}); e == nil {
+			_ = store.WriteTranslation(code, b)
+		}
+	}
+	return withTranslatedTitle(meta, original, title), nil
+}
+
+func withTranslatedTitle(meta domain.MovieMeta, original, title string) domain.MovieMeta {
+	if title == original {
+		return meta
+	}
+	meta.OriginalTitle = original
+	meta.Title = title
+	return meta
+}
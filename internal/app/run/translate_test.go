@@ -0,0 +1,68 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/cache"
+	"github.com/John-Robertt/AVMC/internal/translate"
+)
+
+type stubTranslator struct {
+	out   string
+	err   error
+	calls int
+}
+
+func (s *stubTranslator) Name() string { return "stub:zh" }
+
+func (s *stubTranslator) Translate(ctx context.Context, text string) (string, error) {
+	s.calls++
+	return s.out, s.err
+}
+
+func TestTranslateTitle_CachesPerCode(t *testing.T) {
+	root := t.TempDir()
+	store := cache.New(root, false)
+	meta := domain.MovieMeta{Code: "ABP-001", Title: " 原題 "}
+
+	tr := &stubTranslator{out: "译名"}
+	got, err := translateTitle(context.Background(), tr, store, "ABP-001", meta)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if got.Title != "译名" || got.OriginalTitle != "原題" {
+		t.Fatalf("译文/原文不符合预期：%+v", got)
+	}
+
+	// 命中缓存：不再调用后端。
+	tr2 := &stubTranslator{err: errors.New("不应被调用")}
+	got, err = translateTitle(context.Background(), tr2, store, "ABP-001", meta)
+	if err != nil || tr2.calls != 0 || got.Title != "译名" {
+		t.Fatalf("期望命中缓存：%+v err=%v calls=%d", got, err, tr2.calls)
+	}
+
+	// 原文变化 => 缓存失效，后端错误透传。
+	meta.Title = "新しい原題"
+	if _, err := translateTitle(context.Background(), tr2, store, "ABP-001", meta); err == nil || tr2.calls != 1 {
+		t.Fatalf("原文变化时应重新翻译并返回后端错误：err=%v calls=%d", err, tr2.calls)
+	}
+
+	// 没有译文：保留原文，不算失败。
+	none := &stubTranslator{err: translate.ErrNoTranslation}
+	got, err = translateTitle(context.Background(), none, store, "ABP-001", meta)
+	if err != nil || got.Title != "新しい原題" || got.OriginalTitle != "" {
+		t.Fatalf("无译文时应保留原文：%+v err=%v", got, err)
+	}
+
+	// dry-run（只读缓存）不落盘。
+	ro := cache.New(t.TempDir(), true)
+	if _, err := translateTitle(context.Background(), &stubTranslator{out: "x"}, ro, "ABP-001", meta); err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if _, ok, _ := ro.ReadTranslation("ABP-001"); ok {
+		t.Fatalf("只读模式不应写入翻译缓存")
+	}
+}
@@ -35,15 +35,26 @@ func (e *UnmatchedError) Error() string {
 	}
 }
 
-// Extract 从 VideoFile 的文件名与父目录名中提取唯一 CODE。
-// 若提取失败，返回 *UnmatchedError（no_match / ambiguous）。
+// Extract 以默认规范化规则提取 CODE（见 ExtractWith）。
 func Extract(v domain.VideoFile) (domain.Code, error) {
+	return ExtractWith(v, DefaultNormalizer())
+}
+
+// ExtractWith 从 VideoFile 的文件名与父目录名中提取唯一 CODE（按 n 规范化，ABP-01 与 ABP-001 视为同一个）。
+// 若提取失败，返回 *UnmatchedError（no_match / ambiguous）。
+func ExtractWith(v domain.VideoFile, n Normalizer) (domain.Code, error) {
 	m := map[domain.Code]struct{}{}
 
-	addCandidates(m, v.Base)
+	addCandidates(m, v.Base, n)
 
 	parent := filepath.Base(filepath.Dir(v.AbsPath))
-	addCandidates(m, parent)
+	addCandidates(m, parent, n)
+
+	// 第二轮（保守）：只有带分隔符的形态完全没有命中时，才尝试无分隔符 / DMM content-id 形态。
+	if len(m) == 0 {
+		addLooseCandidates(m, v.Base, n)
+		addLooseCandidates(m, parent, n)
+	}
 
 	if len(m) == 0 {
 		return "", &UnmatchedError{Kind: "no_match"}
@@ -62,7 +73,7 @@ func Extract(v domain.VideoFile) (domain.Code, error) {
 	return "", &UnmatchedError{Kind: "no_match"}
 }
 
-func addCandidates(dst map[domain.Code]struct{}, s string) {
+func addCandidates(dst map[domain.Code]struct{}, s string, n Normalizer) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return
@@ -73,10 +84,72 @@ func addCandidates(dst map[domain.Code]struct{}, s string) {
 		if len(m) < 3 {
 			continue
 		}
-		prefix := strings.ToUpper(m[1])
-		num := m[2]
-		if c, ok := domain.ParseCode(prefix + "-" + num); ok {
+		if c, ok := n.Normalize(m[1], m[2]); ok {
 			dst[c] = struct{}{}
 		}
 	}
 }
+
+// 第二轮的两种形态（都必须占满一个 token，避免从长串中“抠”出 CODE）：
+//   - DMM 专用 label：h_1234abc00123 => ABC-123
+//   - 无分隔符 / content-id：ABP123、abp00123、118abp00123（已知数字 label 前缀）=> ABP-123
+//     （允许紧跟字幕 marker：abp123ch，见 ParseMarkers）
+//     没有数字 label 前缀时数字部分至少 3 位：hdr10、ddp51 这类两位数的发布标记不当作 CODE
+var (
+	dmmHRE  = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])h_[0-9]{3,4}([a-z]{2,6})([0-9]{2,5})(?:[^a-z0-9]|$)`)
+	looseRE = regexp.MustCompile(`^([0-9]{1,3})?([a-z]{2,6})([0-9]{2,5})(?:ch|uc|c)?$`)
+	tokenRE = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// knownLabelPrefixes 是 DMM content-id 中常见的数字 label 前缀（例如 118abp00123）。
+// 不在表内的数字前缀可能本身就是番号的一部分，按“宁可 unmatched”直接放弃。
+var knownLabelPrefixes = map[string]struct{}{
+	"1": {}, "2": {}, "5": {}, "9": {}, "13": {}, "15": {}, "18": {}, "24": {},
+	"36": {}, "41": {}, "48": {}, "55": {}, "57": {}, "59": {}, "84": {}, "118": {},
+}
+
+// noisePrefixes 是文件名中常见的画质/编码/音轨/来源/分段标记，形如 fhd1080、hevc265、hdr1000、remux2160、part12，不能当作 CODE。
+// x264/h265 这类单字母编码标记不满足 looseRE 的字母段长度，无需列出。
+var noisePrefixes = map[string]struct{}{
+	"HD": {}, "FHD": {}, "UHD": {}, "SD": {}, "HEVC": {}, "AVC": {}, "AAC": {}, "DTS": {},
+	"FPS": {}, "BD": {}, "DVD": {}, "WEB": {}, "CD": {}, "PART": {}, "DISC": {}, "VOL": {},
+	"EP": {}, "MP": {}, "SAMPLE": {}, "VR": {},
+	"HDR": {}, "DD": {}, "DDP": {}, "EAC": {}, "AC": {}, "ATMOS": {}, "TRUEHD": {},
+	"FLAC": {}, "OPUS": {}, "PCM": {}, "LPCM": {},
+	"REMUX": {}, "BLURAY": {}, "BDRIP": {}, "BRRIP": {}, "DVDRIP": {}, "HDRIP": {},
+	"WEBDL": {}, "WEBRIP": {}, "HDTV": {}, "DV": {},
+}
+
+func addLooseCandidates(dst map[domain.Code]struct{}, s string, n Normalizer) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return
+	}
+
+	for _, m := range dmmHRE.FindAllStringSubmatch(s, -1) {
+		addLoose(dst, m[1], m[2], n)
+	}
+	for _, tok := range tokenRE.Split(s, -1) {
+		m := looseRE.FindStringSubmatch(tok)
+		if m == nil {
+			continue
+		}
+		if m[1] != "" {
+			if _, ok := knownLabelPrefixes[m[1]]; !ok {
+				continue
+			}
+		} else if len(m[3]) < 3 {
+			continue
+		}
+		addLoose(dst, m[2], m[3], n)
+	}
+}
+
+func addLoose(dst map[domain.Code]struct{}, prefix, num string, n Normalizer) {
+	if _, noise := noisePrefixes[strings.ToUpper(prefix)]; noise {
+		return
+	}
+	if c, ok := n.Normalize(prefix, num); ok {
+		dst[c] = struct{}{}
+	}
+}
@@ -63,3 +63,51 @@ func TestExtract_NoMatch(t *testing.T) {
 		t.Fatalf("期望 no_match，实际 err=%v", err)
 	}
 }
+
+func TestExtract_SeparatorlessAndContentID(t *testing.T) {
+	cases := map[string]domain.Code{
+		"ABP123":               "ABP-123",
+		"abp00123":             "ABP-123",
+		"118abp00123":          "ABP-123",
+		"h_1234abc00123":       "ABC-123",
+		"abp123ch":             "ABP-123",
+		"[FHD] ssis00456 1080": "SSIS-456",
+	}
+	for base, want := range cases {
+		v := domain.VideoFile{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", base+".mp4"), Base: base}
+		got, err := Extract(v)
+		if err != nil || got != want {
+			t.Fatalf("%s：期望 %s，实际 %q err=%v", base, want, got, err)
+		}
+	}
+}
+
+func TestExtract_SeparatorlessConservative(t *testing.T) {
+	// 噪音标记、发布组常见的音视频标记、未知数字前缀、嵌在长串中的片段都不应被识别。
+	for _, base := range []string{
+		"fhd1080", "hevc265", "part12", "777abp00123", "xabp00123y1",
+		"hdr10", "hdr1000", "ddp51", "dd51", "aac20", "ac351", "atmos71", "truehd71", "flac24", "dv10",
+		"remux2160", "bluray1080", "bdrip720", "brrip1080", "dvdrip480", "webdl1080", "webrip1080", "hdtv720", "dv2160",
+		"x264", "x265", "h264", "h265", "movie bluray1080 x265",
+		"movie hdr10 ddp51",
+	} {
+		v := domain.VideoFile{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", base+".mp4"), Base: base}
+		var ue *UnmatchedError
+		if _, err := Extract(v); !errors.As(err, &ue) || ue.Kind != "no_match" {
+			t.Fatalf("%s：期望 no_match，实际 err=%v", base, err)
+		}
+	}
+
+	// 多种解释 => ambiguous。
+	v := domain.VideoFile{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "x.mp4"), Base: "abp00123 ipx00456"}
+	var ue *UnmatchedError
+	if _, err := Extract(v); !errors.As(err, &ue) || ue.Kind != "ambiguous" {
+		t.Fatalf("期望 ambiguous，实际 err=%v", err)
+	}
+
+	// 带分隔符的形态命中时，不再启用第二轮。
+	v = domain.VideoFile{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", "x.mp4"), Base: "CAWD-895 abp00123"}
+	if got, err := Extract(v); err != nil || got != "CAWD-895" {
+		t.Fatalf("第一轮命中时应忽略第二轮：%q err=%v", got, err)
+	}
+}
@@ -0,0 +1,81 @@
+package code
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// marker 必须紧跟在数字（或 4K/2160p 标记）之后（可有一个分隔符），且后面是边界：
+// ABC-123-C / ABC-123ch / ABC-123_UC 命中；ABC-123-CD1 / ABC-123-Cut 不命中。
+var (
+	subtitleRE   = regexp.MustCompile(`(?i)(?:[0-9]|4k|2160p)[-_.\s]?(?:c|ch|uc)(?:$|[^a-z0-9])|中文字幕|中字`)
+	uncensoredRE = regexp.MustCompile(`(?i)(?:[0-9]|4k|2160p)[-_.\s]?(?:u|uc)(?:$|[^a-z0-9])|(?:^|[^a-z])(?:uncensored|leak(?:ed)?)(?:$|[^a-z])|无码流出|無碼流出`)
+	uhdRE        = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:4k|2160p)(?:$|[^a-z0-9])`)
+)
+
+// ParseMarkers 从文件名（不含扩展名）解析 marker。
+//
+// 只做只读判断，不影响 CODE 提取：marker 在 CODE 之后出现，且不会构成“字母段 + 分隔符 + 数字段”。
+func ParseMarkers(base string) domain.Markers {
+	return domain.Markers{
+		Subtitle:   subtitleRE.MatchString(base),
+		Uncensored: uncensoredRE.MatchString(base),
+		UHD:        uhdRE.MatchString(base),
+	}
+}
+
+// ParseOutDirName 解析 out/ 下的目录名：<CODE> 或 <CODE><marker 后缀>（见 domain.MarkerSuffixes）。
+func ParseOutDirName(name string) (domain.Code, bool) {
+	for _, s := range domain.MarkerSuffixes {
+		if s != "" && !strings.HasSuffix(name, s) {
+			continue
+		}
+		if c, ok := domain.ParseCode(strings.TrimSuffix(name, s)); ok {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// partRE 匹配分段标记：cd1 / part2 / pt3 / disc1 / disk2（可有一个分隔符），前后必须是边界。
+// partSuffixRE 匹配文件名末尾紧跟在数字之后的单个字符：ABC-123-1 / ABC-123_B。
+var (
+	partRE       = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:cd|part|pt|disc|disk)[-_.\s]?([0-9]{1,2})(?:$|[^0-9])`)
+	partSuffixRE = regexp.MustCompile(`(?i)[0-9][-_.\s]([0-9a-z])$`)
+)
+
+// ParsePart 从文件名（不含扩展名）解析分段序号；不是分段时返回 0。
+//
+// 分段（ABC-123-cd1 / ABC-123-cd2）是同一作品的不同部分，不视为重复版本。
+// 也识别末尾的数字/字母分段：ABC-123-1 => 1、ABC-123-B => 2（A~H 依次为 1~8）。
+// 字母 C 与字幕 marker（-C）有歧义，不识别，见 PartAmbiguous。
+func ParsePart(base string) int {
+	if m := partRE.FindStringSubmatch(base); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	m := partSuffixRE.FindStringSubmatch(base)
+	if m == nil {
+		return 0
+	}
+	switch ch := strings.ToLower(m[1])[0]; {
+	case ch >= '1' && ch <= '9':
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'h' && ch != 'c':
+		return int(ch-'a') + 1
+	}
+	return 0
+}
+
+// PartAmbiguous 报告文件名末尾的 -C 既可能是字幕 marker 也可能是第 3 段：
+// ParsePart 返回 0，但调用方不应据此认定它与其它文件是同一分段。
+func PartAmbiguous(base string) bool {
+	if partRE.MatchString(base) {
+		return false
+	}
+	m := partSuffixRE.FindStringSubmatch(base)
+	return m != nil && strings.EqualFold(m[1], "c")
+}
@@ -0,0 +1,100 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func TestParseMarkers(t *testing.T) {
+	cases := map[string]domain.Markers{
+		"ABC-123":        {},
+		"ABC-123-C":      {Subtitle: true},
+		"abc-123ch":      {Subtitle: true},
+		"ABC-123-UC":     {Subtitle: true, Uncensored: true},
+		"ABC-123-U":      {Uncensored: true},
+		"ABC-123-4K":     {UHD: true},
+		"ABC-123-4K-C":   {UHD: true, Subtitle: true},
+		"ABC-123 2160p":  {UHD: true},
+		"ABC-123-CD1":    {},
+		"ABC-123-cut":    {},
+		"ABC-123 中文字幕":   {Subtitle: true},
+		"ABC-123 leaked": {Uncensored: true},
+	}
+	for base, want := range cases {
+		if got := ParseMarkers(base); got != want {
+			t.Fatalf("%s：期望 %+v，实际 %+v", base, want, got)
+		}
+		// marker 不能影响 CODE 提取。
+		if got, ok := firstCode(base); !ok || got != "ABC-123" {
+			t.Fatalf("%s：CODE 提取被 marker 干扰：%q", base, got)
+		}
+	}
+}
+
+func TestParseOutDirName(t *testing.T) {
+	for name, want := range map[string]domain.Code{
+		"ABC-123":       "ABC-123",
+		"ABC-123-C":     "ABC-123",
+		"ABC-123-4K-UC": "ABC-123",
+	} {
+		if got, ok := ParseOutDirName(name); !ok || got != want {
+			t.Fatalf("%s：期望 %s，实际 %q ok=%v", name, want, got, ok)
+		}
+	}
+	for _, name := range []string{"ABC-123-X", "misc", "ABC-123-C-C"} {
+		if _, ok := ParseOutDirName(name); ok {
+			t.Fatalf("%s 不应被识别为 CODE 目录", name)
+		}
+	}
+}
+
+func TestParsePart(t *testing.T) {
+	for base, want := range map[string]int{
+		"ABC-123-cd1":      1,
+		"ABC-123_CD2":      2,
+		"ABC-123 part 3":   3,
+		"ABC-123-pt10":     10,
+		"ABC-123.disc1":    1,
+		"ABC-123-C":        0,
+		"ABC-123-CD":       0,
+		"ABC-123":          0,
+		"ABC-123-1080p":    0,
+		"ABCD-123-cd1-4K":  1,
+		"ABC-123-partial1": 0,
+		"ABC-123-1":        1,
+		"ABC-123_2":        2,
+		"ABC-123-A":        1,
+		"abc-123-b":        2,
+		"ABC-123-D":        4,
+		"ABC-123-U":        0,
+		"ABC-123-12":       0,
+		"ABC-123-4K":       0,
+	} {
+		if got := ParsePart(base); got != want {
+			t.Fatalf("%s：期望分段 %d，实际 %d", base, want, got)
+		}
+		if _, ok := firstCode(base); !ok {
+			t.Fatalf("%s：分段标记不应影响 CODE 提取", base)
+		}
+	}
+}
+
+func TestPartAmbiguous(t *testing.T) {
+	for base, want := range map[string]bool{
+		"ABC-123-C":     true,
+		"ABC-123_ch":    false,
+		"ABC-123-B":     false,
+		"ABC-123":       false,
+		"ABC-123-cd1-C": false,
+	} {
+		if got := PartAmbiguous(base); got != want {
+			t.Fatalf("%s：期望 %v，实际 %v", base, want, got)
+		}
+	}
+}
+
+func firstCode(base string) (domain.Code, bool) {
+	c, err := Extract(domain.VideoFile{AbsPath: "/tmp/x/" + base + ".mp4", Base: base})
+	return c, err == nil
+}
@@ -0,0 +1,72 @@
+package code
+
+import (
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// DefaultMinDigits 是数字段的默认最小位数：javbus/javdb 都以 3 位补零收录（ABP-001）。
+const DefaultMinDigits = 3
+
+// DefaultPrefixDigits 是与默认值不同的前缀位数（站点按 4 位收录，例如 HEYZO-0123）。
+var DefaultPrefixDigits = map[string]int{
+	"HEYZO": 4,
+}
+
+// Normalizer 把“字母段 + 数字段”规范化为唯一的 CODE。
+//
+// 规则：
+// - 前缀转大写；命中 PrefixAliases 时替换为规范前缀
+// - 数字段去掉前导 0，再补零到该前缀的最小位数（ABP-01 / ABP-0001 => ABP-001）
+// - 结果必须通过 domain.ParseCode，否则视为不匹配
+//
+// 该规则在提取、分组、out/ 目录查找与缓存键上必须一致（它们都以规范化后的 Code 为键）。
+type Normalizer struct {
+	// MinDigits 为 0 时使用 DefaultMinDigits。
+	MinDigits int
+	// PrefixDigits 覆盖特定前缀（大写）的最小位数。
+	PrefixDigits map[string]int
+	// PrefixAliases 把别名前缀（大写）映射为规范前缀。
+	PrefixAliases map[string]string
+}
+
+// DefaultNormalizer 返回内置默认规则。
+func DefaultNormalizer() Normalizer {
+	return Normalizer{MinDigits: DefaultMinDigits, PrefixDigits: DefaultPrefixDigits}
+}
+
+// Normalize 规范化 prefix + num；无法得到合法 CODE 时返回 false。
+func (n Normalizer) Normalize(prefix, num string) (domain.Code, bool) {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if alias, ok := n.PrefixAliases[prefix]; ok {
+		prefix = alias
+	}
+
+	width := n.MinDigits
+	if width <= 0 {
+		width = DefaultMinDigits
+	}
+	if w, ok := n.PrefixDigits[prefix]; ok && w > 0 {
+		width = w
+	}
+
+	num = strings.TrimLeft(strings.TrimSpace(num), "0")
+	if len(num) < width {
+		num = strings.Repeat("0", width-len(num)) + num
+	}
+	return domain.ParseCode(prefix + "-" + num)
+}
+
+// Canonical 对已解析的 CODE 再做一次规范化（例如 out/ 目录名、缓存键）。
+// c 不是合法 CODE 或规范化失败时原样返回。
+func (n Normalizer) Canonical(c domain.Code) domain.Code {
+	prefix, num, ok := strings.Cut(string(c), "-")
+	if !ok {
+		return c
+	}
+	if out, ok := n.Normalize(prefix, num); ok {
+		return out
+	}
+	return c
+}
@@ -0,0 +1,56 @@
+package code
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func TestNormalizer_ZeroPadding(t *testing.T) {
+	n := DefaultNormalizer()
+	cases := []struct {
+		prefix, num string
+		want        domain.Code
+	}{
+		{"abp", "01", "ABP-001"},
+		{"ABP", "001", "ABP-001"},
+		{"ABP", "0001", "ABP-001"},
+		{"CAWD", "895", "CAWD-895"},
+		{"SSIS", "01234", "SSIS-1234"},
+		{"heyzo", "123", "HEYZO-0123"}, // 默认按 4 位收录
+	}
+	for _, c := range cases {
+		got, ok := n.Normalize(c.prefix, c.num)
+		if !ok || got != c.want {
+			t.Fatalf("%s-%s：期望 %s，实际 %q ok=%v", c.prefix, c.num, c.want, got, ok)
+		}
+	}
+
+	custom := Normalizer{MinDigits: 3, PrefixDigits: map[string]int{"ABC": 5}, PrefixAliases: map[string]string{"OLD": "NEW"}}
+	if got, _ := custom.Normalize("abc", "12"); got != "ABC-00012" {
+		t.Fatalf("prefix_digits 未生效：%q", got)
+	}
+	if got, _ := custom.Normalize("old", "7"); got != "NEW-007" {
+		t.Fatalf("prefix_aliases 未生效：%q", got)
+	}
+	if got := custom.Canonical("OLD-07"); got != "NEW-007" {
+		t.Fatalf("Canonical 不符合预期：%q", got)
+	}
+}
+
+func TestExtract_PaddingVariantsAreSameCode(t *testing.T) {
+	for _, base := range []string{"abp-01", "ABP-001", "abp_0001"} {
+		v := domain.VideoFile{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "x", base+".mp4"), Base: base}
+		got, err := Extract(v)
+		if err != nil || got != "ABP-001" {
+			t.Fatalf("%s：期望 ABP-001，实际 %q err=%v", base, got, err)
+		}
+	}
+
+	// 同一文件名里的补零变体不算 ambiguous。
+	v := domain.VideoFile{AbsPath: filepath.Join(string(filepath.Separator), "tmp", "ABP-0001", "abp-01.mp4"), Base: "abp-01"}
+	if got, err := Extract(v); err != nil || got != "ABP-001" {
+		t.Fatalf("文件名与目录的补零变体应合并：%q err=%v", got, err)
+	}
+}
@@ -7,7 +7,18 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
+
+	"github.com/John-Robertt/AVMC/internal/actors"
+	"github.com/John-Robertt/AVMC/internal/code"
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/imgx"
+	"github.com/John-Robertt/AVMC/internal/nfo"
+	"github.com/John-Robertt/AVMC/internal/scan"
+	"github.com/John-Robertt/AVMC/internal/tags"
+	"github.com/John-Robertt/AVMC/internal/translate"
 )
 
 const (
@@ -24,6 +35,11 @@ const (
 	DefaultProvider = "javbus"
 	// DefaultConcurrency 是并发的内置默认值（当配置未指定时）。
 	DefaultConcurrency = 4
+
+	// DefaultDownloadMaxMB 是单张图片下载的默认大小上限（MiB）。
+	DefaultDownloadMaxMB = 20
+	// DefaultPosterStrategy 是 poster 裁切策略的默认值（按 fanart 宽高比自动选择）。
+	DefaultPosterStrategy = imgx.PosterAuto
 )
 
 // CLIArgs 只包含 CLI 暴露的三项入口（path/provider/apply），并保留“是否显式指定”的信息。
@@ -40,21 +56,192 @@ type CLIArgs struct {
 
 // FileConfig 对应 avmc.json（v2）的解析结构。
 type FileConfig struct {
-	Path         string          `json:"path"`
-	Provider     string          `json:"provider"`
-	Apply        *bool           `json:"apply"`
-	Concurrency  int             `json:"concurrency"`
-	Proxy        *ProxyConfig    `json:"proxy"`
-	ImageProxy   bool            `json:"image_proxy"`
-	ExcludeDirs  []string        `json:"exclude_dirs"`
-	JavDBBaseURL string          `json:"javdb_base_url"`
-	_            json.RawMessage `json:"-"` // 预留：禁止在 Phase 1 做“未知字段报错”的决定
+	Path         string           `json:"path"`
+	Provider     string           `json:"provider"`
+	Apply        *bool            `json:"apply"`
+	Concurrency  int              `json:"concurrency"`
+	Pools        *PoolsConfig     `json:"pools"`
+	Proxy        *ProxyConfig     `json:"proxy"`
+	ImageProxy   bool             `json:"image_proxy"`
+	ExcludeDirs  []string         `json:"exclude_dirs"`
+	JavDBBaseURL string           `json:"javdb_base_url"`
+	FillOut      bool             `json:"fill_out"`
+	Artwork      *ArtworkConfig   `json:"artwork"`
+	Poster       *PosterConfig    `json:"poster"`
+	Fanart       *ImageConfig     `json:"fanart"`
+	Scan         *ScanConfig      `json:"scan"`
+	Code         *CodeConfig      `json:"code"`
+	Markers      *MarkersConfig   `json:"markers"`
+	Translate    *TranslateConfig `json:"translate"`
+	Tags         *TagsConfig      `json:"tags"`
+	Actors       *ActorsConfig    `json:"actors"`
+	NFO          *NFOConfig       `json:"nfo"`
+	Dupes        *DupesConfig     `json:"dupes"`
+	Download     *DownloadConfig  `json:"download"`
+	_            json.RawMessage  `json:"-"` // 预留：禁止在 Phase 1 做“未知字段报错”的决定
+}
+
+// PoolsConfig 分别设置执行阶段三个 worker pool 的大小；0 表示沿用 concurrency。
+type PoolsConfig struct {
+	Meta  int `json:"meta"`  // 刮削与标题翻译（受代理/站点限速影响）
+	Image int `json:"image"` // 图片下载与 sidecar 写入（受图片 CDN 影响）
+	FS    int `json:"fs"`    // 视频移动（本地磁盘）
+}
+
+// Pools 是执行阶段各 worker pool 的大小（已合并默认值并截断到 [1, 32]）。
+// 零值字段由执行层按 Concurrency 兜底（便于测试直接构造 EffectiveConfig）。
+type Pools struct {
+	Meta  int
+	Image int
+	FS    int
 }
 
 type ProxyConfig struct {
 	URL string `json:"url"`
 }
 
+// ArtworkConfig 控制 fanart/poster 之外的可选 artwork（默认全部关闭）。
+type ArtworkConfig struct {
+	// Extrafanart 是 extrafanart/ 下最多下载的样品图张数；0 表示关闭。
+	Extrafanart int  `json:"extrafanart"`
+	Thumb       bool `json:"thumb"`
+	Landscape   bool `json:"landscape"`
+}
+
+// ScanConfig 控制哪些文件被视为待整理的视频（目录级排除仍用 exclude_dirs）。
+type ScanConfig struct {
+	// VideoExts 为空时使用内置默认列表；大小写不敏感，可省略前导 '.'。
+	VideoExts []string `json:"video_exts"`
+	// MinSizeMB 小于该大小（MiB）的视频跳过；0 表示不限。
+	MinSizeMB int `json:"min_size_mb"`
+	// ExcludePatterns 是文件名 glob（不区分大小写）；未配置时使用 DefaultExcludePatterns，
+	// 显式配置为 [] 表示不排除任何文件名。
+	ExcludePatterns []string `json:"exclude_patterns"`
+	// Hash 为 true 时为每个视频计算内容快速指纹（只读首尾 128KiB）：识别同一文件的多个路径，并校验移动。
+	Hash bool `json:"hash"`
+}
+
+// CodeConfig 控制 CODE 的规范化（补零位数与前缀别名）。
+type CodeConfig struct {
+	// MinDigits 是数字段最小位数（2~5）；0 表示默认 3。
+	MinDigits int `json:"min_digits"`
+	// PrefixDigits 覆盖特定前缀的最小位数（与内置默认合并，例如 HEYZO=4）。
+	PrefixDigits map[string]int `json:"prefix_digits"`
+	// PrefixAliases 把别名前缀映射为规范前缀（例如文件名使用的旧前缀）。
+	PrefixAliases map[string]string `json:"prefix_aliases"`
+}
+
+// MarkersConfig 控制文件名 marker（-C/-UC/-4K 等）的额外用途；写入 NFO 总是开启。
+type MarkersConfig struct {
+	// FolderSuffix 为 true 时，新建的 out 目录名带 marker 后缀（例如 out/ABC-123-C/）。
+	FolderSuffix bool `json:"folder_suffix"`
+}
+
+// TranslateConfig 控制标题翻译（未配置或 backend 为空时关闭）。
+type TranslateConfig struct {
+	// Backend：dict | command | http。
+	Backend string `json:"backend"`
+	// Target 是目标语言（传给 command/http；默认 zh）。
+	Target string `json:"target"`
+	// DictFile 是字典文件（JSON 对象 {"原文":"译文"}）；相对路径以 path 为基准。
+	DictFile string `json:"dict_file"`
+	// Command 是外部命令 argv：原文写入 stdin，译文从 stdout 读取。
+	Command []string `json:"command"`
+	// URL 是翻译接口：POST {"text","target"}，响应 {"text"}。
+	URL string `json:"url"`
+	// TimeoutSec 是 command/http 单次翻译超时（秒）；0 表示默认 30。
+	TimeoutSec int `json:"timeout_sec"`
+}
+
+// TagsConfig 控制 NFO 的 genre/tag：映射字典与来源策略。
+type TagsConfig struct {
+	// MapFile 是映射字典文件（JSON 对象 {"原值":"目标值"}，目标为空串表示丢弃）；相对路径以 path 为基准。
+	MapFile string `json:"map_file"`
+	// Map 是内联映射，与 MapFile 合并（同一原值以 Map 为准）。
+	Map map[string]string `json:"map"`
+	// 各来源写入的位置：none | tag | genre | both；空表示默认（actors/markers=both，studio/series=none）。
+	Actors  string `json:"actors"`
+	Studio  string `json:"studio"`
+	Series  string `json:"series"`
+	Markers string `json:"markers"`
+}
+
+// ActorsConfig 控制演员名规范化：别名表与括号别名剥离。
+type ActorsConfig struct {
+	// AliasesFile 是别名文件（JSON 对象 {"规范名":["别名",...]}）；相对路径以 path 为基准。
+	AliasesFile string `json:"aliases_file"`
+	// Aliases 是内联别名表，与 AliasesFile 合并（同一规范名以 Aliases 为准）。
+	Aliases map[string][]string `json:"aliases"`
+	// StripParens 为 true 时剥离名字末尾的括号别名（例如 "三上悠亜（鬼頭桃菜）" => "三上悠亜"）。
+	StripParens bool `json:"strip_parens"`
+	// Portraits：none（默认，NFO 引用远程头像 URL）| item（out/<CODE>/.actors/）| library（全库共享目录）。
+	Portraits string `json:"portraits"`
+	// PortraitDir 是 library 模式的头像目录；相对路径以 path 为基准，默认 out/.actors。
+	PortraitDir string `json:"portrait_dir"`
+}
+
+// NFOConfig 控制 NFO 的结构（profile）、标题类模板与固定元素。
+type NFOConfig struct {
+	// Profile：kodi | jellyfin | emby | plex；空表示默认结构（与旧版本一致）。
+	Profile string `json:"profile"`
+	// Templates 是 title/sorttitle/originaltitle/outline 的模板（{code}、{title} 等占位符）。
+	Templates *NFOTemplates `json:"templates"`
+	// MPAA / Country 未配置时为 R18+ / JP；配置为空串表示不输出。
+	MPAA    *string `json:"mpaa"`
+	Country *string `json:"country"`
+	// UniqueID 控制是否写 <uniqueid type="<provider>">；未配置时由 profile 决定（kodi/jellyfin/emby 写）。
+	UniqueID *bool `json:"uniqueid"`
+	// LockData 为 true 时写 <lockdata>true</lockdata>（Jellyfin/Emby）。
+	LockData bool `json:"lockdata"`
+	// FileInfo 控制是否写 <fileinfo><streamdetails>（视频头部解析结果）；未配置时为 true。
+	FileInfo *bool `json:"fileinfo"`
+}
+
+// DupesConfig 控制同一 CODE、同一分段存在多个版本（例如 720p .avi 与 1080p .mkv）时的处理。
+type DupesConfig struct {
+	// Mode：keep_all（默认）| keep_best | mark，见 domain.Dupes*。
+	Mode string `json:"mode"`
+	// Prefer：resolution（默认）| size。
+	Prefer string `json:"prefer"`
+	// Dir 是 keep_best 的隔离目录（相对 path）；默认 dupes。扫描时自动排除。
+	Dir string `json:"dir"`
+}
+
+// DownloadConfig 控制图片下载（fanart/extrafanart/演员头像）。
+type DownloadConfig struct {
+	// MaxMB 是单张图片的大小上限（MiB）；0 表示默认 20，超过即下载失败。
+	MaxMB int `json:"max_mb"`
+}
+
+// NFOTemplates 是标题类元素的模板；空串表示内置规则。
+type NFOTemplates struct {
+	Title         string `json:"title"`
+	SortTitle     string `json:"sorttitle"`
+	OriginalTitle string `json:"originaltitle"`
+	Outline       string `json:"outline"`
+}
+
+// DefaultExcludePatterns 过滤常见的预览片段/预告片（例如 abc-123-sample.mp4）。
+var DefaultExcludePatterns = []string{
+	"*-sample.*", "*_sample.*", "sample.*",
+	"*-trailer.*", "*_trailer.*", "trailer.*",
+}
+
+// ImageConfig 控制 sidecar 图片的尺寸上限与编码（fanart/extrafanart/poster 共用）。
+type ImageConfig struct {
+	MaxWidth  int    `json:"max_width"`  // 0 表示不限
+	MaxHeight int    `json:"max_height"` // 0 表示不限
+	Quality   int    `json:"quality"`    // 1~100；0 表示默认
+	Format    string `json:"format"`     // jpeg | original（original 仅 fanart 可用）
+}
+
+// PosterConfig 控制 poster.jpg 的生成方式。
+type PosterConfig struct {
+	// Strategy：auto（默认，按 fanart 宽高比选择）| right_half | center | pad | smart。
+	Strategy string `json:"strategy"`
+	ImageConfig
+}
+
 // EffectiveConfig 是合并并做最小规范化后的最终配置（实现层直接消费，不再做二次默认/优先级判断）。
 type EffectiveConfig struct {
 	Path string
@@ -63,13 +250,72 @@ type EffectiveConfig struct {
 	Apply    bool
 
 	Concurrency int
+	Pools       Pools
 	ProxyURL    string
 	ImageProxy  bool
 	ExcludeDirs []string
 
+	// 扫描过滤：VideoExts 已规范化（小写、含 '.'、非空）；MinSizeBytes=0 表示不限。
+	VideoExts       []string
+	MinSizeBytes    int64
+	ExcludePatterns []string
+	// DownloadMaxBytes 是单张图片下载的大小上限；0 表示不限（仅测试直接构造时出现）。
+	DownloadMaxBytes int64
+	// Hash 为 true 时计算视频内容快速指纹（同内容去重 + 移动校验）；默认 false（扫描只做 stat）。
+	Hash bool
+
+	// Code 是 CODE 规范化规则（已合并默认值）；提取、分组、out/ 查找与缓存键都以它为准。
+	Code code.Normalizer
+
+	// Overrides 来自 <path>/avmc.overrides.json（可选）：按 CODE 固定 provider/URL、覆盖字段或忽略，按文件强制 CODE。
+	Overrides domain.Overrides
+
+	// Tags 是 NFO genre/tag 的映射字典与来源策略（零值即默认策略、不映射）。
+	Tags tags.Options
+
+	// Actors 是演员名规范化规则（零值即不改写）；在刮削之后、字段覆盖之前应用。
+	Actors actors.Options
+
+	// Portraits 是演员头像的存放方式（domain.Portraits*；空串等价于 none）；
+	// PortraitDir 仅 library 模式使用（绝对路径）。
+	Portraits   string
+	PortraitDir string
+
+	// NFO 是 NFO 的结构与模板（零值即默认结构）；Tags/Markers/Provider 由执行层按条目填充。
+	NFO nfo.Options
+
+	// Translator 非 nil 时，写 NFO 前把标题翻译为目标语言（原文保留在 <originaltitle>）。
+	Translator translate.Translator
+
+	// DupesMode / DupesPrefer 是多版本处理规则（已规范化；DupesMode 为空等价于 keep_all）；
+	// DupesDir 仅 keep_best 使用（绝对路径，扫描时排除）。
+	DupesMode   string
+	DupesPrefer string
+	DupesDir    string
+
+	// MarkerFolderSuffix 为 true 时新建的 out 目录名带 marker 后缀；已存在的目录（带或不带后缀）始终复用。
+	MarkerFolderSuffix bool
+
 	// JavDBBaseURL 允许在 javdb.com 不可达/被阻断时切换到可用镜像域名（可选）。
 	// 该字段属于高级能力，仅通过 avmc.json 配置，不暴露 CLI 参数。
 	JavDBBaseURL string
+
+	// FillOut 为 true 时，已有的 out/<CODE>/ 目录即使没有新视频也作为 zero-move 工作单元，
+	// 用于补齐缺失的 sidecar（例如只缺 poster.jpg）。
+	FillOut bool
+
+	// 可选 artwork：thumb.jpg/landscape.jpg 由 fanart 派生；extrafanart/ 下载样品图（上限 ExtrafanartMax）。
+	Thumb          bool
+	Landscape      bool
+	ExtrafanartMax int
+
+	// PosterStrategy 是 poster 的裁切策略（已规范化，非空）。
+	PosterStrategy string
+
+	// FanartImage / PosterImage 是图片尺寸上限与编码方式（已填默认值）。
+	// FanartImage 同时用于 extrafanart 样品图；thumb/landscape 复用最终的 fanart 字节。
+	FanartImage imgx.EncodeOptions
+	PosterImage imgx.EncodeOptions
 }
 
 // Error 是配置阶段的结构化错误（带 error_code）。
@@ -196,6 +442,11 @@ func merge(absPath string, cli CLIArgs, fc FileConfig, cfgPath string) (Effectiv
 		concurrency = 32
 	}
 
+	pools, err := poolsOptions(concurrency, fc.Pools)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
 	proxyURL := ""
 	if fc.Proxy != nil {
 		proxyURL = strings.TrimSpace(fc.Proxy.URL)
@@ -220,18 +471,472 @@ func merge(absPath string, cli CLIArgs, fc FileConfig, cfgPath string) (Effectiv
 		}
 	}
 
+	var artwork ArtworkConfig
+	if fc.Artwork != nil {
+		artwork = *fc.Artwork
+	}
+	if artwork.Extrafanart < 0 {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: fmt.Errorf("artwork.extrafanart 不能为负数：%d", artwork.Extrafanart)}
+	}
+
+	posterStrategy := DefaultPosterStrategy
+	if fc.Poster != nil && strings.TrimSpace(fc.Poster.Strategy) != "" {
+		posterStrategy = strings.ToLower(strings.TrimSpace(fc.Poster.Strategy))
+	}
+	if !imgx.ValidPosterStrategy(posterStrategy) {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: fmt.Errorf("poster.strategy 只能是 auto|right_half|center|pad|smart，实际是 %q", posterStrategy)}
+	}
+
+	var fanartIC, posterIC *ImageConfig
+	if fc.Fanart != nil {
+		fanartIC = fc.Fanart
+	}
+	if fc.Poster != nil {
+		posterIC = &fc.Poster.ImageConfig
+	}
+	fanartImage, err := imageOptions("fanart", fanartIC, imgx.EncodeOptions{Format: imgx.FormatOriginal}, true)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+	posterImage, err := imageOptions("poster", posterIC, imgx.EncodeOptions{Quality: imgx.DefaultPosterQuality, Format: imgx.FormatJPEG}, false)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	videoExts, minSize, patterns, err := scanOptions(fc.Scan)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	normalizer, err := codeNormalizer(fc.Code)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	tagOpts, err := tagOptions(absPath, fc.Tags)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	actorOpts, err := actorOptions(absPath, fc.Actors)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	portraits, portraitDir, err := portraitOptions(absPath, fc.Actors)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	nfoOpts, err := nfoOptions(fc.NFO)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	downloadMax := int64(DefaultDownloadMaxMB) << 20
+	if fc.Download != nil {
+		if fc.Download.MaxMB < 0 {
+			return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: fmt.Errorf("download.max_mb 不能为负数：%d", fc.Download.MaxMB)}
+		}
+		if fc.Download.MaxMB > 0 {
+			downloadMax = int64(fc.Download.MaxMB) << 20
+		}
+	}
+
+	dupesMode, dupesPrefer, dupesDir, err := dupesOptions(absPath, fc.Dupes)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	translator, err := newTranslator(absPath, fc.Translate)
+	if err != nil {
+		return EffectiveConfig{}, &Error{Code: ErrCodeInvalid, Path: cfgPath, Err: err}
+	}
+
+	// 覆盖规则依赖 CODE 规范化规则；错误归属于 avmc.overrides.json 本身。
+	overrides, err := loadOverrides(absPath, normalizer)
+	if err != nil {
+		return EffectiveConfig{}, err
+	}
+
 	return EffectiveConfig{
 		Path:         absPath,
 		Provider:     provider,
 		Apply:        apply,
 		Concurrency:  concurrency,
+		Pools:        pools,
 		ProxyURL:     proxyURL,
 		ImageProxy:   fc.ImageProxy,
 		ExcludeDirs:  append([]string(nil), fc.ExcludeDirs...),
 		JavDBBaseURL: javdbBaseURL,
+		FillOut:      fc.FillOut,
+
+		VideoExts:       videoExts,
+		MinSizeBytes:    minSize,
+		ExcludePatterns: patterns,
+		Hash:            fc.Scan != nil && fc.Scan.Hash,
+
+		DownloadMaxBytes: downloadMax,
+
+		Code:               normalizer,
+		Overrides:          overrides,
+		Translator:         translator,
+		Tags:               tagOpts,
+		Actors:             actorOpts,
+		Portraits:          portraits,
+		NFO:                nfoOpts,
+		PortraitDir:        portraitDir,
+		DupesMode:          dupesMode,
+		DupesPrefer:        dupesPrefer,
+		DupesDir:           dupesDir,
+		MarkerFolderSuffix: fc.Markers != nil && fc.Markers.FolderSuffix,
+
+		Thumb:          artwork.Thumb,
+		Landscape:      artwork.Landscape,
+		ExtrafanartMax: artwork.Extrafanart,
+
+		PosterStrategy: posterStrategy,
+
+		FanartImage: fanartImage,
+		PosterImage: posterImage,
+	}, nil
+}
+
+// tagOptions 校验 TagsConfig 并合并映射字典（文件在前，内联覆盖）。
+func tagOptions(root string, tc *TagsConfig) (tags.Options, error) {
+	if tc == nil {
+		return tags.Options{}, nil
+	}
+	p := tags.Policy{
+		Actors:  strings.ToLower(strings.TrimSpace(tc.Actors)),
+		Studio:  strings.ToLower(strings.TrimSpace(tc.Studio)),
+		Series:  strings.ToLower(strings.TrimSpace(tc.Series)),
+		Markers: strings.ToLower(strings.TrimSpace(tc.Markers)),
+	}
+	for name, v := range map[string]string{"actors": p.Actors, "studio": p.Studio, "series": p.Series, "markers": p.Markers} {
+		if !tags.ValidTarget(v) {
+			return tags.Options{}, fmt.Errorf("tags.%s 只能是 none|tag|genre|both，实际是 %q", name, v)
+		}
+	}
+
+	var m tags.Mapper
+	if strings.TrimSpace(tc.MapFile) != "" {
+		raw, err := tags.LoadMapFile(absCleanFrom(root, tc.MapFile))
+		if err != nil {
+			return tags.Options{}, fmt.Errorf("tags.map_file：%w", err)
+		}
+		m = tags.NewMapper(raw)
+	}
+	return tags.Options{Policy: p, Map: m.Merge(tc.Map)}, nil
+}
+
+// actorOptions 读取别名文件并与内联别名表合并（文件在前，内联覆盖）。
+func actorOptions(root string, ac *ActorsConfig) (actors.Options, error) {
+	if ac == nil {
+		return actors.Options{}, nil
+	}
+	var a actors.Aliases
+	if strings.TrimSpace(ac.AliasesFile) != "" {
+		raw, err := actors.LoadAliasFile(absCleanFrom(root, ac.AliasesFile))
+		if err != nil {
+			return actors.Options{}, fmt.Errorf("actors.aliases_file：%w", err)
+		}
+		if a, err = actors.NewAliases(raw); err != nil {
+			return actors.Options{}, fmt.Errorf("actors.aliases_file：%w", err)
+		}
+	}
+	a, err := a.Merge(ac.Aliases)
+	if err != nil {
+		return actors.Options{}, fmt.Errorf("actors.aliases：%w", err)
+	}
+	return actors.Options{Aliases: a, StripParens: ac.StripParens}, nil
+}
+
+// portraitOptions 校验头像模式；none 规范化为空串，library 模式返回头像目录的绝对路径。
+func portraitOptions(root string, ac *ActorsConfig) (string, string, error) {
+	if ac == nil {
+		return "", "", nil
+	}
+	mode := strings.ToLower(strings.TrimSpace(ac.Portraits))
+	switch mode {
+	case "", domain.PortraitsNone, domain.PortraitsItem:
+		if strings.TrimSpace(ac.PortraitDir) != "" {
+			return "", "", errors.New("actors.portrait_dir 仅在 actors.portraits=library 时可用")
+		}
+		if mode == domain.PortraitsNone {
+			mode = ""
+		}
+		return mode, "", nil
+	case domain.PortraitsLibrary:
+		dir := filepath.Join(root, "out", domain.ActorsDir)
+		if strings.TrimSpace(ac.PortraitDir) != "" {
+			dir = absCleanFrom(root, ac.PortraitDir)
+		}
+		return mode, dir, nil
+	default:
+		return "", "", fmt.Errorf("actors.portraits 只能是 none|item|library，实际是 %q", ac.Portraits)
+	}
+}
+
+// poolsOptions 合并 pools 与 concurrency：未配置（0）的 pool 沿用 concurrency，超过 32 截断，负数非法。
+func poolsOptions(concurrency int, pc *PoolsConfig) (Pools, error) {
+	out := Pools{Meta: concurrency, Image: concurrency, FS: concurrency}
+	if pc == nil {
+		return out, nil
+	}
+	for _, f := range []struct {
+		name string
+		v    int
+		dst  *int
+	}{
+		{"pools.meta", pc.Meta, &out.Meta},
+		{"pools.image", pc.Image, &out.Image},
+		{"pools.fs", pc.FS, &out.FS},
+	} {
+		switch {
+		case f.v < 0:
+			return Pools{}, fmt.Errorf("%s 不能为负数：%d", f.name, f.v)
+		case f.v > 32:
+			*f.dst = 32
+		case f.v > 0:
+			*f.dst = f.v
+		}
+	}
+	return out, nil
+}
+
+// dupesOptions 校验多版本处理规则；keep_all 规范化为空串，keep_best 返回隔离目录的绝对路径。
+func dupesOptions(root string, dc *DupesConfig) (mode, prefer, dir string, err error) {
+	if dc == nil {
+		return "", "", "", nil
+	}
+	mode = strings.ToLower(strings.TrimSpace(dc.Mode))
+	switch mode {
+	case "", domain.DupesKeepAll, domain.DupesMark, domain.DupesKeepBest:
+	default:
+		return "", "", "", fmt.Errorf("dupes.mode 只能是 keep_all|keep_best|mark，实际是 %q", dc.Mode)
+	}
+	prefer = strings.ToLower(strings.TrimSpace(dc.Prefer))
+	switch prefer {
+	case "", domain.DupesPreferResolution, domain.DupesPreferSize:
+	default:
+		return "", "", "", fmt.Errorf("dupes.prefer 只能是 resolution|size，实际是 %q", dc.Prefer)
+	}
+	if mode == domain.DupesKeepAll {
+		mode = ""
+	}
+	if mode != domain.DupesKeepBest {
+		if strings.TrimSpace(dc.Dir) != "" {
+			return "", "", "", errors.New("dupes.dir 仅在 dupes.mode=keep_best 时可用")
+		}
+		return mode, prefer, "", nil
+	}
+
+	dir = filepath.Join(root, domain.DupesDir)
+	if strings.TrimSpace(dc.Dir) != "" {
+		dir = absCleanFrom(root, dc.Dir)
+	}
+	// 隔离目录不能是 path 本身，也不能位于 out/、cache/ 之内（否则会被当成作品目录或缓存）。
+	for _, reserved := range []string{"out", "cache"} {
+		r := filepath.Join(root, reserved)
+		if rel, e := filepath.Rel(r, dir); e == nil && (rel == "." || !strings.HasPrefix(rel, "..")) {
+			return "", "", "", fmt.Errorf("dupes.dir 不能位于 %s/ 之内：%s", reserved, dir)
+		}
+	}
+	if dir == filepath.Clean(root) {
+		return "", "", "", errors.New("dupes.dir 不能是 path 本身")
+	}
+	return mode, prefer, dir, nil
+}
+
+// nfoOptions 校验 NFOConfig（profile 与模板占位符）。
+func nfoOptions(nc *NFOConfig) (nfo.Options, error) {
+	if nc == nil {
+		return nfo.Options{}, nil
+	}
+	profile := strings.ToLower(strings.TrimSpace(nc.Profile))
+	if !nfo.ValidProfile(profile) {
+		return nfo.Options{}, fmt.Errorf("nfo.profile 只能是 kodi|jellyfin|emby|plex，实际是 %q", nc.Profile)
+	}
+	var t nfo.Templates
+	if nc.Templates != nil {
+		t = nfo.Templates{
+			Title:         strings.TrimSpace(nc.Templates.Title),
+			SortTitle:     strings.TrimSpace(nc.Templates.SortTitle),
+			OriginalTitle: strings.TrimSpace(nc.Templates.OriginalTitle),
+			Outline:       strings.TrimSpace(nc.Templates.Outline),
+		}
+		for name, tpl := range map[string]string{"title": t.Title, "sorttitle": t.SortTitle, "originaltitle": t.OriginalTitle, "outline": t.Outline} {
+			if err := nfo.ValidateTemplate(tpl); err != nil {
+				return nfo.Options{}, fmt.Errorf("nfo.templates.%s：%w", name, err)
+			}
+		}
+	}
+	return nfo.Options{
+		Profile:   profile,
+		Templates: t,
+		MPAA:      nc.MPAA,
+		Country:   nc.Country,
+		UniqueIDs: nc.UniqueID,
+		LockData:  nc.LockData,
+		FileInfo:  nc.FileInfo,
 	}, nil
 }
 
+// newTranslator 按配置构造翻译后端；未配置时返回 nil（关闭翻译）。
+func newTranslator(root string, tc *TranslateConfig) (translate.Translator, error) {
+	if tc == nil || strings.TrimSpace(tc.Backend) == "" {
+		return nil, nil
+	}
+	if tc.TimeoutSec < 0 {
+		return nil, fmt.Errorf("translate.timeout_sec 不能为负数：%d", tc.TimeoutSec)
+	}
+	dictFile := ""
+	if strings.TrimSpace(tc.DictFile) != "" {
+		dictFile = absCleanFrom(root, tc.DictFile)
+	}
+	t, err := translate.New(translate.Options{
+		Backend:  strings.ToLower(strings.TrimSpace(tc.Backend)),
+		Target:   tc.Target,
+		DictFile: dictFile,
+		Command:  tc.Command,
+		URL:      tc.URL,
+		Timeout:  time.Duration(tc.TimeoutSec) * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("translate：%w", err)
+	}
+	return t, nil
+}
+
+var prefixRE = regexp.MustCompile(`^[A-Z]{2,6}$`)
+
+// codeNormalizer 校验 CodeConfig 并与内置默认规则合并。
+func codeNormalizer(cc *CodeConfig) (code.Normalizer, error) {
+	n := code.DefaultNormalizer()
+	if cc == nil {
+		return n, nil
+	}
+
+	validDigits := func(d int) bool { return d >= 2 && d <= 5 }
+	if cc.MinDigits != 0 {
+		if !validDigits(cc.MinDigits) {
+			return code.Normalizer{}, fmt.Errorf("code.min_digits 必须在 2~5，实际是 %d", cc.MinDigits)
+		}
+		n.MinDigits = cc.MinDigits
+	}
+
+	digits := make(map[string]int, len(n.PrefixDigits)+len(cc.PrefixDigits))
+	for k, v := range n.PrefixDigits {
+		digits[k] = v
+	}
+	for k, v := range cc.PrefixDigits {
+		k = strings.ToUpper(strings.TrimSpace(k))
+		if !prefixRE.MatchString(k) {
+			return code.Normalizer{}, fmt.Errorf("code.prefix_digits 的前缀必须是 2~6 个字母：%q", k)
+		}
+		if !validDigits(v) {
+			return code.Normalizer{}, fmt.Errorf("code.prefix_digits[%s] 必须在 2~5，实际是 %d", k, v)
+		}
+		digits[k] = v
+	}
+	n.PrefixDigits = digits
+
+	if len(cc.PrefixAliases) > 0 {
+		aliases := make(map[string]string, len(cc.PrefixAliases))
+		for k, v := range cc.PrefixAliases {
+			k = strings.ToUpper(strings.TrimSpace(k))
+			v = strings.ToUpper(strings.TrimSpace(v))
+			if !prefixRE.MatchString(k) || !prefixRE.MatchString(v) {
+				return code.Normalizer{}, fmt.Errorf("code.prefix_aliases 的前缀必须是 2~6 个字母：%q => %q", k, v)
+			}
+			aliases[k] = v
+		}
+		n.PrefixAliases = aliases
+	}
+	return n, nil
+}
+
+// scanOptions 校验 ScanConfig 并填充默认值。
+func scanOptions(sc *ScanConfig) ([]string, int64, []string, error) {
+	if sc == nil {
+		sc = &ScanConfig{}
+	}
+
+	exts := append([]string(nil), scan.DefaultVideoExts...)
+	if len(sc.VideoExts) > 0 {
+		exts = make([]string, 0, len(sc.VideoExts))
+		for _, e := range sc.VideoExts {
+			e = strings.ToLower(strings.TrimSpace(e))
+			if e == "" || e == "." {
+				return nil, 0, nil, fmt.Errorf("scan.video_exts 不能包含空扩展名")
+			}
+			if !strings.HasPrefix(e, ".") {
+				e = "." + e
+			}
+			if scan.IsCompanionExt(e) {
+				return nil, 0, nil, fmt.Errorf("scan.video_exts 不能包含字幕扩展名：%q", e)
+			}
+			exts = append(exts, e)
+		}
+	}
+
+	if sc.MinSizeMB < 0 {
+		return nil, 0, nil, fmt.Errorf("scan.min_size_mb 不能为负数：%d", sc.MinSizeMB)
+	}
+
+	patterns := append([]string(nil), DefaultExcludePatterns...)
+	if sc.ExcludePatterns != nil {
+		patterns = make([]string, 0, len(sc.ExcludePatterns))
+		for _, p := range sc.ExcludePatterns {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			if _, err := filepath.Match(p, ""); err != nil {
+				return nil, 0, nil, fmt.Errorf("scan.exclude_patterns 含非法 pattern %q：%w", p, err)
+			}
+			patterns = append(patterns, p)
+		}
+	}
+
+	return exts, int64(sc.MinSizeMB) << 20, patterns, nil
+}
+
+// imageOptions 校验 ImageConfig 并在 def 的基础上覆盖非零字段。
+func imageOptions(name string, ic *ImageConfig, def imgx.EncodeOptions, allowOriginal bool) (imgx.EncodeOptions, error) {
+	if ic == nil {
+		return def, nil
+	}
+	opt := def
+	if ic.MaxWidth < 0 || ic.MaxHeight < 0 {
+		return imgx.EncodeOptions{}, fmt.Errorf("%s.max_width/max_height 不能为负数", name)
+	}
+	opt.MaxWidth = ic.MaxWidth
+	opt.MaxHeight = ic.MaxHeight
+
+	if ic.Quality != 0 {
+		if ic.Quality < 1 || ic.Quality > 100 {
+			return imgx.EncodeOptions{}, fmt.Errorf("%s.quality 必须在 1~100，实际是 %d", name, ic.Quality)
+		}
+		opt.Quality = ic.Quality
+	}
+
+	if f := strings.ToLower(strings.TrimSpace(ic.Format)); f != "" {
+		switch {
+		case f == imgx.FormatJPEG:
+		case f == imgx.FormatOriginal && allowOriginal:
+		default:
+			if allowOriginal {
+				return imgx.EncodeOptions{}, fmt.Errorf("%s.format 只能是 jpeg 或 original，实际是 %q", name, f)
+			}
+			return imgx.EncodeOptions{}, fmt.Errorf("%s.format 只能是 jpeg，实际是 %q", name, f)
+		}
+		opt.Format = f
+	}
+	return opt, nil
+}
+
 func validateProvider(p string) error {
 	switch p {
 	case "javbus", "javdb":
@@ -1,9 +1,16 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/infra/imgx"
+	"github.com/John-Robertt/AVMC/internal/nfo"
+	"github.com/John-Robertt/AVMC/internal/scan"
+	"github.com/John-Robertt/AVMC/internal/tags"
 )
 
 func TestLoadEffective_ConfigNotFound(t *testing.T) {
@@ -143,3 +150,417 @@ func writeFile(t *testing.T, path string, b []byte) {
 		t.Fatalf("写入文件失败 %q：%v", path, err)
 	}
 }
+
+func TestLoadEffective_Artwork(t *testing.T) {
+	cwd := t.TempDir()
+	writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(`{"path":"p","artwork":{"extrafanart":8,"thumb":true}}`))
+
+	eff, err := LoadEffective(cwd, CLIArgs{})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.ExtrafanartMax != 8 || !eff.Thumb || eff.Landscape {
+		t.Fatalf("artwork 不符合预期：%+v", eff)
+	}
+
+	writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(`{"path":"p","artwork":{"extrafanart":-1}}`))
+	if _, err := LoadEffective(cwd, CLIArgs{}); Code(err) != ErrCodeInvalid {
+		t.Fatalf("期望 %q，实际 err=%v", ErrCodeInvalid, err)
+	}
+}
+
+func TestLoadEffective_ImageOptions(t *testing.T) {
+	cwd := t.TempDir()
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.FanartImage.Format != imgx.FormatOriginal || eff.PosterImage.Format != imgx.FormatJPEG || eff.PosterImage.Quality != imgx.DefaultPosterQuality {
+		t.Fatalf("默认图片选项不符合预期：fanart=%+v poster=%+v", eff.FanartImage, eff.PosterImage)
+	}
+
+	writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(`{"path":"p","fanart":{"max_width":1920,"quality":85,"format":"jpeg"},"poster":{"strategy":"pad","max_height":900}}`))
+	eff, err = LoadEffective(cwd, CLIArgs{})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.FanartImage != (imgx.EncodeOptions{MaxWidth: 1920, Quality: 85, Format: imgx.FormatJPEG}) {
+		t.Fatalf("fanart 选项不符合预期：%+v", eff.FanartImage)
+	}
+	if eff.PosterStrategy != imgx.PosterPad || eff.PosterImage.MaxHeight != 900 || eff.PosterImage.Quality != imgx.DefaultPosterQuality {
+		t.Fatalf("poster 选项不符合预期：%s %+v", eff.PosterStrategy, eff.PosterImage)
+	}
+
+	for _, bad := range []string{
+		`{"path":"p","fanart":{"quality":101}}`,
+		`{"path":"p","fanart":{"max_width":-1}}`,
+		`{"path":"p","fanart":{"format":"webp"}}`,
+		`{"path":"p","poster":{"format":"original"}}`,
+		`{"path":"p","poster":{"strategy":"left"}}`,
+	} {
+		writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_ScanOptions(t *testing.T) {
+	cwd := t.TempDir()
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(eff.VideoExts) != len(scan.DefaultVideoExts) || eff.MinSizeBytes != 0 || len(eff.ExcludePatterns) != len(DefaultExcludePatterns) || eff.Hash {
+		t.Fatalf("默认扫描选项不符合预期：%+v %d %+v", eff.VideoExts, eff.MinSizeBytes, eff.ExcludePatterns)
+	}
+
+	writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(`{"path":"p","scan":{"video_exts":["MP4","strm"],"min_size_mb":100,"exclude_patterns":[],"hash":true}}`))
+	eff, err = LoadEffective(cwd, CLIArgs{})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(eff.VideoExts) != 2 || eff.VideoExts[0] != ".mp4" || eff.VideoExts[1] != ".strm" {
+		t.Fatalf("video_exts 应规范化：%+v", eff.VideoExts)
+	}
+	if eff.MinSizeBytes != 100<<20 || len(eff.ExcludePatterns) != 0 || !eff.Hash {
+		t.Fatalf("min_size/exclude_patterns/hash 不符合预期：%d %+v %v", eff.MinSizeBytes, eff.ExcludePatterns, eff.Hash)
+	}
+
+	for _, bad := range []string{
+		`{"path":"p","scan":{"min_size_mb":-1}}`,
+		`{"path":"p","scan":{"video_exts":[""]}}`,
+		`{"path":"p","scan":{"video_exts":[".srt"]}}`,
+		`{"path":"p","scan":{"exclude_patterns":["[abc"]}}`,
+	} {
+		writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_CodeNormalizer(t *testing.T) {
+	cwd := t.TempDir()
+	writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(`{"path":"p","code":{"min_digits":4,"prefix_digits":{"abc":2},"prefix_aliases":{"old":"new"}}}`))
+
+	eff, err := LoadEffective(cwd, CLIArgs{})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.Code.MinDigits != 4 || eff.Code.PrefixDigits["ABC"] != 2 || eff.Code.PrefixDigits["HEYZO"] != 4 || eff.Code.PrefixAliases["OLD"] != "NEW" {
+		t.Fatalf("code 规则不符合预期：%+v", eff.Code)
+	}
+
+	for _, bad := range []string{
+		`{"path":"p","code":{"min_digits":6}}`,
+		`{"path":"p","code":{"prefix_digits":{"A1":3}}}`,
+		`{"path":"p","code":{"prefix_digits":{"ABC":1}}}`,
+		`{"path":"p","code":{"prefix_aliases":{"OLD":"N-1"}}}`,
+	} {
+		writeFile(t, filepath.Join(cwd, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_Overrides(t *testing.T) {
+	cwd := t.TempDir()
+	root := filepath.Join(cwd, "p")
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil || len(eff.Overrides.Codes) != 0 || len(eff.Overrides.Files) != 0 {
+		t.Fatalf("无覆盖文件时应为空规则：%+v err=%v", eff.Overrides, err)
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	writeFile(t, filepath.Join(root, OverridesFileName), []byte(`{
+		"codes": {
+			"abp-01": {"provider": "JavDB", "url": "https://javdb.test/v/x", "meta": {"title": "正确标题"}},
+			"CAWD-895": {"ignore": true}
+		},
+		"files": {"in/../in/odd name.mp4": "ssis-1", "in/x.mp4": "ABP-001"}
+	}`))
+	eff, err = LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	ov, ok := eff.Overrides.ForCode("ABP-001")
+	if !ok || ov.Provider != "javdb" || ov.URL != "https://javdb.test/v/x" || ov.Meta == nil || *ov.Meta.Title != "正确标题" {
+		t.Fatalf("codes 应按 CODE 规范化：%+v", eff.Overrides.Codes)
+	}
+	if ov, ok := eff.Overrides.ForCode("CAWD-895"); !ok || !ov.Ignore {
+		t.Fatalf("ignore 未生效：%+v", eff.Overrides.Codes)
+	}
+	if eff.Overrides.Files["in/x.mp4"] != "ABP-001" || eff.Overrides.Files["in/odd name.mp4"] != "SSIS-001" {
+		t.Fatalf("files 不符合预期：%+v", eff.Overrides.Files)
+	}
+
+	for _, bad := range []string{
+		`{"codes":{"nope":{}}}`,
+		`{"codes":{"ABP-1":{"url":"https://javdb.test/v/x"}}}`,
+		`{"codes":{"ABP-1":{"provider":"dmm"}}}`,
+		`{"codes":{"ABP-1":{"provider":"javdb","url":"ftp://x"}}}`,
+		`{"codes":{"ABP-1":{},"abp-001":{}}}`,
+		`{"files":{"../x.mp4":"ABP-1"}}`,
+		`{"files":{"x.mp4":"bad"}}`,
+	} {
+		writeFile(t, filepath.Join(root, OverridesFileName), []byte(bad))
+		_, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+		var ce *Error
+		if Code(err) != ErrCodeInvalid || !errors.As(err, &ce) || filepath.Base(ce.Path) != OverridesFileName {
+			t.Fatalf("%s：期望 %q 且指向覆盖文件，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_Translate(t *testing.T) {
+	cwd := t.TempDir()
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil || eff.Translator != nil {
+		t.Fatalf("默认应关闭翻译：%v err=%v", eff.Translator, err)
+	}
+
+	root := filepath.Join(cwd, "p")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	writeFile(t, filepath.Join(root, "titles.json"), []byte(`{"原題":"标题"}`))
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"translate":{"backend":"dict","dict_file":"titles.json","target":"zh-CN"}}`))
+	eff, err = LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.Translator == nil || eff.Translator.Name() != "dict:zh-CN" {
+		t.Fatalf("dict 翻译未启用：%v", eff.Translator)
+	}
+
+	for _, bad := range []string{
+		`{"translate":{"backend":"dict","dict_file":"missing.json"}}`,
+		`{"translate":{"backend":"http","url":"not a url"}}`,
+		`{"translate":{"backend":"command"}}`,
+		`{"translate":{"backend":"http","url":"http://127.0.0.1:1","timeout_sec":-1}}`,
+	} {
+		writeFile(t, filepath.Join(root, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{Path: "p"}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_Actors(t *testing.T) {
+	cwd := t.TempDir()
+	root := filepath.Join(cwd, "p")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	writeFile(t, filepath.Join(root, "actors.json"), []byte(`{"三上悠亜":["鬼頭桃菜"],"A":["a1"]}`))
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"actors":{"aliases_file":"actors.json","aliases":{"A":["a2"]},"strip_parens":true}}`))
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if !eff.Actors.StripParens {
+		t.Fatalf("strip_parens 未生效")
+	}
+	if v, ok := eff.Actors.Aliases.Lookup("鬼頭桃菜"); !ok || v != "三上悠亜" {
+		t.Fatalf("别名文件未生效：%q %v", v, ok)
+	}
+	if _, ok := eff.Actors.Aliases.Lookup("a1"); ok {
+		t.Fatalf("内联表重新定义的规范名应覆盖文件")
+	}
+	if eff.Portraits != "" || eff.PortraitDir != "" {
+		t.Fatalf("默认不下载头像：%q %q", eff.Portraits, eff.PortraitDir)
+	}
+
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"actors":{"portraits":"Library"}}`))
+	eff, err = LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.Portraits != domain.PortraitsLibrary || eff.PortraitDir != filepath.Join(root, "out", domain.ActorsDir) {
+		t.Fatalf("library 默认目录不符合预期：%q %q", eff.Portraits, eff.PortraitDir)
+	}
+
+	for _, bad := range []string{
+		`{"actors":{"aliases":{"A":["x"],"B":["x"]}}}`,
+		`{"actors":{"aliases_file":"missing.json"}}`,
+		`{"actors":{"portraits":"all"}}`,
+		`{"actors":{"portraits":"item","portrait_dir":"people"}}`,
+	} {
+		writeFile(t, filepath.Join(root, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{Path: "p"}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_Dupes(t *testing.T) {
+	cwd := t.TempDir()
+	root := filepath.Join(cwd, "p")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.DupesMode != "" || eff.DupesDir != "" {
+		t.Fatalf("默认应为 keep_all：%q %q", eff.DupesMode, eff.DupesDir)
+	}
+
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"dupes":{"mode":"Keep_Best","prefer":"size"}}`))
+	eff, err = LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.DupesMode != domain.DupesKeepBest || eff.DupesPrefer != domain.DupesPreferSize || eff.DupesDir != filepath.Join(root, "dupes") {
+		t.Fatalf("dupes 配置不符合预期：%q %q %q", eff.DupesMode, eff.DupesPrefer, eff.DupesDir)
+	}
+
+	for _, bad := range []string{
+		`{"dupes":{"mode":"newest"}}`,
+		`{"dupes":{"prefer":"bitrate"}}`,
+		`{"dupes":{"mode":"mark","dir":"x"}}`,
+		`{"dupes":{"mode":"keep_best","dir":"out/dupes"}}`,
+		`{"dupes":{"mode":"keep_best","dir":"cache"}}`,
+		`{"dupes":{"mode":"keep_best","dir":"."}}`,
+	} {
+		writeFile(t, filepath.Join(root, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{Path: "p"}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_Pools(t *testing.T) {
+	cwd := t.TempDir()
+	root := filepath.Join(cwd, "p")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"concurrency":6}`))
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.Pools != (Pools{Meta: 6, Image: 6, FS: 6}) {
+		t.Fatalf("未配置 pools 时应沿用 concurrency：%+v", eff.Pools)
+	}
+
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"concurrency":6,"pools":{"meta":2,"image":100}}`))
+	eff, err = LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.Pools != (Pools{Meta: 2, Image: 32, FS: 6}) {
+		t.Fatalf("pools 合并/截断不符合预期：%+v", eff.Pools)
+	}
+
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"pools":{"fs":-1}}`))
+	if _, err := LoadEffective(cwd, CLIArgs{Path: "p"}); Code(err) != ErrCodeInvalid {
+		t.Fatalf("期望 %q，实际 err=%v", ErrCodeInvalid, err)
+	}
+}
+
+func TestLoadEffective_Download(t *testing.T) {
+	cwd := t.TempDir()
+	root := filepath.Join(cwd, "p")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.DownloadMaxBytes != DefaultDownloadMaxMB<<20 {
+		t.Fatalf("默认下载上限不符合预期：%d", eff.DownloadMaxBytes)
+	}
+
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"download":{"max_mb":5}}`))
+	eff, err = LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.DownloadMaxBytes != 5<<20 {
+		t.Fatalf("download.max_mb 不符合预期：%d", eff.DownloadMaxBytes)
+	}
+
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"download":{"max_mb":-1}}`))
+	if _, err := LoadEffective(cwd, CLIArgs{Path: "p"}); Code(err) != ErrCodeInvalid {
+		t.Fatalf("期望 %q，实际 err=%v", ErrCodeInvalid, err)
+	}
+}
+
+func TestLoadEffective_NFO(t *testing.T) {
+	cwd := t.TempDir()
+	root := filepath.Join(cwd, "p")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"nfo":{"profile":"Kodi","templates":{"title":"{title}"},"mpaa":"","uniqueid":false,"lockdata":true,"fileinfo":false}}`))
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	n := eff.NFO
+	if n.Profile != nfo.ProfileKodi || n.Templates.Title != "{title}" || n.MPAA == nil || *n.MPAA != "" || n.Country != nil || n.UniqueIDs == nil || *n.UniqueIDs || !n.LockData || n.FileInfo == nil || *n.FileInfo {
+		t.Fatalf("nfo 配置不符合预期：%+v", n)
+	}
+
+	for _, bad := range []string{
+		`{"nfo":{"profile":"xbmc"}}`,
+		`{"nfo":{"templates":{"outline":"{plot"}}}`,
+		`{"nfo":{"templates":{"sorttitle":"{unknown}"}}}`,
+	} {
+		writeFile(t, filepath.Join(root, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{Path: "p"}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
+
+func TestLoadEffective_Tags(t *testing.T) {
+	cwd := t.TempDir()
+	root := filepath.Join(cwd, "p")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("创建目录失败：%v", err)
+	}
+	writeFile(t, filepath.Join(root, "genres.json"), []byte(`{"高畫質":"","中出し":"中出"}`))
+	writeFile(t, filepath.Join(root, "avmc.json"), []byte(`{"tags":{"map_file":"genres.json","map":{"中出し":"Creampie"},"actors":"none","studio":"Tag"}}`))
+
+	eff, err := LoadEffective(cwd, CLIArgs{Path: "p"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if eff.Tags.Policy.Actors != tags.TargetNone || eff.Tags.Policy.Studio != tags.TargetTag || eff.Tags.Policy.Markers != "" {
+		t.Fatalf("策略不符合预期：%+v", eff.Tags.Policy)
+	}
+	if v, ok := eff.Tags.Map.Map("中出し"); !ok || v != "Creampie" {
+		t.Fatalf("内联映射应覆盖文件：%q %v", v, ok)
+	}
+	if _, ok := eff.Tags.Map.Map("高畫質"); ok {
+		t.Fatalf("映射为空串的值应被丢弃")
+	}
+
+	for _, bad := range []string{
+		`{"tags":{"actors":"all"}}`,
+		`{"tags":{"map_file":"missing.json"}}`,
+	} {
+		writeFile(t, filepath.Join(root, "avmc.json"), []byte(bad))
+		if _, err := LoadEffective(cwd, CLIArgs{Path: "p"}); Code(err) != ErrCodeInvalid {
+			t.Fatalf("%s：期望 %q，实际 err=%v", bad, ErrCodeInvalid, err)
+		}
+	}
+}
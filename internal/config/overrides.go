@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/code"
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// OverridesFileName 是覆盖规则文件名，位置固定在 <path>/ 下（与 avmc.json 同级，可选）。
+const OverridesFileName = "avmc.overrides.json"
+
+// OverridesFile 对应 avmc.overrides.json 的解析结构。
+type OverridesFile struct {
+	// Codes 的 key 是 CODE（大小写不敏感，按 code 规则补零）。
+	Codes map[string]domain.CodeOverride `json:"codes"`
+	// Files 的 key 是相对 <path> 的文件路径，value 是强制使用的 CODE。
+	Files map[string]string `json:"files"`
+}
+
+// loadOverrides 读取并校验 <root>/avmc.overrides.json；文件不存在时返回空规则。
+func loadOverrides(root string, n code.Normalizer) (domain.Overrides, error) {
+	p := filepath.Join(root, OverridesFileName)
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return domain.Overrides{}, nil
+		}
+		return domain.Overrides{}, &Error{Code: ErrCodeInvalid, Path: p, Err: err}
+	}
+	var f OverridesFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return domain.Overrides{}, &Error{Code: ErrCodeInvalid, Path: p, Err: err}
+	}
+	ov, err := normalizeOverrides(f, n)
+	if err != nil {
+		return domain.Overrides{}, &Error{Code: ErrCodeInvalid, Path: p, Err: err}
+	}
+	return ov, nil
+}
+
+func normalizeOverrides(f OverridesFile, n code.Normalizer) (domain.Overrides, error) {
+	out := domain.Overrides{}
+
+	if len(f.Codes) > 0 {
+		out.Codes = make(map[domain.Code]domain.CodeOverride, len(f.Codes))
+	}
+	for k, co := range f.Codes {
+		c, ok := parseOverrideCode(k, n)
+		if !ok {
+			return domain.Overrides{}, fmt.Errorf("codes 中的 CODE 无效：%q", k)
+		}
+		if _, dup := out.Codes[c]; dup {
+			return domain.Overrides{}, fmt.Errorf("codes 中 %q 重复（规范化后为 %s）", k, c)
+		}
+
+		co.Provider = strings.ToLower(strings.TrimSpace(co.Provider))
+		co.URL = strings.TrimSpace(co.URL)
+		if co.Provider != "" {
+			if err := validateProvider(co.Provider); err != nil {
+				return domain.Overrides{}, fmt.Errorf("codes.%s.%w", c, err)
+			}
+		}
+		if co.URL != "" {
+			if co.Provider == "" {
+				return domain.Overrides{}, fmt.Errorf("codes.%s.url 需要同时指定 provider", c)
+			}
+			u, err := url.Parse(co.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return domain.Overrides{}, fmt.Errorf("codes.%s.url 必须是 http/https 地址：%q", c, co.URL)
+			}
+		}
+		out.Codes[c] = co
+	}
+
+	if len(f.Files) > 0 {
+		out.Files = make(map[string]domain.Code, len(f.Files))
+	}
+	for k, v := range f.Files {
+		rel := path.Clean(filepath.ToSlash(strings.TrimSpace(k)))
+		if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+			return domain.Overrides{}, fmt.Errorf("files 的 key 必须是相对 path 的文件路径：%q", k)
+		}
+		c, ok := parseOverrideCode(v, n)
+		if !ok {
+			return domain.Overrides{}, fmt.Errorf("files.%s 的 CODE 无效：%q", k, v)
+		}
+		out.Files[rel] = c
+	}
+	return out, nil
+}
+
+// parseOverrideCode 接受大小写任意、已带 '-' 的 CODE，并按规范化规则补零。
+func parseOverrideCode(s string, n code.Normalizer) (domain.Code, bool) {
+	prefix, num, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return "", false
+	}
+	return n.Normalize(prefix, num)
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// AuditIssue.Kind 枚举（对外稳定；新增只追加不改名）。
+const (
+	AuditMissingNFO     = "missing_nfo"
+	AuditMissingPoster  = "missing_poster"
+	AuditMissingFanart  = "missing_fanart"
+	AuditNFOEmpty       = "nfo_empty"
+	AuditNFOInvalid     = "nfo_invalid"
+	AuditNFONumMismatch = "nfo_num_mismatch"
+	AuditImageEmpty     = "image_empty"
+	AuditImageInvalid   = "image_invalid"
+	AuditNoVideo        = "no_video"
+	AuditStrayTemp      = "stray_tmp"
+	AuditNotCodeDir     = "not_code_dir"
+	AuditNotDir         = "not_dir"
+	// AuditNonCanonicalDir：目录名是合法 CODE 但不是规范形态（例如 ABP-01，应为 ABP-001）。
+	AuditNonCanonicalDir = "non_canonical_dir"
+)
+
+// AuditReport 是 `avmc audit` 的对外稳定输出（风格与 RunReport 保持一致）。
+type AuditReport struct {
+	Path string `json:"path"`
+
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	Summary AuditSummary `json:"summary"`
+	Items   []AuditItem  `json:"items"`
+
+	// Fix 仅在 --fix 时非空：对可修复问题重新规划并执行后的 RunReport。
+	Fix *RunReport `json:"fix,omitempty"`
+}
+
+type AuditSummary struct {
+	Dirs       int `json:"dirs"`
+	OK         int `json:"ok"`
+	WithIssues int `json:"with_issues"`
+	Issues     int `json:"issues"`
+	Repairable int `json:"repairable"`
+}
+
+// AuditItem 对应 out/ 下的一个条目（通常是 out/<CODE>/ 目录）。
+type AuditItem struct {
+	// Code 为目录名解析出的 CODE；目录名不是合法 CODE 时为空串。
+	Code string `json:"code"`
+	// Dir 是相对 path 的路径（例如 out/CAWD-895）。
+	Dir    string       `json:"dir"`
+	Issues []AuditIssue `json:"issues"`
+}
+
+// AuditIssue 描述一个具体问题。
+//
+// Repairable=true 表示 `avmc audit --fix` 可以通过“删除坏文件 + 重新补齐 sidecar”修复；
+// 其余问题（例如 NFO 的 num 与目录不一致）可能是用户手工改动，必须人工确认。
+type AuditIssue struct {
+	Kind       string `json:"kind"`
+	File       string `json:"file"`
+	Repairable bool   `json:"repairable"`
+	Msg        string `json:"msg"`
+}
+
+// Finalize 统一时间为 UTC、稳定排序（按 dir 字典序；issues 按 kind+file），并计算 summary。
+func (r *AuditReport) Finalize() {
+	r.StartedAt = r.StartedAt.UTC()
+	r.FinishedAt = r.FinishedAt.UTC()
+
+	sort.SliceStable(r.Items, func(i, j int) bool { return r.Items[i].Dir < r.Items[j].Dir })
+
+	var s AuditSummary
+	for i := range r.Items {
+		it := &r.Items[i]
+		if it.Issues == nil {
+			it.Issues = []AuditIssue{}
+		}
+		sort.SliceStable(it.Issues, func(a, b int) bool {
+			if it.Issues[a].Kind != it.Issues[b].Kind {
+				return it.Issues[a].Kind < it.Issues[b].Kind
+			}
+			return it.Issues[a].File < it.Issues[b].File
+		})
+
+		s.Dirs++
+		if len(it.Issues) == 0 {
+			s.OK++
+			continue
+		}
+		s.WithIssues++
+		for _, is := range it.Issues {
+			s.Issues++
+			if is.Repairable {
+				s.Repairable++
+			}
+		}
+	}
+	r.Summary = s
+}
+
+// MarshalJSON 与 RunReport 相同：集中约束输出稳定性。
+func (r AuditReport) MarshalJSON() ([]byte, error) {
+	type Alias AuditReport
+	return json.Marshal(Alias(r))
+}
@@ -0,0 +1,29 @@
+package domain
+
+// 同一 CODE 下的多个版本（不是 cd1/cd2 分段）的处理方式（配置 dupes.mode）。
+const (
+	// DupesKeepAll：全部移入 out/<CODE>/（同名时按 __2 去冲突），报告不做标记；默认值。
+	DupesKeepAll = "keep_all"
+	// DupesKeepBest：只把最佳版本移入 out/<CODE>/，其余移到隔离目录 <dupes.dir>/<CODE>/。
+	DupesKeepBest = "keep_best"
+	// DupesMark：全部移入 out/<CODE>/（最佳版本优先占用原文件名），报告中标记每个版本。
+	DupesMark = "mark"
+)
+
+// 选择最佳版本的依据（配置 dupes.prefer）。
+const (
+	// DupesPreferResolution：先比较容器头部解析出的分辨率（像素数），相同或无法解析时比较文件大小；默认值。
+	DupesPreferResolution = "resolution"
+	// DupesPreferSize：只比较文件大小。
+	DupesPreferSize = "size"
+)
+
+// DupesDir 是 keep_best 隔离目录的默认名（相对 path）。
+const DupesDir = "dupes"
+
+// MovePlan.Duplicate / FileResult.duplicate 的取值（仅 keep_best / mark 模式下、且存在多个版本时非空）。
+const (
+	DuplicateBest        = "best"        // 该分段的最佳版本
+	DuplicateAlternate   = "alternate"   // 次选版本，仍移入 out/<CODE>/（mark）
+	DuplicateQuarantined = "quarantined" // 次选版本，移入隔离目录（keep_best）
+)
@@ -0,0 +1,64 @@
+package domain
+
+// Markers 是从视频文件名解析出的附加属性（例如 ABC-123-C、ABC-123-UC、ABC-123-4K）。
+type Markers struct {
+	Subtitle   bool `json:"subtitle"`   // -C / ch：中文字幕
+	Uncensored bool `json:"uncensored"` // -U / -UC：无码流出
+	UHD        bool `json:"uhd"`        // -4K / 2160p
+}
+
+// 写入 NFO 的标签（同时作为 genre 与 tag）。
+const (
+	MarkerLabelSubtitle   = "中文字幕"
+	MarkerLabelUncensored = "无码流出"
+	MarkerLabelUHD        = "4K"
+)
+
+// MarkerSuffixes 是 out/ 目录名允许的全部 marker 后缀（与 Markers.Suffix 的输出一一对应）。
+var MarkerSuffixes = []string{"", "-C", "-U", "-UC", "-4K", "-4K-C", "-4K-U", "-4K-UC"}
+
+// Any 表示是否有任一 marker。
+func (m Markers) Any() bool {
+	return m.Subtitle || m.Uncensored || m.UHD
+}
+
+// Merge 返回 m 与 o 的并集（同一 CODE 的多个文件合并为 item 级属性）。
+func (m Markers) Merge(o Markers) Markers {
+	return Markers{
+		Subtitle:   m.Subtitle || o.Subtitle,
+		Uncensored: m.Uncensored || o.Uncensored,
+		UHD:        m.UHD || o.UHD,
+	}
+}
+
+// Labels 返回写入 NFO 的标签（稳定顺序）。
+func (m Markers) Labels() []string {
+	out := make([]string, 0, 3)
+	if m.Subtitle {
+		out = append(out, MarkerLabelSubtitle)
+	}
+	if m.Uncensored {
+		out = append(out, MarkerLabelUncensored)
+	}
+	if m.UHD {
+		out = append(out, MarkerLabelUHD)
+	}
+	return out
+}
+
+// Suffix 返回目录名后缀（例如 "-4K-UC"）；无 marker 时为空串。
+func (m Markers) Suffix() string {
+	s := ""
+	if m.UHD {
+		s += "-4K"
+	}
+	switch {
+	case m.Uncensored && m.Subtitle:
+		s += "-UC"
+	case m.Uncensored:
+		s += "-U"
+	case m.Subtitle:
+		s += "-C"
+	}
+	return s
+}
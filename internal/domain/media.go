@@ -0,0 +1,17 @@
+package domain
+
+// MediaInfo 是从视频容器头部解析出的流信息（不解码任何帧；解析失败或格式不支持时为 nil）。
+//
+// 只记录第一个视频轨与第一个音频轨（默认轨优先）；codec 使用 Kodi streamdetails 的小写命名
+// （h264/hevc/av1/vp9/aac/ac3/eac3/dts/flac/opus 等）。
+type MediaInfo struct {
+	Container     string  `json:"container"` // mp4 | mkv
+	DurationS     float64 `json:"duration_s"`
+	VideoCodec    string  `json:"video_codec,omitempty"`
+	Width         int     `json:"width,omitempty"`
+	Height        int     `json:"height,omitempty"`
+	AudioCodec    string  `json:"audio_codec,omitempty"`
+	AudioChannels int     `json:"audio_channels,omitempty"`
+	// AudioLanguage 是 ISO 639-2 三字母代码（例如 jpn）；容器未标注或为 und 时为空。
+	AudioLanguage string `json:"audio_language,omitempty"`
+}
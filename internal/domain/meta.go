@@ -1,24 +1,108 @@
 package domain
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 // MovieMeta 是 provider 解析得到的结构化元数据（最小可用集）。
 //
 // 约束：
 // - Website 必须写入最终成功 provider 的详情页 URL（也是来源标记）
 // - 字段缺失允许为空，但结构必须稳定（不要为“全量字段”牺牲可维护性）
 type MovieMeta struct {
-	Code     Code
-	Title    string
+	Code  Code
+	Title string
+	// OriginalTitle 是翻译前的原始标题（仅启用翻译且得到译文时非空）。
+	OriginalTitle string
+	// Plot 是剧情简介（多数详情页没有，通常来自 overrides）。
+	Plot string
+	// Studio 是 NFO <studio>，取 Label 或 Maker 之一（由 provider 决定，与旧版本一致）；
+	// Maker（制作商）与 Label（发行商/厂牌）单独保留。
 	Studio   string
+	Maker    string
+	Label    string
+	Director string
 	Series   string
 	Release  string // ISO date, e.g. "2025-11-27"
 	Year     int
 	RuntimeM int
 
-	Actors []string
+	// Rating 统一为 0~10 分（站点为 5 星制时乘以 2）；Votes 是评分人数。0 表示站点未提供。
+	Rating float64
+	Votes  int
+
+	Actors []Actor
 	Genres []string
 	Tags   []string
 
 	Website   string
 	CoverURL  string
 	FanartURL string
+	// TrailerURL 是预告片地址（JavDB 为带签名的 m3u8，可能过期）。
+	TrailerURL string
+
+	// SampleURLs 是详情页的样品图（按页面顺序），用于 extrafanart/。
+	SampleURLs []string
+}
+
+// Actor 是一位演员：Thumb 是头像（远程 URL，下载后改写为本地路径），URL 是 provider 的演员页；两者都可能为空。
+type Actor struct {
+	Name  string
+	Thumb string
+	URL   string
+}
+
+// UnmarshalJSON 同时接受对象与纯字符串（旧版本缓存/plan 文件中的 Actors 是 []string）。
+func (a *Actor) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*a = Actor{Name: name}
+		return nil
+	}
+	type plain Actor
+	var p plain
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*a = Actor(p)
+	return nil
+}
+
+// ActorNames 返回演员名列表（去空白、跳过空名，保持顺序）。
+func ActorNames(actors []Actor) []string {
+	out := make([]string, 0, len(actors))
+	for _, a := range actors {
+		if n := strings.TrimSpace(a.Name); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// 演员头像的存放方式（actors.portraits）。
+const (
+	PortraitsNone    = "none"    // 不下载，NFO 中引用 provider 的头像 URL
+	PortraitsItem    = "item"    // out/<CODE>/.actors/<Name>.jpg（Kodi 约定）
+	PortraitsLibrary = "library" // 全库共享目录（默认 out/.actors/）
+)
+
+// ActorsDir 是存放演员头像的目录名（Kodi 约定）。
+const ActorsDir = ".actors"
+
+// ExtrafanartDir 是 out/<CODE>/ 下存放样品图的目录名（Kodi/Jellyfin 约定）。
+const ExtrafanartDir = "extrafanart"
+
+// IsExtrafanartName 报告 name 是否为 extrafanart 目录中的样品图文件名（fanart1.jpg、fanart12.jpg…）。
+func IsExtrafanartName(name string) bool {
+	n := strings.TrimSuffix(strings.TrimPrefix(name, "fanart"), ".jpg")
+	if n == "" || len(n)+len("fanart.jpg") != len(name) {
+		return false
+	}
+	for _, r := range n {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
 }
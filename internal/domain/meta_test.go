@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestIsExtrafanartName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"fanart1.jpg":  true,
+		"fanart12.jpg": true,
+		"fanart.jpg":   false,
+		"fanartx.jpg":  false,
+		"fanart1.png":  false,
+		"xfanart1.jpg": false,
+	} {
+		if got := IsExtrafanartName(name); got != want {
+			t.Fatalf("%s：期望 %v，实际 %v", name, want, got)
+		}
+	}
+}
+
+func TestMovieMeta_DecodesLegacyActorStrings(t *testing.T) {
+	var m MovieMeta
+	legacy := `{"Title":"T","Actors":["A",""]}`
+	if err := json.Unmarshal([]byte(legacy), &m); err != nil {
+		t.Fatalf("旧缓存应能解码：%v", err)
+	}
+	if want := []Actor{{Name: "A"}, {Name: ""}}; !reflect.DeepEqual(m.Actors, want) {
+		t.Fatalf("期望 %+v，实际 %+v", want, m.Actors)
+	}
+	if m.Director != "" || m.Rating != 0 || m.TrailerURL != "" {
+		t.Fatalf("旧缓存缺失的新字段应为零值：%+v", m)
+	}
+
+	b, err := json.Marshal(MovieMeta{Actors: []Actor{{Name: "B", Thumb: "https://x/b.jpg", URL: "https://x/star/b"}}})
+	if err != nil {
+		t.Fatalf("编码失败：%v", err)
+	}
+	var back MovieMeta
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("新格式应能解码：%v", err)
+	}
+	if back.Actors[0].Thumb != "https://x/b.jpg" || back.Actors[0].URL != "https://x/star/b" {
+		t.Fatalf("往返不一致：%+v", back.Actors)
+	}
+	if got := ActorNames([]Actor{{Name: " A "}, {Name: ""}}); !reflect.DeepEqual(got, []string{"A"}) {
+		t.Fatalf("ActorNames 不一致：%v", got)
+	}
+}
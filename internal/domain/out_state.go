@@ -8,6 +8,10 @@ type OutState struct {
 	HasPoster bool
 	HasFanart bool
 
+	HasThumb       bool
+	HasLandscape   bool
+	HasExtrafanart bool // extrafanart/ 目录存在即视为满足
+
 	// ExistingNames 是目录内现有文件名集合，用于 O(1) 冲突判定。
 	ExistingNames map[string]struct{}
 }
@@ -0,0 +1,107 @@
+package domain
+
+// 报告中 overrides 字段的取值（ItemResult.Overrides）。meta 字段覆盖记为 "meta.<字段名>"。
+const (
+	OverrideFileCode = "file_code" // 某个文件的 CODE 由 overrides.files 强制指定
+	OverrideIgnore   = "ignore"    // CODE 被标记为忽略
+	OverrideProvider = "provider"  // 固定 provider（不降级）
+	OverrideURL      = "url"       // 固定详情页 URL
+)
+
+// Overrides 是用户维护的覆盖规则（<path>/avmc.overrides.json，已规范化）。
+type Overrides struct {
+	// Codes 按规范化 CODE 索引。
+	Codes map[Code]CodeOverride
+	// Files 把相对 <path> 的文件路径（'/' 分隔）强制映射到 CODE，优先于文件名识别。
+	Files map[string]Code
+}
+
+// CodeOverride 是单个 CODE 的覆盖规则。
+type CodeOverride struct {
+	// Provider 非空时只使用该 provider（不降级）；URL 非空时直接抓取该详情页（必须同时指定 Provider）。
+	Provider string `json:"provider,omitempty"`
+	URL      string `json:"url,omitempty"`
+
+	// Ignore 为 true 时该 CODE 不做任何处理（不刮削、不写 sidecar、不移动）。
+	Ignore bool `json:"ignore,omitempty"`
+
+	// Meta 覆盖刮削结果中的个别字段（只影响新写入的 NFO；已存在的 NFO 不会被覆盖）。
+	Meta *MetaOverride `json:"meta,omitempty"`
+}
+
+// MetaOverride 按字段覆盖 MovieMeta：nil 表示不覆盖；切片字段给出 [] 表示清空。
+type MetaOverride struct {
+	Title      *string  `json:"title,omitempty"`
+	Plot       *string  `json:"plot,omitempty"`
+	Studio     *string  `json:"studio,omitempty"`
+	Maker      *string  `json:"maker,omitempty"`
+	Label      *string  `json:"label,omitempty"`
+	Director   *string  `json:"director,omitempty"`
+	Series     *string  `json:"series,omitempty"`
+	Release    *string  `json:"release,omitempty"`
+	Year       *int     `json:"year,omitempty"`
+	RuntimeM   *int     `json:"runtime,omitempty"`
+	Rating     *float64 `json:"rating,omitempty"`
+	Votes      *int     `json:"votes,omitempty"`
+	Actors     []Actor  `json:"actors"`
+	Genres     []string `json:"genres"`
+	Tags       []string `json:"tags"`
+	CoverURL   *string  `json:"cover_url,omitempty"`
+	FanartURL  *string  `json:"fanart_url,omitempty"`
+	TrailerURL *string  `json:"trailer_url,omitempty"`
+}
+
+// ForCode 返回 c 的覆盖规则。
+func (o Overrides) ForCode(c Code) (CodeOverride, bool) {
+	ov, ok := o.Codes[c]
+	return ov, ok
+}
+
+// Apply 把覆盖写入 m，返回结果与被覆盖的字段（形如 "meta.title"，顺序固定）。
+func (o MetaOverride) Apply(m MovieMeta) (MovieMeta, []string) {
+	var applied []string
+	setStr := func(dst *string, v *string, name string) {
+		if v != nil {
+			*dst = *v
+			applied = append(applied, "meta."+name)
+		}
+	}
+	setInt := func(dst *int, v *int, name string) {
+		if v != nil {
+			*dst = *v
+			applied = append(applied, "meta."+name)
+		}
+	}
+	setList := func(dst *[]string, v []string, name string) {
+		if v != nil {
+			*dst = append([]string{}, v...)
+			applied = append(applied, "meta."+name)
+		}
+	}
+
+	setStr(&m.Title, o.Title, "title")
+	setStr(&m.Plot, o.Plot, "plot")
+	setStr(&m.Studio, o.Studio, "studio")
+	setStr(&m.Maker, o.Maker, "maker")
+	setStr(&m.Label, o.Label, "label")
+	setStr(&m.Director, o.Director, "director")
+	setStr(&m.Series, o.Series, "series")
+	setStr(&m.Release, o.Release, "release")
+	setInt(&m.Year, o.Year, "year")
+	setInt(&m.RuntimeM, o.RuntimeM, "runtime")
+	if o.Rating != nil {
+		m.Rating = *o.Rating
+		applied = append(applied, "meta.rating")
+	}
+	setInt(&m.Votes, o.Votes, "votes")
+	if o.Actors != nil {
+		m.Actors = append([]Actor{}, o.Actors...)
+		applied = append(applied, "meta.actors")
+	}
+	setList(&m.Genres, o.Genres, "genres")
+	setList(&m.Tags, o.Tags, "tags")
+	setStr(&m.CoverURL, o.CoverURL, "cover_url")
+	setStr(&m.FanartURL, o.FanartURL, "fanart_url")
+	setStr(&m.TrailerURL, o.TrailerURL, "trailer_url")
+	return m, applied
+}
@@ -1,23 +1,93 @@
 package domain
 
+import "time"
+
 // MovePlan 规划一次文件移动（只描述 src/dst；真正执行必须遵守“移动最后一步”）。
 type MovePlan struct {
-	SrcAbs string
-	DstAbs string
+	SrcAbs string `json:"src"`
+	DstAbs string `json:"dst"`
+
+	// Markers 是源文件名解析出的 marker（伴随文件沿用所属视频的 marker）。
+	Markers Markers `json:"markers"`
+
+	// Hash 是规划时视频内容的快速指纹（scan.hash=true 时非空；伴随文件为空）：
+	// 移动后与回滚前校验目标文件仍是同一内容，apply plan 时校验源文件未被替换。
+	Hash string `json:"hash,omitempty"`
+
+	// Part 是文件名中的分段序号（cd1/part2/disc3 等；0 表示不是分段）；伴随文件沿用所属视频。
+	Part int `json:"part,omitempty"`
+	// Duplicate 见 Duplicate* 常量；为空表示该分段只有这一个版本（或 dupes.mode=keep_all）。
+	Duplicate string `json:"duplicate,omitempty"`
+
+	// SrcSize / SrcModNano 是规划时源文件的指纹（plan 文件工作流）；apply 时不一致则拒绝执行该 item。
+	SrcSize    int64 `json:"src_size"`
+	SrcModNano int64 `json:"src_mtime_ns"`
 }
 
 type SidecarNeed struct {
-	NeedScrape bool
-	NeedNFO    bool
-	NeedPoster bool
-	NeedFanart bool
+	NeedScrape bool `json:"scrape"`
+	NeedNFO    bool `json:"nfo"`
+	NeedPoster bool `json:"poster"`
+	NeedFanart bool `json:"fanart"`
+
+	// 可选 artwork（由配置开启）：thumb/landscape 由 fanart 派生；extrafanart 需要刮削样品图 URL。
+	NeedThumb       bool `json:"thumb"`
+	NeedLandscape   bool `json:"landscape"`
+	NeedExtrafanart bool `json:"extrafanart"`
+}
+
+// Any 表示是否需要写入任一 sidecar。
+func (n SidecarNeed) Any() bool {
+	return n.NeedNFO || n.NeedPoster || n.NeedFanart || n.NeedThumb || n.NeedLandscape || n.NeedExtrafanart
 }
 
 // ItemPlan 是对某个 CODE 的最小执行计划。
 type ItemPlan struct {
-	Code              Code
-	ProviderRequested string
+	Code              Code   `json:"code"`
+	ProviderRequested string `json:"provider_requested"`
+
+	// OutDir 是目标目录（通常为 <path>/out/<CODE>；开启 folder_suffix 时可能带 marker 后缀）。
+	// 为空时执行层回退到 <path>/out/<CODE>。
+	OutDir string `json:"out_dir"`
+
+	Moves []MovePlan  `json:"moves"`
+	Need  SidecarNeed `json:"need"`
+
+	// Markers 是 item 内所有文件 marker 的并集（写入 NFO 的 genre/tag）。
+	Markers Markers `json:"markers"`
+
+	// ExtrafanartMax 是 extrafanart/ 下最多下载的样品图张数（仅 NeedExtrafanart 时有意义）。
+	ExtrafanartMax int `json:"extrafanart_max"`
+
+	// Override 是该 CODE 的覆盖规则（avmc.overrides.json）；Overrides 是规划阶段已生效的覆盖项（写入报告）。
+	Override  *CodeOverride `json:"override,omitempty"`
+	Overrides []string      `json:"overrides,omitempty"`
+
+	// Resolved 是规划阶段已解析的元数据（plan 文件工作流）；非空时执行层直接使用，不再刮削。
+	Resolved *ResolvedMeta `json:"resolved,omitempty"`
+}
+
+// ResolvedMeta 是一次成功刮削的结果快照。Meta 的 JSON 形态与 provider JSON 缓存一致（字段名）。
+type ResolvedMeta struct {
+	ProviderUsed string            `json:"provider_used"`
+	Website      string            `json:"website"`
+	Attempts     []ProviderAttempt `json:"attempts"`
+	Meta         MovieMeta         `json:"meta"`
+
+	// Overrides 是刮削后生效的字段覆盖（形如 "meta.title"）。
+	Overrides []string `json:"overrides,omitempty"`
+
+	// ActorRenames 是刮削后按别名规则改写的演员名。
+	ActorRenames []ActorRename `json:"actor_renames,omitempty"`
+}
+
+// PlanFileVersion 是 plan 文件格式版本；不兼容变更时递增，apply 拒绝未知版本。
+const PlanFileVersion = 1
 
-	Moves []MovePlan
-	Need  SidecarNeed
+// PlanFile 是 `avmc plan --out` 的输出、`avmc apply <plan.json>` 的输入。
+type PlanFile struct {
+	Version   int        `json:"version"`
+	Path      string     `json:"path"`
+	CreatedAt time.Time  `json:"created_at"`
+	Items     []ItemPlan `json:"items"`
 }
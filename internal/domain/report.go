@@ -18,6 +18,7 @@ const (
 	FileStatusMoved      = "moved"
 	FileStatusRolledBack = "rolled_back"
 	FileStatusFailed     = "failed"
+	FileStatusIgnored    = "ignored" // CODE 被 overrides 标记为 ignore：原地不动
 )
 
 const (
@@ -30,6 +31,9 @@ const (
 	ErrCodeConfigNotFound    = "config_not_found"
 	ErrCodeConfigInvalid     = "config_invalid"
 	ErrCodeConfigMissingPath = "config_missing_path"
+	ErrCodeTranslateFailed   = "translate_failed" // 启用了标题翻译但后端出错（不写 NFO、不移动）
+	ErrCodeSourceChanged     = "source_changed"   // 源文件在规划后被修改/移走（plan 文件工作流；scan.hash 时移动前的内容校验）
+	ErrCodePlanInvalid       = "plan_invalid"     // plan 文件工作流：计划内容越界或与当前配置不符
 )
 
 // RunReport 是对外稳定输出（report.json / stdout JSON）的结构。
@@ -42,6 +46,9 @@ type RunReport struct {
 
 	Summary ReportSummary `json:"summary"`
 	Items   []ItemResult  `json:"items"`
+
+	// Skipped 是扫描阶段按规则跳过的文件/目录（不参与分组，也不计入 summary）。
+	Skipped []SkippedFile `json:"skipped"`
 }
 
 type ReportSummary struct {
@@ -49,6 +56,8 @@ type ReportSummary struct {
 	Skipped   int `json:"skipped"`
 	Failed    int `json:"failed"`
 	Unmatched int `json:"unmatched"`
+	// FetchesSaved 是本次运行内被合并（并发相同请求）或命中内存缓存、因而没有真正发出的 HTTP 请求数。
+	FetchesSaved int `json:"fetches_saved"`
 }
 
 type ItemResult struct {
@@ -72,6 +81,18 @@ type ItemResult struct {
 
 	Candidates []string     `json:"candidates"`
 	Files      []FileResult `json:"files"`
+
+	// Overrides 列出对该 item 生效的覆盖项（avmc.overrides.json）；未使用覆盖时省略。
+	Overrides []string `json:"overrides,omitempty"`
+
+	// ActorRenames 列出按别名表/括号剥离改写的演员名；没有改写时省略。
+	ActorRenames []ActorRename `json:"actor_renames,omitempty"`
+}
+
+// ActorRename 记录一次演员名改写。
+type ActorRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
 
 // ProviderAttempt 表达一次 provider 尝试的结果。
@@ -85,19 +106,45 @@ type ProviderAttempt struct {
 	ErrorMsg  string `json:"error_msg"`
 }
 
+// SkippedFile.Reason 枚举（对外稳定；新增只追加不改名）。
+const (
+	SkipReasonTooSmall        = "too_small"        // 小于 scan.min_size_mb
+	SkipReasonExcludedPattern = "excluded_pattern" // 文件名命中 scan.exclude_patterns
+	SkipReasonIgnoreMarker    = "avmcignore"       // 目录内有 .avmcignore（整目录跳过）
+	SkipReasonSameContent     = "same_content"     // 与另一路径的视频内容相同（scan.hash）；detail 为该路径
+)
+
+// SkippedFile 描述扫描阶段被跳过的一个文件或目录。
+type SkippedFile struct {
+	Path   string `json:"path"` // 相对 path
+	Reason string `json:"reason"`
+	Detail string `json:"detail"` // 例如命中的 pattern、实际大小
+}
+
 type FileResult struct {
 	Src    string `json:"src"`
 	Dst    string `json:"dst"`
 	Status string `json:"status"`
+	// Hash 是视频内容的快速指纹（scan.hash=true 时输出；跨路径识别同一文件）。
+	Hash string `json:"hash,omitempty"`
+	// Part / Duplicate 与 MovePlan 同义：分段序号与多版本标记（见 Duplicate* 常量）。
+	Part      int    `json:"part,omitempty"`
+	Duplicate string `json:"duplicate,omitempty"`
+	// Media 是视频容器头部的解析结果（仅 MP4/Matroska；字幕等伴随文件或解析失败时省略）。
+	Media *MediaInfo `json:"media,omitempty"`
 }
 
 // Finalize 做三件事：
 // 1) 时间统一为 UTC（确保 JSON 为 RFC3339 且后缀 Z）
 // 2) items 稳定排序：按 code 字典序；code=="" 的条目排在最后
 // 3) summary 由 items 计算得出
+// 另外 skipped 为 nil 时规范化为空数组（JSON 输出 [] 而不是 null）。
 func (r *RunReport) Finalize() {
 	r.StartedAt = r.StartedAt.UTC()
 	r.FinishedAt = r.FinishedAt.UTC()
+	if r.Skipped == nil {
+		r.Skipped = []SkippedFile{}
+	}
 
 	sort.SliceStable(r.Items, func(i, j int) bool {
 		a := r.Items[i].Code
@@ -114,7 +161,8 @@ func (r *RunReport) Finalize() {
 		return a < b
 	})
 
-	var s ReportSummary
+	// 计数由执行层写入，Finalize 只重算条目统计。
+	s := ReportSummary{FetchesSaved: r.Summary.FetchesSaved}
 	for _, it := range r.Items {
 		switch it.Status {
 		case StatusProcessed:
@@ -7,4 +7,3 @@ type Unmatched struct {
 	Kind       string // "no_match" | "ambiguous"
 	Candidates []Code // 仅 ambiguous 时非空（已排序）
 }
-
@@ -4,7 +4,7 @@ package domain
 //
 // 不变量（实现必须遵守）：
 // - AbsPath 必须是 clean + absolute
-// - 扫描阶段只做 stat，不读文件内容
+// - 扫描阶段只做 stat，不读文件内容（Hash 由 run 在扫描之后按需填充）
 type VideoFile struct {
 	AbsPath string
 	RelPath string
@@ -12,4 +12,11 @@ type VideoFile struct {
 	Ext     string // ".mp4"
 	Size    int64
 	ModUnix int64
+
+	// Hash 是内容快速指纹（fsx.QuickHash；仅 scan.hash=true 时计算，否则为空）。
+	Hash string
+
+	// Companions 是同目录、文件名以 "<Base>." 开头的伴随文件（字幕等）的绝对路径，
+	// 例如 ABC-123.srt / ABC-123.chs.ass。它们随视频一起移动并同步改名。
+	Companions []string
 }
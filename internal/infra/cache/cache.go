@@ -55,6 +55,46 @@ func (s Store) ProviderJSONPath(provider string, code domain.Code) (string, erro
 	return filepath.Join(s.Root, "cache", "providers", p, string(code)+".json"), nil
 }
 
+// DownloadDir 返回图片下载临时文件目录（cache/downloads/）：只在本次运行内使用，运行结束即清空。
+// 放在 <path> 之下而不是系统临时目录（常见为 tmpfs），避免大图库占满内存。
+func (s Store) DownloadDir() string {
+	return filepath.Join(s.Root, "cache", "downloads")
+}
+
+// TranslationPath 返回标题翻译缓存的绝对路径（cache/translations/<CODE>.json）。
+func (s Store) TranslationPath(code domain.Code) (string, error) {
+	if code == "" {
+		return "", fmt.Errorf("code 不能为空")
+	}
+	return filepath.Join(s.Root, "cache", "translations", string(code)+".json"), nil
+}
+
+func (s Store) ReadTranslation(code domain.Code) ([]byte, bool, error) {
+	path, err := s.TranslationPath(code)
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (s Store) WriteTranslation(code domain.Code, b []byte) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	path, err := s.TranslationPath(code)
+	if err != nil {
+		return err
+	}
+	return fsx.WriteFileAtomicReplace(filepath.Dir(path), filepath.Base(path), b)
+}
+
 func (s Store) ReadProviderHTML(provider string, code domain.Code) ([]byte, bool, error) {
 	path, err := s.ProviderHTMLPath(provider, code)
 	if err != nil {
@@ -0,0 +1,64 @@
+package fsx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// quickHashChunk 是 QuickHash 读取的首/尾块大小。
+const quickHashChunk = 64 << 10
+
+// QuickHash 计算文件的快速内容指纹（与 OpenSubtitles 的 hash 算法一致）：
+// 文件大小 + 首、尾各 64KiB 按小端 uint64 累加（溢出回绕），输出 16 位小写十六进制。
+//
+// 只读取最多 128KiB，适合大视频的身份识别；不是密码学哈希，不能用来防篡改。
+func QuickHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	return QuickHashReaderAt(f, fi.Size())
+}
+
+// QuickHashReaderAt 与 QuickHash 相同，但从 r 读取（size 为内容总长度）。
+// 小于 64KiB 的内容首尾块重叠，仍按两次累加计算（与原算法对小文件的处理一致）。
+func QuickHashReaderAt(r io.ReaderAt, size int64) (string, error) {
+	n := int64(quickHashChunk)
+	if size < n {
+		n = size
+	}
+	sum := uint64(size)
+	buf := make([]byte, n)
+	for _, off := range []int64{0, size - n} {
+		if m, err := r.ReadAt(buf, off); int64(m) < n {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		sum += sumWords(buf)
+	}
+	return fmt.Sprintf("%016x", sum), nil
+}
+
+// sumWords 把 b 视为小端 uint64 序列求和；末尾不足 8 字节的部分补 0。
+func sumWords(b []byte) uint64 {
+	var sum uint64
+	for len(b) >= 8 {
+		sum += binary.LittleEndian.Uint64(b)
+		b = b[8:]
+	}
+	if len(b) > 0 {
+		var tail [8]byte
+		copy(tail[:], b)
+		sum += binary.LittleEndian.Uint64(tail[:])
+	}
+	return sum
+}
@@ -0,0 +1,69 @@
+package fsx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuickHash(t *testing.T) {
+	dir := t.TempDir()
+	// 200KiB：首尾块不重叠；中间内容不参与计算。
+	b := make([]byte, 200<<10)
+	for i := range b {
+		b[i] = byte(i * 7)
+	}
+	p := filepath.Join(dir, "a.mp4")
+	if err := os.WriteFile(p, b, 0o644); err != nil {
+		t.Fatalf("写入失败：%v", err)
+	}
+	h, err := QuickHash(p)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(h) != 16 {
+		t.Fatalf("期望 16 位十六进制，实际 %q", h)
+	}
+
+	// 期望值按算法定义独立计算：size + 首块字和 + 尾块字和。
+	want := uint64(len(b)) + sumWords(b[:quickHashChunk]) + sumWords(b[len(b)-quickHashChunk:])
+	if got, _ := QuickHashReaderAt(bytes.NewReader(b), int64(len(b))); got != h || h != fmtHash(want) {
+		t.Fatalf("hash 不一致：file=%s reader=%s want=%s", h, got, fmtHash(want))
+	}
+
+	mid := append([]byte(nil), b...)
+	mid[100<<10] ^= 0xFF
+	if got, _ := QuickHashReaderAt(bytes.NewReader(mid), int64(len(mid))); got != h {
+		t.Fatalf("中间字节变化不应影响快速 hash")
+	}
+	head := append([]byte(nil), b...)
+	head[0] ^= 0xFF
+	if got, _ := QuickHashReaderAt(bytes.NewReader(head), int64(len(head))); got == h {
+		t.Fatalf("首块变化应改变 hash")
+	}
+	if got, _ := QuickHashReaderAt(bytes.NewReader(b[:len(b)-1]), int64(len(b)-1)); got == h {
+		t.Fatalf("大小变化应改变 hash")
+	}
+
+	// 小文件与空文件也能计算。
+	if _, err := QuickHashReaderAt(bytes.NewReader([]byte("abc")), 3); err != nil {
+		t.Fatalf("小文件不应报错：%v", err)
+	}
+	if h, err := QuickHashReaderAt(bytes.NewReader(nil), 0); err != nil || h != "0000000000000000" {
+		t.Fatalf("空文件 hash 不符合预期：%q err=%v", h, err)
+	}
+	if _, err := QuickHash(filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("文件不存在应报错")
+	}
+}
+
+func fmtHash(v uint64) string {
+	const hexdigits = "0123456789abcdef"
+	out := make([]byte, 16)
+	for i := 15; i >= 0; i-- {
+		out[i] = hexdigits[v&0xF]
+		v >>= 4
+	}
+	return string(out)
+}
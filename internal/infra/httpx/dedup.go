@@ -0,0 +1,210 @@
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDedupTTL 是运行内响应缓存的有效期：只为合并同一次运行内的重复请求，不替代磁盘缓存。
+	defaultDedupTTL = 10 * time.Minute
+	// dedupMaxBody 是可共享/缓存的单个响应体上限；更大的响应照常透传给发起方。
+	dedupMaxBody = 16 << 20
+	// dedupMaxTotal 是内存缓存的总字节上限；超出后新响应不再缓存（仍可被并发请求共享）。
+	dedupMaxTotal = 128 << 20
+)
+
+// Dedup 合并同一次运行内的相同 GET 请求（键为 URL + 影响响应的请求头）：
+//   - 并发的相同请求只真正发出一次，其余等待并共享同一份响应（singleflight）；
+//   - 2xx 响应在 TTL 内缓存在内存中，之后的相同请求直接复用。
+//
+// 网络错误不共享也不缓存：等待者各自重新请求，避免一个被取消的 ctx 拖累其他条目。
+// 非 2xx 响应只共享给同时在等的请求，不进入缓存。
+//
+// 响应体会先完整读入内存，只适合体积小的元数据请求；图片等流式下载用 Downloads。
+type Dedup struct {
+	Next http.RoundTripper
+	TTL  time.Duration
+
+	mu      sync.Mutex
+	flights map[string]*flight
+	cache   map[string]*snapshot
+	total   int64
+	saved   int
+}
+
+// NewDedup 用默认 TTL 包装 next。
+func NewDedup(next http.RoundTripper) *Dedup {
+	return &Dedup{Next: next, TTL: defaultDedupTTL}
+}
+
+// Saved 返回被合并或命中缓存、因此没有真正发出的请求数。
+func (d *Dedup) Saved() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.saved
+}
+
+type flight struct {
+	done chan struct{}
+	snap *snapshot // nil 表示结果不可共享（出错或响应体过大）
+}
+
+// snapshot 是已完整读入内存的响应。
+type snapshot struct {
+	status     string
+	statusCode int
+	header     http.Header
+	body       []byte
+	at         time.Time
+}
+
+func (s *snapshot) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        s.status,
+		StatusCode:    s.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        s.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(s.body)),
+		ContentLength: int64(len(s.body)),
+		Request:       req,
+	}
+}
+
+func (d *Dedup) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := dedupKey(req)
+	if !ok {
+		return d.Next.RoundTrip(req)
+	}
+
+	d.mu.Lock()
+	if s := d.cache[key]; s != nil {
+		if time.Since(s.at) < d.ttl() {
+			d.saved++
+			d.mu.Unlock()
+			return s.response(req), nil
+		}
+		d.evictLocked(key)
+	}
+	if f := d.flights[key]; f != nil {
+		d.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if f.snap == nil {
+			return d.Next.RoundTrip(req)
+		}
+		d.mu.Lock()
+		d.saved++
+		d.mu.Unlock()
+		return f.snap.response(req), nil
+	}
+	if d.flights == nil {
+		d.flights = map[string]*flight{}
+	}
+	f := &flight{done: make(chan struct{})}
+	d.flights[key] = f
+	d.mu.Unlock()
+
+	resp, err := d.Next.RoundTrip(req)
+	if err == nil {
+		resp, f.snap = capture(resp)
+	}
+
+	d.mu.Lock()
+	delete(d.flights, key)
+	if s := f.snap; s != nil && s.statusCode >= 200 && s.statusCode < 300 {
+		d.storeLocked(key, s)
+	}
+	d.mu.Unlock()
+	close(f.done)
+	return resp, err
+}
+
+func (d *Dedup) ttl() time.Duration {
+	if d.TTL <= 0 {
+		return defaultDedupTTL
+	}
+	return d.TTL
+}
+
+func (d *Dedup) storeLocked(key string, s *snapshot) {
+	if d.cache == nil {
+		d.cache = map[string]*snapshot{}
+	}
+	if d.total+int64(len(s.body)) > dedupMaxTotal {
+		// 先清掉过期项再试一次；仍放不下就不缓存。
+		for k, v := range d.cache {
+			if time.Since(v.at) >= d.ttl() {
+				d.evictLocked(k)
+			}
+		}
+		if d.total+int64(len(s.body)) > dedupMaxTotal {
+			return
+		}
+	}
+	d.cache[key] = s
+	d.total += int64(len(s.body))
+}
+
+func (d *Dedup) evictLocked(key string) {
+	if s := d.cache[key]; s != nil {
+		d.total -= int64(len(s.body))
+		delete(d.cache, key)
+	}
+}
+
+// capture 把响应体读入内存以便共享；超过 dedupMaxBody 时把已读部分接回 body 原样返回，不共享。
+// 读取中途出错时同样不共享：调用方先读到已收到的部分再拿到该错误（便于按 Range 续传）。
+func capture(resp *http.Response) (*http.Response, *snapshot) {
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, dedupMaxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(buf), errReader{err}))
+		return resp, nil
+	}
+	if len(buf) > dedupMaxBody {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	s := &snapshot{
+		status:     resp.Status,
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       buf,
+		at:         time.Now(),
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(buf))
+	resp.ContentLength = int64(len(buf))
+	return resp, s
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// dedupKey 只为可重放的 GET 生成键；Referer/Cookie 等会改变站点响应的请求头也计入键。
+func dedupKey(req *http.Request) (string, bool) {
+	if req.Method != http.MethodGet || (req.Body != nil && req.Body != http.NoBody) || req.URL == nil {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteString(req.URL.String())
+	for _, h := range []string{"Referer", "Cookie", "Range", "Authorization"} {
+		b.WriteByte('\n')
+		b.WriteString(req.Header.Get(h))
+	}
+	return b.String(), true
+}
@@ -0,0 +1,225 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sniffLen 是交给 DownloadOptions.Sniff 的开头字节数（与 http.DetectContentType 一致）。
+const sniffLen = 512
+
+// DownloadOptions 控制 Download 的流式下载行为。
+type DownloadOptions struct {
+	// Header 是附加的请求头（例如站点要求的 Referer/Cookie）。
+	Header http.Header
+	// MaxBytes 是响应体大小上限；0 表示不限。
+	MaxBytes int64
+	// Sniff 校验响应体开头（最多 512 字节）；返回错误即中止下载。nil 表示不校验。
+	Sniff func(head []byte) error
+	// Dir 是临时文件目录；空串表示系统临时目录。
+	Dir string
+}
+
+// TooLargeError 表示响应体超过 DownloadOptions.MaxBytes。
+type TooLargeError struct {
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("响应超过大小上限（%d 字节）", e.Limit)
+}
+
+// StatusError 表示非 2xx 响应。
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string { return fmt.Sprintf("HTTP %d", e.StatusCode) }
+
+// Download 把 u 的响应体流式写入临时文件，返回文件路径（调用方负责删除；出错时已删除）。
+//
+// 规则：
+//   - 边读边写，累计超过 MaxBytes 立即中止；Content-Length 已超限时不读 body；
+//   - 开头字节先经 Sniff 校验（例如拒绝 200 状态码的 HTML 错误页），不合格不会落成完整文件；
+//   - 读取中途断开时按 Range 从已写入的位置续传，次数沿用 client 的重试上限（Transport.RetryMax）；
+//     服务端不支持 Range（返回 200）时截断临时文件从头重下。
+func Download(ctx context.Context, c *http.Client, u string, opt DownloadOptions) (string, error) {
+	if c == nil {
+		return "", errors.New("http client 为空")
+	}
+	f, err := os.CreateTemp(opt.Dir, ".avmc-dl-*")
+	if err != nil {
+		return "", err
+	}
+	d := &downloader{c: c, u: u, opt: opt, f: f}
+	err = d.run(ctx, retryMax(c.Transport))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// retryMax 返回 rt 的重试上限；非本包 Transport 时使用默认值。
+func retryMax(rt http.RoundTripper) int {
+	if t, ok := rt.(*Transport); ok {
+		if t.RetryMax < 0 {
+			return 0
+		}
+		return t.RetryMax
+	}
+	return defaultRetryMax
+}
+
+type downloader struct {
+	c   *http.Client
+	u   string
+	opt DownloadOptions
+	f   *os.File
+
+	written int64
+	head    []byte
+	sniffed bool
+}
+
+// resumableError 表示响应体读取中途失败：已写入的部分有效，可以续传。
+type resumableError struct{ err error }
+
+func (e *resumableError) Error() string { return e.err.Error() }
+func (e *resumableError) Unwrap() error { return e.err }
+
+func (d *downloader) run(ctx context.Context, retries int) error {
+	for attempt := 0; ; attempt++ {
+		err := d.fetch(ctx)
+		if err == nil {
+			return nil
+		}
+		var re *resumableError
+		if !errors.As(err, &re) || attempt >= retries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func (d *downloader) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.u, nil)
+	if err != nil {
+		return err
+	}
+	for k, vs := range d.opt.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if d.written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.written))
+	}
+
+	resp, err := d.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case d.written > 0 && resp.StatusCode == http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != d.written {
+			return fmt.Errorf("续传响应的 Content-Range 不匹配：%q", resp.Header.Get("Content-Range"))
+		}
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// 首次请求，或服务端忽略了 Range：从头写。
+		if d.written > 0 {
+			if err := d.reset(); err != nil {
+				return err
+			}
+		}
+	default:
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	if max := d.opt.MaxBytes; max > 0 && resp.ContentLength > 0 && d.written+resp.ContentLength > max {
+		return &TooLargeError{Limit: max}
+	}
+	return d.copy(resp.Body)
+}
+
+func (d *downloader) reset() error {
+	if err := d.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.written, d.head, d.sniffed = 0, nil, false
+	return nil
+}
+
+func (d *downloader) copy(body io.Reader) error {
+	buf := make([]byte, 32<<10)
+	for {
+		n, rerr := body.Read(buf)
+		if n > 0 {
+			if max := d.opt.MaxBytes; max > 0 && d.written+int64(n) > max {
+				return &TooLargeError{Limit: max}
+			}
+			if err := d.sniff(buf[:n], false); err != nil {
+				return err
+			}
+			if _, err := d.f.Write(buf[:n]); err != nil {
+				return err
+			}
+			d.written += int64(n)
+		}
+		if rerr == io.EOF {
+			return d.sniff(nil, true)
+		}
+		if rerr != nil {
+			return &resumableError{err: rerr}
+		}
+	}
+}
+
+// sniff 攒够 512 字节（或读到结尾）后调用一次 opt.Sniff。
+func (d *downloader) sniff(b []byte, eof bool) error {
+	if d.sniffed || d.opt.Sniff == nil {
+		return nil
+	}
+	if need := sniffLen - len(d.head); need > 0 {
+		if len(b) > need {
+			b = b[:need]
+		}
+		d.head = append(d.head, b...)
+	}
+	if len(d.head) < sniffLen && !eof {
+		return nil
+	}
+	d.sniffed = true
+	return d.opt.Sniff(d.head)
+}
+
+// contentRangeStart 解析 "bytes <start>-<end>/<total>" 的 start。
+func contentRangeStart(v string) (int64, bool) {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "bytes ") {
+		return 0, false
+	}
+	v = strings.TrimPrefix(v, "bytes ")
+	i := strings.IndexByte(v, '-')
+	if i <= 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
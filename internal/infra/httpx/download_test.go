@@ -0,0 +1,291 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/John-Robertt/AVMC/internal/infra/imgx"
+)
+
+func jpegSniff(head []byte) error {
+	if !bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}) {
+		return errors.New("不是 JPEG")
+	}
+	return nil
+}
+
+func TestDownload(t *testing.T) {
+	img := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte("j"), 100<<10)...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/img.jpg":
+			if r.Header.Get("Referer") != "https://site.test/" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write(img)
+		case "/error.jpg":
+			_, _ = w.Write([]byte("<!DOCTYPE html><html>blocked</html>"))
+		case "/chunked.jpg":
+			// 不带 Content-Length：只能边读边数。
+			w.Header().Set("Content-Type", "image/jpeg")
+			for i := 0; i < 4; i++ {
+				_, _ = w.Write(img)
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewImageClient("", false)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	opt := DownloadOptions{Header: http.Header{"Referer": {"https://site.test/"}}, MaxBytes: 200 << 10, Sniff: jpegSniff, Dir: t.TempDir()}
+
+	path, err := Download(context.Background(), c, srv.URL+"/img.jpg", opt)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(got, img) {
+		t.Fatalf("下载内容不一致：len=%d err=%v", len(got), err)
+	}
+
+	if _, err := Download(context.Background(), c, srv.URL+"/error.jpg", opt); err == nil || err.Error() != "不是 JPEG" {
+		t.Fatalf("HTML 错误页应被 Sniff 拒绝：%v", err)
+	}
+	var tl *TooLargeError
+	if _, err := Download(context.Background(), c, srv.URL+"/chunked.jpg", opt); !errors.As(err, &tl) {
+		t.Fatalf("超过上限应报 TooLargeError：%v", err)
+	}
+	var se *StatusError
+	if _, err := Download(context.Background(), c, srv.URL+"/img.jpg", DownloadOptions{Dir: opt.Dir}); !errors.As(err, &se) || se.StatusCode != http.StatusForbidden {
+		t.Fatalf("非 2xx 应报 StatusError：%v", err)
+	}
+
+	// 失败时不残留临时文件。
+	entries, _ := os.ReadDir(opt.Dir)
+	if len(entries) != 1 {
+		t.Fatalf("期望只剩成功下载的文件：%v", entries)
+	}
+}
+
+func TestDownload_Resume(t *testing.T) {
+	img := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte("r"), 64<<10)...)
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		first := len(ranges) == 1
+		mu.Unlock()
+		if first {
+			// 首次只发一半就断开连接。
+			w.Header().Set("Content-Length", strconv.Itoa(len(img)))
+			_, _ = w.Write(img[:len(img)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "img.jpg", time.Time{}, bytes.NewReader(img))
+	}))
+	defer srv.Close()
+
+	c, err := NewImageClient("", false)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	path, err := Download(context.Background(), c, srv.URL+"/img.jpg", DownloadOptions{Sniff: jpegSniff, Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if !bytes.Equal(got, img) {
+		t.Fatalf("续传后的内容不一致：len=%d", len(got))
+	}
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] == "" {
+		t.Fatalf("期望第二次请求带 Range：%q", ranges)
+	}
+}
+
+func TestDownloads(t *testing.T) {
+	img := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte("d"), 1<<10)...)
+	var mu sync.Mutex
+	hits := map[string]int{}
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/slow.jpg":
+			<-release
+			_, _ = w.Write(img)
+		case "/bad.jpg":
+			_, _ = w.Write([]byte("<html>"))
+		default:
+			_, _ = w.Write(img)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewImageClient("", false)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	d := NewDownloads(c)
+	d.Dir = filepath.Join(t.TempDir(), "cache", "downloads") // 不存在时自动创建
+	opt := DownloadOptions{MaxBytes: 1 << 20, Sniff: jpegSniff}
+
+	// 并发的相同下载只发出一次，共享同一个临时文件。
+	var wg sync.WaitGroup
+	paths := make([]string, 3)
+	errs := make([]error, 3)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var rel func()
+			paths[i], rel, errs[i] = d.Get(context.Background(), srv.URL+"/slow.jpg", opt)
+			if errs[i] == nil {
+				rel()
+			}
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for i := range paths {
+		if errs[i] != nil || paths[i] != paths[0] {
+			t.Fatalf("期望共享同一个文件：paths=%v errs=%v", paths, errs)
+		}
+	}
+	// 之后的相同下载直接复用；不同的请求头（Referer）不共享。
+	p, rel, err := d.Get(context.Background(), srv.URL+"/slow.jpg", opt)
+	if err != nil || p != paths[0] {
+		t.Fatalf("期望复用已下载文件：%s err=%v", p, err)
+	}
+	rel()
+	ref := opt
+	ref.Header = http.Header{"Referer": {"https://site.test/"}}
+	p, rel, err = d.Get(context.Background(), srv.URL+"/slow.jpg", ref)
+	if err != nil || p == paths[0] {
+		t.Fatalf("不同 Referer 不应共享：%s err=%v", p, err)
+	}
+	rel()
+	if hits["/slow.jpg"] != 2 || d.Saved() != 3 {
+		t.Fatalf("期望真正下载 2 次、省下 3 次：hits=%d saved=%d", hits["/slow.jpg"], d.Saved())
+	}
+
+	// 失败不缓存：每次都重新请求。
+	for i := 0; i < 2; i++ {
+		if _, _, err := d.Get(context.Background(), srv.URL+"/bad.jpg", opt); err == nil {
+			t.Fatalf("期望 Sniff 拒绝")
+		}
+	}
+	if hits["/bad.jpg"] != 2 {
+		t.Fatalf("失败的下载不应被缓存：hits=%d", hits["/bad.jpg"])
+	}
+
+	// Close 清理全部临时文件与目录。
+	if err := d.Close(); err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if _, err := os.Stat(d.Dir); !os.IsNotExist(err) {
+		t.Fatalf("Close 后不应残留临时目录：%v", err)
+	}
+}
+
+func TestDownloads_MaxTotal(t *testing.T) {
+	img := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte("m"), 1<<10)...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(img)
+	}))
+	defer srv.Close()
+
+	c, err := NewImageClient("", false)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	d := NewDownloads(c)
+	d.Dir = t.TempDir()
+	d.MaxTotal = int64(len(img)) * 2 // 最多保留两份
+
+	count := func() int {
+		entries, _ := os.ReadDir(d.Dir)
+		return len(entries)
+	}
+	// 持有中的文件不受上限影响。
+	var rels []func()
+	for i := 0; i < 4; i++ {
+		_, rel, err := d.Get(context.Background(), srv.URL+"/"+strconv.Itoa(i)+".jpg", DownloadOptions{Sniff: jpegSniff})
+		if err != nil {
+			t.Fatalf("不期望错误：%v", err)
+		}
+		rels = append(rels, rel)
+	}
+	if n := count(); n != 4 {
+		t.Fatalf("持有中的文件不应被删除：%d", n)
+	}
+	// 释放后按最久未用淘汰到上限以内。
+	for _, rel := range rels {
+		rel()
+	}
+	if n := count(); n != 2 {
+		t.Fatalf("期望只保留 2 个文件：%d", n)
+	}
+	p, rel, err := d.Get(context.Background(), srv.URL+"/3.jpg", DownloadOptions{Sniff: jpegSniff})
+	if err != nil || d.Saved() != 1 {
+		t.Fatalf("最近使用的文件应被保留：%s err=%v saved=%d", p, err, d.Saved())
+	}
+	rel()
+
+	// 负数：不保留，用完即删。
+	d.MaxTotal = -1
+	_, rel, err = d.Get(context.Background(), srv.URL+"/x.jpg", DownloadOptions{Sniff: jpegSniff})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	rel()
+	if n := count(); n != 0 {
+		t.Fatalf("MaxTotal<0 时释放后应全部删除：%d", n)
+	}
+}
+
+func TestDownload_WebP(t *testing.T) {
+	webp := []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/webp")
+		_, _ = w.Write(webp)
+	}))
+	defer srv.Close()
+
+	c, err := NewImageClient("", false)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	sniff := func(head []byte) error {
+		_, err := imgx.Sniff(head)
+		return err
+	}
+	path, err := Download(context.Background(), c, srv.URL+"/img.webp", DownloadOptions{Sniff: sniff, Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("WebP 应通过 Sniff：%v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if !bytes.Equal(got, webp) {
+		t.Fatalf("下载内容不一致：len=%d", len(got))
+	}
+	if err := imgx.Validate(got); err != nil {
+		t.Fatalf("下载的 WebP 应可解码：%v", err)
+	}
+}
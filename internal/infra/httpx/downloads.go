@@ -0,0 +1,230 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// defaultDownloadsMaxTotal 是 Downloads 保留的已下载文件总字节上限（不含正在被调用方使用的文件）。
+const defaultDownloadsMaxTotal = 256 << 20
+
+// Downloads 合并同一次运行内对同一资源的流式下载（键为 URL + 影响响应的请求头 + MaxBytes）：
+//   - 并发的相同下载只真正发出一次，其余等待并共享同一个临时文件；
+//   - 成功下载的临时文件留作复用，之后的相同下载直接拿到它。
+//
+// 与 Dedup 不同，响应体始终经 Download 边读边写盘：MaxBytes/Sniff 在读取时即生效，内存中只保存文件路径。
+// 失败不共享也不缓存：等待者各自重新下载。同一 Downloads 的调用方应使用相同的 Sniff。
+//
+// 保留的文件有总量上限（MaxTotal）：超出时按最久未用的顺序删除没有调用方持有的文件，
+// 正在被持有（Get 之后尚未 release）的文件不会被删除。
+type Downloads struct {
+	Client *http.Client
+	// Dir 是临时文件目录（不存在时自动创建）；空串表示系统临时目录。
+	Dir string
+	// MaxTotal 是保留文件的总字节上限；0 表示默认 256MiB，负数表示不保留（用完即删）。
+	MaxTotal int64
+
+	mu      sync.Mutex
+	flights map[string]*dlFlight
+	files   map[string]*dlFile
+	total   int64
+	tick    int64
+	saved   int
+}
+
+// NewDownloads 用 c 构造 Downloads。
+func NewDownloads(c *http.Client) *Downloads {
+	return &Downloads{Client: c}
+}
+
+type dlFlight struct {
+	done    chan struct{}
+	waiters int     // 等待者数量：发布结果时一并计入引用，避免文件在它们醒来前被删除
+	file    *dlFile // nil 表示下载失败
+	settled bool
+}
+
+type dlFile struct {
+	key  string
+	path string
+	size int64
+	refs int
+	used int64
+}
+
+// Get 下载 u 并返回临时文件路径与 release。文件归 Downloads 所有：调用方只读、不要删除，
+// 用完后必须调用 release（之后文件可能随时被清理）。出错时 release 为 nil。
+func (d *Downloads) Get(ctx context.Context, u string, opt DownloadOptions) (string, func(), error) {
+	if d == nil || d.Client == nil {
+		return "", nil, errors.New("http client 为空")
+	}
+	key := downloadKey(u, opt)
+
+	d.mu.Lock()
+	if f := d.files[key]; f != nil {
+		d.saved++
+		f.refs++
+		d.mu.Unlock()
+		return f.path, d.releaser(f), nil
+	}
+	if fl := d.flights[key]; fl != nil {
+		fl.waiters++
+		d.mu.Unlock()
+		select {
+		case <-fl.done:
+		case <-ctx.Done():
+			d.mu.Lock()
+			if !fl.settled {
+				fl.waiters--
+			} else if fl.file != nil {
+				d.releaseLocked(fl.file)
+			}
+			d.mu.Unlock()
+			return "", nil, ctx.Err()
+		}
+		if fl.file == nil {
+			return d.Get(ctx, u, opt)
+		}
+		d.mu.Lock()
+		d.saved++
+		d.mu.Unlock()
+		return fl.file.path, d.releaser(fl.file), nil
+	}
+	if d.flights == nil {
+		d.flights = map[string]*dlFlight{}
+	}
+	fl := &dlFlight{done: make(chan struct{})}
+	d.flights[key] = fl
+	d.mu.Unlock()
+
+	if opt.Dir == "" {
+		opt.Dir = d.Dir
+	}
+	var (
+		path string
+		err  error
+	)
+	if opt.Dir != "" {
+		err = os.MkdirAll(opt.Dir, 0o755)
+	}
+	if err == nil {
+		path, err = Download(ctx, d.Client, u, opt)
+	}
+
+	d.mu.Lock()
+	delete(d.flights, key)
+	fl.settled = true
+	var f *dlFile
+	if err == nil {
+		f = &dlFile{key: key, path: path, refs: 1 + fl.waiters}
+		if fi, serr := os.Stat(path); serr == nil {
+			f.size = fi.Size()
+		}
+		if d.files == nil {
+			d.files = map[string]*dlFile{}
+		}
+		d.files[key] = f
+		d.total += f.size
+		d.touchLocked(f)
+		fl.file = f
+	}
+	d.mu.Unlock()
+	close(fl.done)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, d.releaser(f), nil
+}
+
+func (d *Downloads) releaser(f *dlFile) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			d.releaseLocked(f)
+			d.mu.Unlock()
+		})
+	}
+}
+
+func (d *Downloads) releaseLocked(f *dlFile) {
+	f.refs--
+	d.touchLocked(f)
+	d.evictLocked()
+}
+
+func (d *Downloads) touchLocked(f *dlFile) {
+	d.tick++
+	f.used = d.tick
+}
+
+// evictLocked 在超出 MaxTotal 时按最久未用删除无人持有的文件。
+func (d *Downloads) evictLocked() {
+	limit := d.MaxTotal
+	if limit == 0 {
+		limit = defaultDownloadsMaxTotal
+	}
+	for d.total > limit {
+		var victim *dlFile
+		for _, f := range d.files {
+			if f.refs <= 0 && (victim == nil || f.used < victim.used) {
+				victim = f
+			}
+		}
+		if victim == nil {
+			return
+		}
+		d.removeLocked(victim)
+	}
+}
+
+func (d *Downloads) removeLocked(f *dlFile) error {
+	delete(d.files, f.key)
+	d.total -= f.size
+	if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Saved 返回被合并或命中已下载文件、因此没有真正发出的下载数。
+func (d *Downloads) Saved() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.saved
+}
+
+// Close 删除所有保留的临时文件（Dir 为空目录时一并删除）；之后的 Get 会重新下载。
+func (d *Downloads) Close() error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var first error
+	for _, f := range d.files {
+		if err := d.removeLocked(f); err != nil && first == nil {
+			first = err
+		}
+	}
+	if d.Dir != "" {
+		_ = os.Remove(d.Dir) // 非空（例如其他进程的文件）时保留
+	}
+	return first
+}
+
+// downloadKey 与 dedupKey 一致地把会改变站点响应的请求头计入键；MaxBytes 不同的调用不共享结果。
+func downloadKey(u string, opt DownloadOptions) string {
+	key := u
+	for _, h := range []string{"Referer", "Cookie", "Range", "Authorization"} {
+		key += "\n" + opt.Header.Get(h)
+	}
+	return key + "\n" + strconv.FormatInt(opt.MaxBytes, 10)
+}
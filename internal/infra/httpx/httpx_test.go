@@ -1,6 +1,13 @@
 package httpx
 
-import "testing"
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestNewMetaClient_ProxyDisablesKeepAlive(t *testing.T) {
 	c, err := NewMetaClient("http://127.0.0.1:8080")
@@ -71,3 +78,72 @@ func TestNewMetaClient_InvalidProxyURL(t *testing.T) {
 		t.Fatalf("期望错误，但得到 nil")
 	}
 }
+
+func TestDedup(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path+"|"+r.Header.Get("Referer")]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/slow":
+			<-release
+			_, _ = w.Write([]byte("page"))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	d := NewDedup(http.DefaultTransport)
+	c := &http.Client{Transport: d}
+	get := func(path, referer string) string {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatalf("不期望错误：%v", err)
+		}
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Errorf("不期望错误：%v", err)
+			return ""
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	// 并发的相同请求只发出一次；晚到的请求命中缓存，同样不再发出。
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := get("/slow", ""); got != "page" {
+				t.Errorf("共享的响应体不符合预期：%q", got)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if hits["/slow|"] != 1 || d.Saved() != 4 {
+		t.Fatalf("期望只请求 1 次、节省 4 次：hits=%v saved=%d", hits, d.Saved())
+	}
+
+	// 非 2xx 不缓存；Referer 不同视为不同请求。
+	get("/missing", "")
+	get("/missing", "")
+	get("/img", "https://a.test/")
+	get("/img", "https://b.test/")
+	get("/img", "https://a.test/")
+	if hits["/missing|"] != 2 || hits["/img|https://a.test/"] != 1 || hits["/img|https://b.test/"] != 1 || d.Saved() != 5 {
+		t.Fatalf("缓存键/状态码处理不符合预期：hits=%v saved=%d", hits, d.Saved())
+	}
+}
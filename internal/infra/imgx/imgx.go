@@ -3,44 +3,56 @@ package imgx
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"image"
-	"image/draw"
-	"image/jpeg"
-	_ "image/png" // 注册 PNG 解码器（输入不一定总是 jpeg）
+	_ "image/jpeg" // 注册 JPEG 解码器
+	_ "image/png"  // 注册 PNG 解码器（输入不一定总是 jpeg）
+	"net/http"
+
+	_ "golang.org/x/image/webp" // 注册 WebP 解码器（部分图床按 Accept 返回 WebP）
 )
 
-// PosterFromFanartRightHalfJPEG 把 fanart 图片裁切为“右半边”，并编码为 JPEG（用于 poster.jpg）。
+// Sniff 按魔数判断 head（响应体开头，建议 ≥12 字节）是否为 JPEG/PNG/WebP，返回格式名。
 //
-// 约束：
-// - 输入允许是 JPEG/PNG（依赖标准库解码器）
-// - 输出固定为 JPEG
-// - 裁切规则：保留原高度，宽度取右半边（从 w/2 到 w）
-func PosterFromFanartRightHalfJPEG(fanart []byte) ([]byte, error) {
-	if len(fanart) == 0 {
-		return nil, errors.New("fanart 为空")
+// 用途：下载时在写完之前就拒绝 200 状态码的 HTML 错误页、JSON 等非图片内容。
+// 只看魔数，不保证可完整解码（完整校验见 Validate）。
+func Sniff(head []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg", nil
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "png", nil
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return "webp", nil
+	case len(head) == 0:
+		return "", errors.New("内容为空")
 	}
+	return "", fmt.Errorf("内容不是 JPEG/PNG/WebP 图片（检测为 %s）", http.DetectContentType(head))
+}
 
-	img, _, err := image.Decode(bytes.NewReader(fanart))
+// Validate 校验 b 是否为可完整解码的图片（JPEG/PNG/WebP）。
+//
+// 用途：识别 0 字节、被当作图片保存的 HTML 错误页、截断文件等“看起来存在但不可用”的 sidecar。
+func Validate(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("图片为空")
+	}
+	img, _, err := image.Decode(bytes.NewReader(b))
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	b := img.Bounds()
-	if b.Dx() <= 0 || b.Dy() <= 0 {
-		return nil, errors.New("图片尺寸无效")
+	if r := img.Bounds(); r.Dx() <= 0 || r.Dy() <= 0 {
+		return errors.New("图片尺寸无效")
 	}
+	return nil
+}
 
-	// 右半边：x 从 w/2 到 w，y 全保留。
-	x0 := b.Min.X + b.Dx()/2
-	srcRect := image.Rect(x0, b.Min.Y, b.Max.X, b.Max.Y)
-
-	dst := image.NewRGBA(image.Rect(0, 0, srcRect.Dx(), srcRect.Dy()))
-	draw.Draw(dst, dst.Bounds(), img, srcRect.Min, draw.Src)
-
-	var out bytes.Buffer
-	// 质量：不需要太“讲究”，但要稳定可用；95 在体积与质量之间比较均衡。
-	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 95}); err != nil {
-		return nil, err
-	}
-	return out.Bytes(), nil
+// PosterFromFanartRightHalfJPEG 把 fanart 图片裁切为“右半边”，并编码为 JPEG（用于 poster.jpg）。
+//
+// 约束：
+// - 输入允许是 JPEG/PNG（依赖标准库解码器）
+// - 输出固定为 JPEG
+// - 裁切规则：保留原高度，宽度取右半边（从 w/2 到 w）
+func PosterFromFanartRightHalfJPEG(fanart []byte) ([]byte, error) {
+	return PosterJPEG(fanart, PosterRightHalf)
 }
@@ -56,3 +56,64 @@ func TestPosterFromFanartRightHalfJPEG_Empty(t *testing.T) {
 		t.Fatalf("期望空输入返回错误")
 	}
 }
+
+func TestValidate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatalf("encode jpeg 失败：%v", err)
+	}
+	if err := Validate(buf.Bytes()); err != nil {
+		t.Fatalf("期望合法 JPEG 通过校验：%v", err)
+	}
+	if err := Validate(nil); err == nil {
+		t.Fatalf("期望空输入返回错误")
+	}
+	if err := Validate([]byte("<html>blocked</html>")); err == nil {
+		t.Fatalf("期望 HTML 内容返回错误")
+	}
+	if err := Validate(buf.Bytes()[:buf.Len()/2]); err == nil {
+		t.Fatalf("期望截断 JPEG 返回错误")
+	}
+}
+
+func TestSniff(t *testing.T) {
+	for _, tc := range []struct {
+		head []byte
+		want string
+	}{
+		{[]byte{0xFF, 0xD8, 0xFF, 0xE0}, "jpeg"},
+		{[]byte("\x89PNG\r\n\x1a\n...."), "png"},
+		{[]byte("RIFF\x10\x00\x00\x00WEBPVP8 "), "webp"},
+	} {
+		if got, err := Sniff(tc.head); err != nil || got != tc.want {
+			t.Fatalf("%q：期望 %s，实际 %s err=%v", tc.head, tc.want, got, err)
+		}
+	}
+	for _, bad := range [][]byte{nil, []byte("<!DOCTYPE html><html>"), []byte("RIFF\x10\x00\x00\x00WAVE")} {
+		if _, err := Sniff(bad); err == nil {
+			t.Fatalf("%q：期望错误", bad)
+		}
+	}
+}
+
+// tinyWebP 是 1x1 的无损 WebP（VP8L）。
+var tinyWebP = []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+func TestWebP_DecodeAndReencode(t *testing.T) {
+	if f, err := Sniff(tinyWebP); err != nil || f != "webp" {
+		t.Fatalf("期望识别为 webp：%s err=%v", f, err)
+	}
+	if err := Validate(tinyWebP); err != nil {
+		t.Fatalf("期望合法 WebP 通过校验：%v", err)
+	}
+	// 即使 format=original 也重新编码为 JPEG：sidecar 是 .jpg。
+	for _, format := range []string{FormatOriginal, FormatJPEG} {
+		out, err := Process(tinyWebP, EncodeOptions{Format: format})
+		if err != nil {
+			t.Fatalf("%s：不期望错误：%v", format, err)
+		}
+		if _, f, err := image.Decode(bytes.NewReader(out)); err != nil || f != "jpeg" {
+			t.Fatalf("%s：期望输出 JPEG，实际 %s err=%v", format, f, err)
+		}
+	}
+}
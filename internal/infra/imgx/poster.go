@@ -0,0 +1,257 @@
+package imgx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// poster 裁切策略（对外稳定，与 avmc.json 的 poster.strategy 取值一致）。
+const (
+	PosterAuto      = "auto"       // 按 fanart 宽高比自动选择
+	PosterRightHalf = "right_half" // 标准双联封面：取右半边
+	PosterCenter    = "center"     // 居中裁切为 2:3
+	PosterPad       = "pad"        // 完整保留原图，补边到 2:3
+	PosterSmart     = "smart"      // 基于肤色/边缘的显著性窗口裁切为 2:3
+)
+
+// ValidPosterStrategy 判断 s 是否为支持的策略名。
+func ValidPosterStrategy(s string) bool {
+	switch s {
+	case PosterAuto, PosterRightHalf, PosterCenter, PosterPad, PosterSmart:
+		return true
+	default:
+		return false
+	}
+}
+
+// PickPosterStrategy 按宽高比为 auto 选择具体策略。
+//
+// 经验区间（宽/高）：
+// - [1.3, 2.2)：标准双联封面（约 1.42~1.5）=> right_half
+// - >= 2.2：超宽封面（常见于 VR）=> smart
+// - (0.8, 1.3)：方形/单图封面 => smart
+// - <= 0.8：本身已接近竖版 => center
+func PickPosterStrategy(w, h int) string {
+	if w <= 0 || h <= 0 {
+		return PosterRightHalf
+	}
+	r := float64(w) / float64(h)
+	switch {
+	case r >= 2.2:
+		return PosterSmart
+	case r >= 1.3:
+		return PosterRightHalf
+	case r > 0.8:
+		return PosterSmart
+	default:
+		return PosterCenter
+	}
+}
+
+// DefaultPosterQuality 是 poster 的默认 JPEG 质量：在体积与质量之间比较均衡。
+const DefaultPosterQuality = 95
+
+// PosterJPEG 按 strategy 从 fanart 生成 poster，并以默认质量编码为 JPEG（不限尺寸）。
+// strategy 为空等价于 auto。
+func PosterJPEG(fanart []byte, strategy string) ([]byte, error) {
+	return Poster(fanart, strategy, EncodeOptions{Quality: DefaultPosterQuality, Format: FormatJPEG})
+}
+
+// Poster 与 PosterJPEG 相同，但按 opt 限制尺寸与 JPEG 质量（poster 总是重新编码）。
+func Poster(fanart []byte, strategy string, opt EncodeOptions) ([]byte, error) {
+	if len(fanart) == 0 {
+		return nil, errors.New("fanart 为空")
+	}
+	img, _, err := image.Decode(bytes.NewReader(fanart))
+	if err != nil {
+		return nil, err
+	}
+	dst, err := posterImage(img, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encode(dst, opt)
+}
+
+func posterImage(img image.Image, strategy string) (image.Image, error) {
+	b := img.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return nil, errors.New("图片尺寸无效")
+	}
+
+	if strategy == "" || strategy == PosterAuto {
+		strategy = PickPosterStrategy(b.Dx(), b.Dy())
+	}
+
+	switch strategy {
+	case PosterRightHalf:
+		// 右半边：x 从 w/2 到 w，y 全保留。
+		return crop(img, image.Rect(b.Min.X+b.Dx()/2, b.Min.Y, b.Max.X, b.Max.Y)), nil
+	case PosterCenter:
+		r := window23(b)
+		return crop(img, r.Add(image.Pt((b.Dx()-r.Dx())/2, (b.Dy()-r.Dy())/2))), nil
+	case PosterPad:
+		return pad23(img), nil
+	case PosterSmart:
+		return crop(img, smartWindow(img)), nil
+	default:
+		return nil, fmt.Errorf("未知 poster 策略：%q", strategy)
+	}
+}
+
+func crop(img image.Image, r image.Rectangle) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+// window23 返回能放进 b 的最大 2:3 窗口（位于 b.Min）。
+func window23(b image.Rectangle) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if w*3 > h*2 {
+		// 偏宽：高度占满。
+		cw := h * 2 / 3
+		if cw < 1 {
+			cw = 1
+		}
+		return image.Rect(b.Min.X, b.Min.Y, b.Min.X+cw, b.Max.Y)
+	}
+	ch := w * 3 / 2
+	if ch < 1 {
+		ch = 1
+	}
+	return image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+ch)
+}
+
+// pad23 把整张图居中放入 2:3 画布，空白处填黑。
+func pad23(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	cw, ch := w, h
+	if w*3 > h*2 {
+		ch = w * 3 / 2
+	} else {
+		cw = h * 2 / 3
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, cw, ch))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	off := image.Pt((cw-w)/2, (ch-h)/2)
+	draw.Draw(dst, image.Rectangle{Min: off, Max: off.Add(image.Pt(w, h))}, img, b.Min, draw.Src)
+	return dst
+}
+
+// smartWindow 在 2:3 窗口可滑动的方向上，选出“显著性”总分最高的位置。
+//
+// 显著性 = 肤色像素（权重高，人物通常是封面主体）+ 亮度梯度（纹理/轮廓）。
+// 为了速度在采样网格上计算（长边约 160 个采样点），结果映射回原图坐标。
+func smartWindow(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	win := window23(b)
+	horizontal := win.Dx() < b.Dx()
+	if win.Dx() == b.Dx() && win.Dy() == b.Dy() {
+		return win
+	}
+
+	step := max(b.Dx(), b.Dy()) / 160
+	if step < 1 {
+		step = 1
+	}
+	gw := (b.Dx() + step - 1) / step
+	gh := (b.Dy() + step - 1) / step
+
+	lum := make([]float64, gw*gh)
+	skin := make([]bool, gw*gh)
+	for gy := 0; gy < gh; gy++ {
+		for gx := 0; gx < gw; gx++ {
+			r, g, bl, _ := img.At(b.Min.X+gx*step, b.Min.Y+gy*step).RGBA()
+			r8, g8, b8 := int(r>>8), int(g>>8), int(bl>>8)
+			lum[gy*gw+gx] = 0.299*float64(r8) + 0.587*float64(g8) + 0.114*float64(b8)
+			skin[gy*gw+gx] = isSkin(r8, g8, b8)
+		}
+	}
+
+	// 沿滑动方向累加每一列（或每一行）的得分。
+	n := gh
+	if horizontal {
+		n = gw
+	}
+	line := make([]float64, n)
+	for gy := 0; gy < gh; gy++ {
+		for gx := 0; gx < gw; gx++ {
+			i := gy*gw + gx
+			score := 0.0
+			if skin[i] {
+				score += 64
+			}
+			if gx+1 < gw {
+				score += absf(lum[i+1] - lum[i])
+			}
+			if gy+1 < gh {
+				score += absf(lum[i+gw] - lum[i])
+			}
+			if horizontal {
+				line[gx] += score
+			} else {
+				line[gy] += score
+			}
+		}
+	}
+
+	span := win.Dy()
+	total := b.Dy()
+	if horizontal {
+		span = win.Dx()
+		total = b.Dx()
+	}
+	k := (span + step - 1) / step
+	if k > n {
+		k = n
+	}
+
+	best, bestAt, sum := -1.0, 0, 0.0
+	for i := 0; i < n; i++ {
+		sum += line[i]
+		if i >= k {
+			sum -= line[i-k]
+		}
+		if i >= k-1 && sum > best {
+			best = sum
+			bestAt = i - k + 1
+		}
+	}
+
+	off := bestAt * step
+	if off+span > total {
+		off = total - span
+	}
+	if horizontal {
+		return win.Add(image.Pt(off, 0))
+	}
+	return win.Add(image.Pt(0, off))
+}
+
+// isSkin 是经典的 RGB 肤色规则（对亮光下的肤色足够好，且纯 Go、无依赖）。
+func isSkin(r, g, b int) bool {
+	mx := max(r, max(g, b))
+	mn := min(r, min(g, b))
+	return r > 95 && g > 40 && b > 20 && mx-mn > 15 && absInt(r-g) > 15 && r > g && r > b
+}
+
+func absf(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package imgx
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestPickPosterStrategy(t *testing.T) {
+	cases := []struct {
+		w, h int
+		want string
+	}{
+		{800, 538, PosterRightHalf}, // 标准双联封面
+		{200, 100, PosterRightHalf},
+		{1600, 600, PosterSmart}, // 超宽（VR）
+		{800, 800, PosterSmart},  // 方形
+		{400, 600, PosterCenter}, // 已是竖版
+	}
+	for _, c := range cases {
+		if got := PickPosterStrategy(c.w, c.h); got != c.want {
+			t.Fatalf("%dx%d：期望 %s，实际 %s", c.w, c.h, c.want, got)
+		}
+	}
+}
+
+func TestPosterJPEG_CenterAndPadSizes(t *testing.T) {
+	src := mustPNG(t, image.NewRGBA(image.Rect(0, 0, 300, 300)))
+
+	out, err := PosterJPEG(src, PosterCenter)
+	if err != nil {
+		t.Fatalf("center 失败：%v", err)
+	}
+	if w, h := decodedSize(t, out); w != 200 || h != 300 {
+		t.Fatalf("center 尺寸不符合预期：%dx%d", w, h)
+	}
+
+	out, err = PosterJPEG(src, PosterPad)
+	if err != nil {
+		t.Fatalf("pad 失败：%v", err)
+	}
+	if w, h := decodedSize(t, out); w != 300 || h != 450 {
+		t.Fatalf("pad 尺寸不符合预期：%dx%d", w, h)
+	}
+
+	if _, err := PosterJPEG(src, "nope"); err == nil {
+		t.Fatalf("期望未知策略返回错误")
+	}
+}
+
+func TestPosterJPEG_SmartFollowsSkinRegion(t *testing.T) {
+	// 超宽灰底图，肤色块位于右侧：smart 窗口应覆盖该区域。
+	const w, h = 300, 90
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{90, 90, 90, 255}
+			if x >= 230 && x < 280 {
+				c = color.RGBA{224, 172, 140, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	r := smartWindow(img)
+	if r.Dx() != 60 || r.Dy() != h {
+		t.Fatalf("窗口尺寸应为 2:3：%v", r)
+	}
+	if r.Min.X > 230 || r.Max.X < 280 {
+		t.Fatalf("smart 窗口未覆盖肤色区域：%v", r)
+	}
+
+	out, err := PosterJPEG(mustPNG(t, img), PosterAuto)
+	if err != nil {
+		t.Fatalf("auto 失败：%v", err)
+	}
+	if gw, gh := decodedSize(t, out); gw != 60 || gh != h {
+		t.Fatalf("auto（超宽 => smart）尺寸不符合预期：%dx%d", gw, gh)
+	}
+}
+
+func mustPNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png 失败：%v", err)
+	}
+	return buf.Bytes()
+}
+
+func decodedSize(t *testing.T, b []byte) (int, int) {
+	t.Helper()
+	img, err := jpeg.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("decode jpeg 失败：%v", err)
+	}
+	return img.Bounds().Dx(), img.Bounds().Dy()
+}
@@ -0,0 +1,227 @@
+package imgx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"math"
+)
+
+// 输出格式（对外稳定，与 avmc.json 的 fanart.format / poster.format 取值一致）。
+// 文件名固定为 .jpg（媒体库按文件名识别 sidecar），因此只支持 JPEG 输出或“保留原始字节”。
+const (
+	FormatJPEG     = "jpeg"
+	FormatOriginal = "original" // 不需要缩放时保留下载到的原始字节（仅 fanart 等下载类图片有意义）
+)
+
+// DefaultQuality 是需要重新编码且未指定质量时使用的 JPEG 质量。
+const DefaultQuality = 90
+
+// EncodeOptions 描述 sidecar 图片的尺寸上限与编码方式。零值表示“不限尺寸 + 保留原始字节”。
+type EncodeOptions struct {
+	MaxWidth  int // 0 表示不限
+	MaxHeight int // 0 表示不限
+	Quality   int // 1~100；0 表示 DefaultQuality
+	Format    string
+}
+
+// Process 校验 b 是真实图片，并按 opt 缩放/重新编码。
+//
+// - Format=original 且无需缩放：原样返回 b（避免无谓的有损重编码）
+// - WebP 输入总是重新编码为 JPEG：sidecar 文件名是 .jpg，不少播放器/刮削器不认 WebP
+// - 其余情况：等比缩小到 MaxWidth x MaxHeight 以内（从不放大），编码为 JPEG
+func Process(b []byte, opt EncodeOptions) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, errors.New("图片为空")
+	}
+	img, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	r := img.Bounds()
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return nil, errors.New("图片尺寸无效")
+	}
+
+	w, h := FitSize(r.Dx(), r.Dy(), opt.MaxWidth, opt.MaxHeight)
+	if (opt.Format == "" || opt.Format == FormatOriginal) && format != "webp" && w == r.Dx() && h == r.Dy() {
+		return b, nil
+	}
+	return Encode(img, opt)
+}
+
+// Encode 按 opt 等比缩小 img（从不放大）并编码为 JPEG。
+func Encode(img image.Image, opt EncodeOptions) ([]byte, error) {
+	r := img.Bounds()
+	w, h := FitSize(r.Dx(), r.Dy(), opt.MaxWidth, opt.MaxHeight)
+	if w != r.Dx() || h != r.Dy() {
+		img = Resize(img, w, h)
+	}
+
+	q := opt.Quality
+	if q <= 0 {
+		q = DefaultQuality
+	}
+	if q > 100 {
+		return nil, fmt.Errorf("JPEG 质量必须在 1~100：%d", q)
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: q}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// FitSize 返回把 w x h 等比缩小到 maxW x maxH 以内后的尺寸（max 为 0 表示该方向不限；从不放大）。
+func FitSize(w, h, maxW, maxH int) (int, int) {
+	scale := 1.0
+	if maxW > 0 && w > maxW {
+		scale = math.Min(scale, float64(maxW)/float64(w))
+	}
+	if maxH > 0 && h > maxH {
+		scale = math.Min(scale, float64(maxH)/float64(h))
+	}
+	if scale >= 1 {
+		return w, h
+	}
+	nw := int(math.Round(float64(w) * scale))
+	nh := int(math.Round(float64(h) * scale))
+	return max(nw, 1), max(nh, 1)
+}
+
+// Resize 用 Catmull-Rom 三次卷积把 img 重采样为 w x h（可分离的两趟卷积）。
+//
+// 缩小时按比例放宽卷积核（等价于先低通再采样），避免最近邻/双线性常见的锯齿与摩尔纹。
+func Resize(img image.Image, w, h int) *image.RGBA {
+	src := toRGBA(img)
+	sb := src.Bounds()
+
+	// 先水平（sw x sh -> w x sh），再垂直（w x sh -> w x h）。
+	tmp := resample(src.Pix, src.Stride, sb.Dx(), sb.Dy(), w, true)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	out := resample(tmp, w*4, w, sb.Dy(), h, false)
+	copy(dst.Pix, out)
+	return dst
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if r, ok := img.(*image.RGBA); ok && r.Bounds().Min == (image.Point{}) {
+		return r
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// resample 沿一个方向重采样。horizontal=true 时把每行从 sw 采样到 n；否则把每列从 sh 采样到 n。
+// 输出按紧凑 RGBA 排列（stride = 输出宽度 * 4）。
+func resample(pix []byte, stride, sw, sh, n int, horizontal bool) []byte {
+	srcLen := sh
+	if horizontal {
+		srcLen = sw
+	}
+	weights, starts := kernelWeights(srcLen, n)
+
+	var outW, outH int
+	if horizontal {
+		outW, outH = n, sh
+	} else {
+		outW, outH = sw, n
+	}
+	out := make([]byte, outW*outH*4)
+
+	lines := sh
+	if !horizontal {
+		lines = sw
+	}
+	for line := 0; line < lines; line++ {
+		for i := 0; i < n; i++ {
+			var r, g, b, a float64
+			for k, wt := range weights[i] {
+				j := starts[i] + k
+				var off int
+				if horizontal {
+					off = line*stride + j*4
+				} else {
+					off = j*stride + line*4
+				}
+				r += wt * float64(pix[off])
+				g += wt * float64(pix[off+1])
+				b += wt * float64(pix[off+2])
+				a += wt * float64(pix[off+3])
+			}
+			var o int
+			if horizontal {
+				o = (line*outW + i) * 4
+			} else {
+				o = (i*outW + line) * 4
+			}
+			out[o] = clamp8(r)
+			out[o+1] = clamp8(g)
+			out[o+2] = clamp8(b)
+			out[o+3] = clamp8(a)
+		}
+	}
+	return out
+}
+
+// kernelWeights 预计算每个输出位置的卷积权重（已归一化）与起始源下标。
+func kernelWeights(srcLen, dstLen int) ([][]float64, []int) {
+	scale := float64(srcLen) / float64(dstLen)
+	support := 2.0 // Catmull-Rom 半径
+	filterScale := math.Max(scale, 1)
+	radius := support * filterScale
+
+	weights := make([][]float64, dstLen)
+	starts := make([]int, dstLen)
+	for i := 0; i < dstLen; i++ {
+		center := (float64(i)+0.5)*scale - 0.5
+		lo := int(math.Ceil(center - radius))
+		hi := int(math.Floor(center + radius))
+		lo = max(lo, 0)
+		hi = min(hi, srcLen-1)
+
+		ws := make([]float64, 0, hi-lo+1)
+		sum := 0.0
+		for j := lo; j <= hi; j++ {
+			wt := catmullRom((float64(j) - center) / filterScale)
+			ws = append(ws, wt)
+			sum += wt
+		}
+		if sum != 0 {
+			for k := range ws {
+				ws[k] /= sum
+			}
+		}
+		weights[i] = ws
+		starts[i] = lo
+	}
+	return weights, starts
+}
+
+func catmullRom(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return (1.5*x-2.5)*x*x + 1
+	case x < 2:
+		return ((-0.5*x+2.5)*x-4)*x + 2
+	default:
+		return 0
+	}
+}
+
+func clamp8(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
@@ -0,0 +1,82 @@
+package imgx
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFitSize(t *testing.T) {
+	cases := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{800, 538, 0, 0, 800, 538},       // 不限
+		{800, 538, 400, 0, 400, 269},     // 只限宽
+		{800, 538, 0, 269, 400, 269},     // 只限高
+		{800, 538, 1000, 1000, 800, 538}, // 从不放大
+		{1000, 100, 100, 100, 100, 10},   // 两个方向取更严格者
+	}
+	for _, c := range cases {
+		if w, h := FitSize(c.w, c.h, c.maxW, c.maxH); w != c.wantW || h != c.wantH {
+			t.Fatalf("FitSize(%d,%d,%d,%d)：期望 %dx%d，实际 %dx%d", c.w, c.h, c.maxW, c.maxH, c.wantW, c.wantH, w, h)
+		}
+	}
+}
+
+func TestProcess_OriginalKeepsBytesWhenNoResize(t *testing.T) {
+	src := mustPNG(t, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	out, err := Process(src, EncodeOptions{MaxWidth: 100, Format: FormatOriginal})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("无需缩放时应保留原始字节")
+	}
+}
+
+func TestProcess_ResizesAndReencodes(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+
+	out, err := Process(mustPNG(t, img), EncodeOptions{MaxWidth: 100, Format: FormatOriginal})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if w, h := decodedSize(t, out); w != 100 || h != 50 {
+		t.Fatalf("缩放后尺寸不符合预期：%dx%d", w, h)
+	}
+
+	// 纯色图缩放后颜色应基本不变（卷积权重已归一化）。
+	dec, _, err := image.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode 失败：%v", err)
+	}
+	r, g, b, _ := dec.At(50, 25).RGBA()
+	if absInt(int(r>>8)-200) > 8 || absInt(int(g>>8)-100) > 8 || absInt(int(b>>8)-50) > 8 {
+		t.Fatalf("缩放后颜色偏差过大：%d,%d,%d", r>>8, g>>8, b>>8)
+	}
+
+	// Format=jpeg 即使无需缩放也重新编码为 JPEG。
+	out, err = Process(mustPNG(t, img), EncodeOptions{Format: FormatJPEG, Quality: 80})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if w, h := decodedSize(t, out); w != 400 || h != 200 {
+		t.Fatalf("重编码尺寸不符合预期：%dx%d", w, h)
+	}
+}
+
+func TestProcess_RejectsNonImage(t *testing.T) {
+	if _, err := Process([]byte("<html>blocked</html>"), EncodeOptions{}); err == nil {
+		t.Fatalf("HTML 不应被当作图片")
+	}
+	if _, err := Process(nil, EncodeOptions{}); err == nil {
+		t.Fatalf("空字节应报错")
+	}
+}
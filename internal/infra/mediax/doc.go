@@ -0,0 +1,2 @@
+// Package mediax 用纯 Go 解析 MP4/Matroska 容器头部，得到时长、分辨率与编码（只读头部字节，不解码）。
+package mediax
@@ -0,0 +1,72 @@
+package mediax
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// ErrUnsupported 表示文件不是可识别的 MP4/Matroska 容器（例如字幕、AVI、TS）。
+var ErrUnsupported = errors.New("不支持的容器格式")
+
+// maxBoxBytes 是单次读入内存的头部结构上限（moov / Info / Tracks）；超过视为损坏。
+const maxBoxBytes = 64 << 20
+
+// Probe 打开 path 并解析容器头部（见 ProbeReaderAt）。
+func Probe(path string) (domain.MediaInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return domain.MediaInfo{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return domain.MediaInfo{}, err
+	}
+	return ProbeReaderAt(f, fi.Size())
+}
+
+// ProbeReaderAt 按魔数识别容器并解析头部：
+//   - MP4/MOV：顶层 box 只读 8/16 字节头并跳过，mdat 不读取；只把 moov 读入内存
+//   - Matroska/WebM：读取 Segment 下的 Info 与 Tracks；遇到 Cluster 时按 SeekHead 定位，不扫描媒体数据
+//
+// 非 MP4/Matroska 返回 ErrUnsupported。
+func ProbeReaderAt(r io.ReaderAt, size int64) (domain.MediaInfo, error) {
+	var head [12]byte
+	n, err := r.ReadAt(head[:], 0)
+	if n < len(head) {
+		if err == nil || err == io.EOF {
+			return domain.MediaInfo{}, ErrUnsupported
+		}
+		return domain.MediaInfo{}, err
+	}
+	switch {
+	case bytes.Equal(head[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return probeMKV(r, size)
+	case string(head[4:8]) == "ftyp" || string(head[4:8]) == "moov":
+		return probeMP4(r, size)
+	}
+	return domain.MediaInfo{}, ErrUnsupported
+}
+
+// readAt 读取 [off, off+n) 的完整字节；n 超过 maxBoxBytes 或越界时报错。
+func readAt(r io.ReaderAt, off, n, size int64) ([]byte, error) {
+	if n < 0 || n > maxBoxBytes {
+		return nil, fmt.Errorf("头部结构过大：%d 字节", n)
+	}
+	if off < 0 || off+n > size {
+		return nil, fmt.Errorf("头部结构越界：offset=%d size=%d", off, n)
+	}
+	b := make([]byte, n)
+	if m, err := r.ReadAt(b, off); m < len(b) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
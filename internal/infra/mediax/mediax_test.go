@@ -0,0 +1,141 @@
+package mediax
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func mp4Box(typ string, parts ...[]byte) []byte {
+	body := bytes.Join(parts, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+	copy(b[4:], typ)
+	return append(b, body...)
+}
+
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+
+func mp4Trak(handler string, lang string, entry []byte) []byte {
+	hdlr := mp4Box("hdlr", make([]byte, 8), []byte(handler), make([]byte, 12))
+	packed := uint16(0)
+	for _, c := range []byte(lang) {
+		packed = packed<<5 | uint16(c-0x60)
+	}
+	mdhd := mp4Box("mdhd", make([]byte, 20), u16(packed), make([]byte, 2))
+	stsd := mp4Box("stsd", make([]byte, 4), u32(1), entry)
+	tkhd := mp4Box("tkhd", make([]byte, 76), u32(640<<16), u32(360<<16))
+	return mp4Box("trak", tkhd, mp4Box("mdia", mdhd, hdlr, mp4Box("minf", mp4Box("stbl", stsd))))
+}
+
+func sampleMP4(mdatFirst bool) []byte {
+	video := mp4Box("avc1", make([]byte, 24), u16(1920), u16(1080), make([]byte, 50))
+	audio := mp4Box("mp4a", make([]byte, 16), u16(6), make([]byte, 10))
+	mvhd := mp4Box("mvhd", make([]byte, 12), u32(1000), u32(7265500), make([]byte, 80))
+	moov := mp4Box("moov", mvhd, mp4Trak("vide", "und", video), mp4Trak("soun", "jpn", audio))
+	ftyp := mp4Box("ftyp", []byte("isom"), u32(512))
+	mdat := mp4Box("mdat", bytes.Repeat([]byte{0xAB}, 4096))
+	if mdatFirst {
+		return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+	}
+	return bytes.Join([][]byte{ftyp, moov, mdat}, nil)
+}
+
+func ebml(id uint64, parts ...[]byte) []byte {
+	body := bytes.Join(parts, nil)
+	var b []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if c := byte(id >> shift); c != 0 || len(b) > 0 {
+			b = append(b, c)
+		}
+	}
+	// 长度统一用 8 字节 vint（0x01 标记 + 7 字节）。
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(body)))
+	size[0] = 0x01
+	return append(append(b, size...), body...)
+}
+
+func ebmlU(id uint64, v uint64) []byte { return ebml(id, binary.BigEndian.AppendUint64(nil, v)) }
+
+func sampleMKV(tracksAfterCluster bool) []byte {
+	header := ebml(idEBML, ebml(idDocType, []byte("matroska")))
+	info := ebml(idInfo, ebmlU(idTimecodeScale, 1000000), ebml(idDuration, binary.BigEndian.AppendUint64(nil, math.Float64bits(5400500))))
+	tracks := ebml(idTracks,
+		ebml(idTrackEntry, ebmlU(idTrackType, mkvTrackVideo), ebml(idCodecID, []byte("V_MPEGH/ISO/HEVC")),
+			ebml(idVideo, ebmlU(idPixelWidth, 3840), ebmlU(idPixelHeight, 2160))),
+		ebml(idTrackEntry, ebmlU(idTrackType, mkvTrackAudio), ebml(idCodecID, []byte("A_AAC")), ebmlU(idFlagDefault, 0),
+			ebml(idAudio, ebmlU(idChannels, 2))),
+		ebml(idTrackEntry, ebmlU(idTrackType, mkvTrackAudio), ebml(idCodecID, []byte("A_AC3")), ebml(idLanguage, []byte("jpn")),
+			ebml(idAudio, ebmlU(idChannels, 6))),
+	)
+	cluster := ebml(idCluster, bytes.Repeat([]byte{0xCD}, 2048))
+	if !tracksAfterCluster {
+		return append(header, ebml(idSegment, info, tracks, cluster)...)
+	}
+	// SeekHead 的位置相对 Segment 内容起点；SeekHead 自身长度固定，可先按占位计算。
+	seekHead := func(pos uint64) []byte {
+		return ebml(idSeekHead, ebml(idSeek, ebml(idSeekID, u32(idTracks)), ebmlU(idSeekPosition, pos)))
+	}
+	pos := uint64(len(seekHead(0)) + len(info) + len(cluster))
+	return append(header, ebml(idSegment, seekHead(pos), info, cluster, tracks)...)
+}
+
+func TestProbe_MP4(t *testing.T) {
+	want := domain.MediaInfo{Container: "mp4", DurationS: 7265.5, VideoCodec: "h264", Width: 1920, Height: 1080, AudioCodec: "aac", AudioChannels: 6, AudioLanguage: "jpn"}
+	for _, mdatFirst := range []bool{false, true} {
+		b := sampleMP4(mdatFirst)
+		got, err := ProbeReaderAt(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			t.Fatalf("不期望错误：%v", err)
+		}
+		if got != want {
+			t.Fatalf("mdatFirst=%v 解析结果不符合预期：\n got=%+v\nwant=%+v", mdatFirst, got, want)
+		}
+	}
+}
+
+func TestProbe_MKV(t *testing.T) {
+	want := domain.MediaInfo{Container: "mkv", DurationS: 5400.5, VideoCodec: "hevc", Width: 3840, Height: 2160, AudioCodec: "ac3", AudioChannels: 6, AudioLanguage: "jpn"}
+	for _, viaSeek := range []bool{false, true} {
+		b := sampleMKV(viaSeek)
+		got, err := ProbeReaderAt(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			t.Fatalf("不期望错误：%v", err)
+		}
+		if got != want {
+			t.Fatalf("viaSeek=%v 解析结果不符合预期：\n got=%+v\nwant=%+v", viaSeek, got, want)
+		}
+	}
+}
+
+func TestProbe_UnsupportedAndTruncated(t *testing.T) {
+	dir := t.TempDir()
+	srt := filepath.Join(dir, "ABC-123.srt")
+	if err := os.WriteFile(srt, []byte("1\n00:00:01,000 --> 00:00:02,000\nhi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Probe(srt); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("字幕文件应返回 ErrUnsupported，实际：%v", err)
+	}
+	if _, err := ProbeReaderAt(bytes.NewReader([]byte("tiny")), 4); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("过短文件应返回 ErrUnsupported，实际：%v", err)
+	}
+
+	b := sampleMP4(true)
+	cut := b[:len(b)-20] // moov 被截断
+	if _, err := ProbeReaderAt(bytes.NewReader(cut), int64(len(cut))); err == nil {
+		t.Fatalf("截断的 MP4 应报错")
+	}
+	noMoov := mp4Box("ftyp", []byte("isom"), u32(512))
+	if _, err := ProbeReaderAt(bytes.NewReader(noMoov), int64(len(noMoov))); err == nil {
+		t.Fatalf("缺少 moov 应报错")
+	}
+}
@@ -0,0 +1,352 @@
+package mediax
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// Matroska 元素 ID（保留长度标记位，与规范文档写法一致）。
+const (
+	idEBML          = 0x1A45DFA3
+	idDocType       = 0x4282
+	idSegment       = 0x18538067
+	idSeekHead      = 0x114D9B74
+	idSeek          = 0x4DBB
+	idSeekID        = 0x53AB
+	idSeekPosition  = 0x53AC
+	idInfo          = 0x1549A966
+	idTimecodeScale = 0x2AD7B1
+	idDuration      = 0x4489
+	idTracks        = 0x1654AE6B
+	idTrackEntry    = 0xAE
+	idTrackType     = 0x83
+	idCodecID       = 0x86
+	idLanguage      = 0x22B59C
+	idFlagDefault   = 0x88
+	idVideo         = 0xE0
+	idPixelWidth    = 0xB0
+	idPixelHeight   = 0xBA
+	idAudio         = 0xE1
+	idChannels      = 0x9F
+	idCluster       = 0x1F43B675
+)
+
+const (
+	mkvTrackVideo = 1
+	mkvTrackAudio = 2
+)
+
+// ebmlElem 是内存中已读出的一个元素（Data 为元素内容）。
+type ebmlElem struct {
+	ID   uint64
+	Data []byte
+}
+
+func probeMKV(r io.ReaderAt, size int64) (domain.MediaInfo, error) {
+	id, ds, hl, err := readHeader(r, 0, size)
+	if err != nil || id != idEBML || ds < 0 {
+		return domain.MediaInfo{}, ErrUnsupported
+	}
+	head, err := readAt(r, hl, ds, size)
+	if err != nil {
+		return domain.MediaInfo{}, err
+	}
+	switch docType := string(findElem(head, idDocType)); docType {
+	case "matroska", "webm":
+	default:
+		return domain.MediaInfo{}, ErrUnsupported
+	}
+
+	off := hl + ds
+	id, ds, hl, err = readHeader(r, off, size)
+	if err != nil {
+		return domain.MediaInfo{}, err
+	}
+	if id != idSegment {
+		return domain.MediaInfo{}, errors.New("Matroska 缺少 Segment")
+	}
+	segStart := off + hl
+	segEnd := size
+	if ds >= 0 && segStart+ds < size {
+		segEnd = segStart + ds
+	}
+
+	var info, tracks []byte
+	seek := map[uint64]int64{}
+	// 只遍历 Cluster 之前的顶层元素：Info/Tracks 通常在这里；否则按 SeekHead 跳转，不扫描媒体数据。
+	for off = segStart; off < segEnd && (info == nil || tracks == nil); {
+		id, ds, hl, err = readHeader(r, off, segEnd)
+		if err != nil || ds < 0 || id == idCluster {
+			break
+		}
+		switch id {
+		case idInfo, idTracks, idSeekHead:
+			b, err := readAt(r, off+hl, ds, size)
+			if err != nil {
+				return domain.MediaInfo{}, err
+			}
+			switch id {
+			case idInfo:
+				info = b
+			case idTracks:
+				tracks = b
+			default:
+				parseSeekHead(b, segStart, seek)
+			}
+		}
+		off += hl + ds
+	}
+	for _, want := range []struct {
+		id  uint64
+		dst *[]byte
+	}{{idInfo, &info}, {idTracks, &tracks}} {
+		pos, ok := seek[want.id]
+		if *want.dst != nil || !ok {
+			continue
+		}
+		id, ds, hl, err := readHeader(r, pos, size)
+		if err != nil || id != want.id || ds < 0 {
+			continue
+		}
+		if b, err := readAt(r, pos+hl, ds, size); err == nil {
+			*want.dst = b
+		}
+	}
+	if info == nil && tracks == nil {
+		return domain.MediaInfo{}, errors.New("Matroska 缺少 Info/Tracks")
+	}
+
+	mi := domain.MediaInfo{Container: "mkv", DurationS: parseMKVInfo(info)}
+	parseMKVTracks(tracks, &mi)
+	return mi, nil
+}
+
+// readHeader 读取 off 处的元素头：返回 ID、内容长度（未知长度为 -1）与头部长度。
+func readHeader(r io.ReaderAt, off, limit int64) (uint64, int64, int64, error) {
+	n := limit - off
+	if n > 12 {
+		n = 12
+	}
+	if n < 2 {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	if m, err := r.ReadAt(b, off); int64(m) < n {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, 0, err
+	}
+	id, in, ok := vint(b, true)
+	if !ok || in > 4 {
+		return 0, 0, 0, errors.New("EBML 元素 ID 不合法")
+	}
+	ds, sn, ok := vint(b[in:], false)
+	if !ok {
+		return 0, 0, 0, errors.New("EBML 元素长度不合法")
+	}
+	if ds == 1<<(7*sn)-1 {
+		return id, -1, int64(in + sn), nil
+	}
+	if ds > math.MaxInt64/2 {
+		return 0, 0, 0, errors.New("EBML 元素长度不合法")
+	}
+	return id, int64(ds), int64(in + sn), nil
+}
+
+// vint 解析 EBML 变长整数：首字节前导 0 的个数决定长度；keepMarker 为 true 时保留长度标记位（元素 ID 的写法）。
+func vint(b []byte, keepMarker bool) (uint64, int, bool) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0, false
+	}
+	n := bits.LeadingZeros8(b[0]) + 1
+	if len(b) < n {
+		return 0, 0, false
+	}
+	v := uint64(b[0])
+	if !keepMarker {
+		v &= 0xFF >> n
+	}
+	for i := 1; i < n; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, n, true
+}
+
+// ebmlChildren 解析内存中的子元素序列；遇到未知长度或越界时停止。
+func ebmlChildren(b []byte) []ebmlElem {
+	var out []ebmlElem
+	for len(b) > 0 {
+		id, in, ok := vint(b, true)
+		if !ok || in > 4 {
+			return out
+		}
+		ds, sn, ok := vint(b[in:], false)
+		if !ok || ds == 1<<(7*sn)-1 || ds > uint64(len(b)-in-sn) {
+			return out
+		}
+		start := in + sn
+		out = append(out, ebmlElem{ID: id, Data: b[start : start+int(ds)]})
+		b = b[start+int(ds):]
+	}
+	return out
+}
+
+func findElem(b []byte, id uint64) []byte {
+	for _, e := range ebmlChildren(b) {
+		if e.ID == id {
+			return e.Data
+		}
+	}
+	return nil
+}
+
+func ebmlUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func ebmlFloat(b []byte) float64 {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// parseSeekHead 把 SeekHead 中的条目记录为 元素ID -> 文件内绝对偏移。
+func parseSeekHead(b []byte, segStart int64, out map[uint64]int64) {
+	for _, e := range ebmlChildren(b) {
+		if e.ID != idSeek {
+			continue
+		}
+		id := ebmlUint(findElem(e.Data, idSeekID))
+		pos := findElem(e.Data, idSeekPosition)
+		if id == 0 || len(pos) == 0 {
+			continue
+		}
+		if _, ok := out[id]; !ok {
+			out[id] = segStart + int64(ebmlUint(pos))
+		}
+	}
+}
+
+// parseMKVInfo 返回时长（秒）：Duration 以 TimecodeScale（默认 1ms = 1e6 ns）为单位。
+func parseMKVInfo(b []byte) float64 {
+	scale := uint64(1000000)
+	var dur float64
+	for _, e := range ebmlChildren(b) {
+		switch e.ID {
+		case idTimecodeScale:
+			if v := ebmlUint(e.Data); v > 0 {
+				scale = v
+			}
+		case idDuration:
+			dur = ebmlFloat(e.Data)
+		}
+	}
+	if dur <= 0 || math.IsNaN(dur) || math.IsInf(dur, 0) {
+		return 0
+	}
+	return dur * float64(scale) / 1e9
+}
+
+// parseMKVTracks 取第一个视频轨，以及默认音频轨（没有默认标记时取第一个音频轨）。
+func parseMKVTracks(b []byte, mi *domain.MediaInfo) {
+	haveVideo, audioDefault := false, false
+	for _, e := range ebmlChildren(b) {
+		if e.ID != idTrackEntry {
+			continue
+		}
+		var typ uint64
+		var codec, lang string
+		isDefault := true // FlagDefault 缺省为 1
+		var video, audio []byte
+		for _, c := range ebmlChildren(e.Data) {
+			switch c.ID {
+			case idTrackType:
+				typ = ebmlUint(c.Data)
+			case idCodecID:
+				codec = strings.TrimRight(string(c.Data), "\x00")
+			case idLanguage:
+				lang = strings.TrimRight(string(c.Data), "\x00")
+			case idFlagDefault:
+				isDefault = ebmlUint(c.Data) != 0
+			case idVideo:
+				video = c.Data
+			case idAudio:
+				audio = c.Data
+			}
+		}
+		switch {
+		case typ == mkvTrackVideo && !haveVideo:
+			haveVideo = true
+			mi.VideoCodec = mkvCodec(codec)
+			mi.Width = int(ebmlUint(findElem(video, idPixelWidth)))
+			mi.Height = int(ebmlUint(findElem(video, idPixelHeight)))
+		case typ == mkvTrackAudio && !audioDefault && (mi.AudioCodec == "" || isDefault):
+			audioDefault = isDefault
+			mi.AudioCodec = mkvCodec(codec)
+			mi.AudioChannels = 1 // Channels 缺省为 1
+			if ch := findElem(audio, idChannels); len(ch) > 0 {
+				mi.AudioChannels = int(ebmlUint(ch))
+			}
+			mi.AudioLanguage = normLanguage(lang)
+		}
+	}
+}
+
+// mkvCodec 把 Matroska CodecID 映射为 Kodi 的 codec 名；未知 ID 去掉 V_/A_ 前缀后小写返回。
+func mkvCodec(id string) string {
+	switch {
+	case id == "V_MPEG4/ISO/AVC":
+		return "h264"
+	case id == "V_MPEGH/ISO/HEVC":
+		return "hevc"
+	case id == "V_AV1":
+		return "av1"
+	case id == "V_VP8":
+		return "vp8"
+	case id == "V_VP9":
+		return "vp9"
+	case strings.HasPrefix(id, "V_MPEG4/ISO/"):
+		return "mpeg4"
+	case id == "V_MPEG2":
+		return "mpeg2video"
+	case strings.HasPrefix(id, "A_AAC"):
+		return "aac"
+	case id == "A_AC3":
+		return "ac3"
+	case id == "A_EAC3":
+		return "eac3"
+	case strings.HasPrefix(id, "A_DTS"):
+		return "dts"
+	case id == "A_TRUEHD":
+		return "truehd"
+	case id == "A_FLAC":
+		return "flac"
+	case id == "A_OPUS":
+		return "opus"
+	case id == "A_VORBIS":
+		return "vorbis"
+	case id == "A_MPEG/L3":
+		return "mp3"
+	case id == "A_MPEG/L2":
+		return "mp2"
+	case strings.HasPrefix(id, "A_PCM"):
+		return "pcm"
+	}
+	id = strings.TrimPrefix(strings.TrimPrefix(id, "V_"), "A_")
+	return strings.ToLower(id)
+}
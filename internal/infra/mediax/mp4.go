@@ -0,0 +1,250 @@
+package mediax
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// box 是 ISO BMFF 的一个 box：Type 为四字符类型，Body 为去掉头部后的内容。
+type box struct {
+	Type string
+	Body []byte
+}
+
+// mp4Track 是从 trak 中解析出的单轨信息。
+type mp4Track struct {
+	handler  string // vide | soun
+	codec    string
+	width    int
+	height   int
+	channels int
+	language string
+}
+
+func probeMP4(r io.ReaderAt, size int64) (domain.MediaInfo, error) {
+	moov, err := findTopLevelMoov(r, size)
+	if err != nil {
+		return domain.MediaInfo{}, err
+	}
+
+	info := domain.MediaInfo{Container: "mp4"}
+	haveVideo, haveAudio := false, false
+	for _, b := range children(moov) {
+		switch b.Type {
+		case "mvhd":
+			info.DurationS = parseMvhd(b.Body)
+		case "trak":
+			t := parseTrak(b.Body)
+			switch {
+			case t.handler == "vide" && !haveVideo:
+				haveVideo = true
+				info.VideoCodec, info.Width, info.Height = t.codec, t.width, t.height
+			case t.handler == "soun" && !haveAudio:
+				haveAudio = true
+				info.AudioCodec, info.AudioChannels, info.AudioLanguage = t.codec, t.channels, t.language
+			}
+		}
+	}
+	return info, nil
+}
+
+// findTopLevelMoov 逐个读取顶层 box 头并跳过内容（mdat 可能在 moov 之前，也只读头部），返回 moov 的内容。
+func findTopLevelMoov(r io.ReaderAt, size int64) ([]byte, error) {
+	var hdr [16]byte
+	for off := int64(0); off+8 <= size; {
+		n := int64(8)
+		if _, err := r.ReadAt(hdr[:8], off); err != nil {
+			return nil, err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(hdr[:4]))
+		typ := string(hdr[4:8])
+		switch boxSize {
+		case 0: // 延伸到文件末尾
+			boxSize = size - off
+		case 1: // 64 位 largesize
+			if _, err := r.ReadAt(hdr[8:16], off+8); err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(hdr[8:16]))
+			n = 16
+		}
+		if boxSize < n || off+boxSize > size {
+			return nil, errors.New("MP4 box 长度不合法")
+		}
+		if typ == "moov" {
+			return readAt(r, off+n, boxSize-n, size)
+		}
+		off += boxSize
+	}
+	return nil, errors.New("MP4 缺少 moov")
+}
+
+// children 把内存中的 box 内容拆成子 box；遇到不合法长度时停止（尽量返回已解析部分）。
+func children(b []byte) []box {
+	var out []box
+	for len(b) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(b[:4]))
+		typ := string(b[4:8])
+		n := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(b))
+		case 1:
+			if len(b) < 16 {
+				return out
+			}
+			size = binary.BigEndian.Uint64(b[8:16])
+			n = 16
+		}
+		if size < n || size > uint64(len(b)) {
+			return out
+		}
+		out = append(out, box{Type: typ, Body: b[n:size]})
+		b = b[size:]
+	}
+	return out
+}
+
+func child(b []byte, typ string) []byte {
+	for _, c := range children(b) {
+		if c.Type == typ {
+			return c.Body
+		}
+	}
+	return nil
+}
+
+// parseMvhd 返回影片时长（秒）。version 0 为 32 位时间字段，version 1 为 64 位。
+func parseMvhd(b []byte) float64 {
+	if len(b) < 4 {
+		return 0
+	}
+	var timescale uint32
+	var duration uint64
+	if b[0] == 1 {
+		if len(b) < 32 {
+			return 0
+		}
+		timescale = binary.BigEndian.Uint32(b[20:24])
+		duration = binary.BigEndian.Uint64(b[24:32])
+	} else {
+		if len(b) < 20 {
+			return 0
+		}
+		timescale = binary.BigEndian.Uint32(b[12:16])
+		duration = uint64(binary.BigEndian.Uint32(b[16:20]))
+	}
+	// 全 1 表示时长未知（例如分片 MP4）。
+	if timescale == 0 || duration == 0xFFFFFFFF || duration == 0xFFFFFFFFFFFFFFFF {
+		return 0
+	}
+	return float64(duration) / float64(timescale)
+}
+
+func parseTrak(b []byte) mp4Track {
+	var t mp4Track
+	if tkhd := child(b, "tkhd"); len(tkhd) > 0 {
+		t.width, t.height = parseTkhdSize(tkhd)
+	}
+	mdia := child(b, "mdia")
+	if hdlr := child(mdia, "hdlr"); len(hdlr) >= 12 {
+		t.handler = string(hdlr[8:12])
+	}
+	if mdhd := child(mdia, "mdhd"); len(mdhd) > 0 {
+		t.language = parseMdhdLanguage(mdhd)
+	}
+	stsd := child(child(child(mdia, "minf"), "stbl"), "stsd")
+	if len(stsd) < 8 {
+		return t
+	}
+	entries := children(stsd[8:])
+	if len(entries) == 0 {
+		return t
+	}
+	e := entries[0]
+	t.codec = mp4Codec(e.Type)
+	switch t.handler {
+	case "vide":
+		// VisualSampleEntry：6 reserved + 2 data_reference_index + 16 pre_defined/reserved + width(2) + height(2)。
+		if len(e.Body) >= 28 {
+			if w, h := int(binary.BigEndian.Uint16(e.Body[24:26])), int(binary.BigEndian.Uint16(e.Body[26:28])); w > 0 && h > 0 {
+				t.width, t.height = w, h
+			}
+		}
+	case "soun":
+		// AudioSampleEntry：6 reserved + 2 data_reference_index + 8 reserved + channelcount(2)。
+		if len(e.Body) >= 18 {
+			t.channels = int(binary.BigEndian.Uint16(e.Body[16:18]))
+		}
+	}
+	return t
+}
+
+// parseTkhdSize 读取 tkhd 末尾的显示宽高（16.16 定点数）；作为 stsd 缺失时的兜底。
+func parseTkhdSize(b []byte) (int, int) {
+	if len(b) < 8 {
+		return 0, 0
+	}
+	tail := b[len(b)-8:]
+	return int(binary.BigEndian.Uint32(tail[:4]) >> 16), int(binary.BigEndian.Uint32(tail[4:]) >> 16)
+}
+
+// parseMdhdLanguage 解码 mdhd 的 ISO-639-2/T 语言码（3×5 位，每个字符 +0x60）。
+func parseMdhdLanguage(b []byte) string {
+	off := 20 // version 0：version/flags(4) + creation(4) + modification(4) + timescale(4) + duration(4)
+	if b[0] == 1 {
+		off = 32
+	}
+	if len(b) < off+2 {
+		return ""
+	}
+	v := binary.BigEndian.Uint16(b[off : off+2])
+	lang := string([]byte{byte(v>>10&0x1F) + 0x60, byte(v>>5&0x1F) + 0x60, byte(v&0x1F) + 0x60})
+	return normLanguage(lang)
+}
+
+// mp4Codec 把 sample entry 类型映射为 Kodi 的 codec 名；未知类型原样小写返回。
+func mp4Codec(typ string) string {
+	switch typ {
+	case "avc1", "avc3":
+		return "h264"
+	case "hvc1", "hev1":
+		return "hevc"
+	case "av01":
+		return "av1"
+	case "vp08":
+		return "vp8"
+	case "vp09":
+		return "vp9"
+	case "mp4v":
+		return "mpeg4"
+	case "mp4a":
+		return "aac"
+	case "ac-3":
+		return "ac3"
+	case "ec-3":
+		return "eac3"
+	case "Opus":
+		return "opus"
+	case "fLaC":
+		return "flac"
+	case ".mp3":
+		return "mp3"
+	case "alac":
+		return "alac"
+	}
+	return strings.ToLower(strings.TrimSpace(typ))
+}
+
+// normLanguage 规范化语言码：小写，未标注（und/空）返回空串。
+func normLanguage(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "und" || s == "" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,325 @@
+package nfo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// movieIn 是读取用的 NFO 结构：兼容本工具各 profile 的输出与常见第三方刮削器的写法。
+type movieIn struct {
+	XMLName xml.Name
+
+	Title         string     `xml:"title"`
+	OriginalTitle string     `xml:"originaltitle"`
+	Num           []string   `xml:"num"`
+	ID            string     `xml:"id"`
+	UniqueIDs     []uniqueID `xml:"uniqueid"`
+	Outline       string     `xml:"outline"`
+	Plot          string     `xml:"plot"`
+
+	Studio    string   `xml:"studio"`
+	Maker     string   `xml:"maker"`
+	Label     string   `xml:"label"`
+	Publisher string   `xml:"publisher"`
+	Director  []string `xml:"director"`
+	Set       setIn    `xml:"set"`
+
+	Premiered   string `xml:"premiered"`
+	Release     string `xml:"release"`
+	ReleaseDate string `xml:"releasedate"`
+	Aired       string `xml:"aired"`
+	Year        string `xml:"year"`
+	Runtime     string `xml:"runtime"`
+
+	Thumbs []art   `xml:"thumb"`
+	Fanart fanart  `xml:"fanart"`
+	Cover  string  `xml:"cover"`
+	Rating string  `xml:"rating"`
+	Votes  string  `xml:"votes"`
+	Rates  ratings `xml:"ratings"`
+
+	Actors []actor  `xml:"actor"`
+	Tags   []string `xml:"tag"`
+	Genres []string `xml:"genre"`
+
+	Website string `xml:"website"`
+	Trailer string `xml:"trailer"`
+}
+
+// setIn 兼容 <set>系列</set> 与 Kodi v18+ 的 <set><name>系列</name></set>。
+type setIn struct {
+	Text string `xml:",chardata"`
+	Name string `xml:"name"`
+}
+
+// Problem 是 Validate 发现的一个结构问题；Field 为元素名（文档级问题为空）。
+type Problem struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+// dateFields 是可能承载发行日期的元素（按优先级）。
+var dateFields = []string{"premiered", "release", "releasedate", "aired"}
+
+func parseMovie(b []byte) (movieIn, error) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return movieIn{}, errors.New("NFO 为空")
+	}
+	var in movieIn
+	if err := xml.Unmarshal(b, &in); err != nil {
+		return movieIn{}, fmt.Errorf("NFO 不是合法 XML：%w", err)
+	}
+	if in.XMLName.Local != "movie" {
+		return movieIn{}, fmt.Errorf("NFO 根元素应为 <movie>，实际 <%s>", in.XMLName.Local)
+	}
+	return in, nil
+}
+
+// Decode 把 NFO 读回 MovieMeta（宽松：数字/日期解析失败时按缺失处理；需要严格检查时用 Validate）。
+//
+// 与 EncodeWithOptions 互逆的约定：
+//   - title 去掉编码时追加的 "CODE " 前缀（等于 CODE 时视为空标题）
+//   - genre/tag 中与演员、片商、系列同名的条目视为 tags.Compose 按策略追加的，予以剔除
+//   - NFO 不承载的字段保持零值：FanartURL（仅第三方写远程 fanart 时有值）、SampleURLs、Actor.URL
+func Decode(b []byte) (domain.MovieMeta, error) {
+	in, err := parseMovie(b)
+	if err != nil {
+		return domain.MovieMeta{}, err
+	}
+
+	code := in.code()
+	meta := domain.MovieMeta{
+		Code:          code,
+		OriginalTitle: strings.TrimSpace(in.OriginalTitle),
+		Plot:          strings.TrimSpace(in.Plot),
+		Studio:        strings.TrimSpace(in.Studio),
+		Maker:         strings.TrimSpace(in.Maker),
+		Label:         firstNonEmpty(in.Label, in.Publisher),
+		Series:        firstNonEmpty(in.Set.Name, in.Set.Text),
+		Release:       in.release(),
+		Website:       strings.TrimSpace(in.Website),
+		CoverURL:      strings.TrimSpace(in.Cover),
+		TrailerURL:    strings.TrimSpace(in.Trailer),
+	}
+	if len(in.Director) > 0 {
+		meta.Director = strings.TrimSpace(in.Director[0])
+	}
+	if meta.Plot == "" {
+		meta.Plot = strings.TrimSpace(in.Outline)
+	}
+
+	title := strings.TrimSpace(in.Title)
+	if code != "" {
+		if title == string(code) {
+			title = ""
+		} else {
+			title = strings.TrimPrefix(title, string(code)+" ")
+		}
+	}
+	meta.Title = title
+
+	meta.Year, _ = strconv.Atoi(strings.TrimSpace(in.Year))
+	if meta.Year == 0 && len(meta.Release) >= 4 {
+		meta.Year, _ = strconv.Atoi(meta.Release[:4])
+	}
+	meta.RuntimeM = leadingInt(in.Runtime)
+	meta.Rating, meta.Votes = in.rating()
+
+	// 第三方刮削器常把远程图片写在 <thumb>/<fanart>；本工具写的是本地文件名，不是 URL。
+	if meta.CoverURL == "" {
+		for _, t := range in.Thumbs {
+			if isURL(t.Path) {
+				meta.CoverURL = strings.TrimSpace(t.Path)
+				break
+			}
+		}
+	}
+	if isURL(in.Fanart.Path) {
+		meta.FanartURL = strings.TrimSpace(in.Fanart.Path)
+	}
+	for _, t := range in.Fanart.Thumbs {
+		if meta.FanartURL == "" && isURL(t.Path) {
+			meta.FanartURL = strings.TrimSpace(t.Path)
+		}
+	}
+
+	actorNames := make(map[string]struct{}, len(in.Actors))
+	for _, a := range in.Actors {
+		name := strings.TrimSpace(a.Name)
+		if name == "" {
+			continue
+		}
+		if _, ok := actorNames[name]; ok {
+			continue
+		}
+		actorNames[name] = struct{}{}
+		meta.Actors = append(meta.Actors, domain.Actor{Name: name, Thumb: strings.TrimSpace(a.Thumb)})
+	}
+	appended := actorNames
+	for _, s := range []string{meta.Studio, meta.Series} {
+		if s != "" {
+			appended[s] = struct{}{}
+		}
+	}
+	meta.Genres = withoutNames(in.Genres, appended)
+	meta.Tags = withoutNames(in.Tags, appended)
+	return meta, nil
+}
+
+// Validate 检查 NFO 的结构问题（没有问题时返回 nil）：
+// XML/根元素、<num> 缺失或不是合法 CODE、<title> 缺失、日期/年份/时长/评分格式不合法、演员缺少名字。
+func Validate(b []byte) []Problem {
+	in, err := parseMovie(b)
+	if err != nil {
+		return []Problem{{Msg: err.Error()}}
+	}
+
+	var ps []Problem
+	add := func(field, format string, args ...any) {
+		ps = append(ps, Problem{Field: field, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case len(in.Num) == 0 || strings.TrimSpace(in.Num[0]) == "":
+		add("num", "缺少 <num>（媒体库与 audit 依赖它识别 CODE）")
+	case len(in.Num) > 1:
+		add("num", "<num> 出现了 %d 次", len(in.Num))
+	default:
+		if _, ok := domain.ParseCode(strings.TrimSpace(in.Num[0])); !ok {
+			add("num", "<num>=%q 不是合法 CODE", strings.TrimSpace(in.Num[0]))
+		}
+	}
+	if strings.TrimSpace(in.Title) == "" {
+		add("title", "缺少 <title>")
+	}
+
+	dates := map[string]string{"premiered": in.Premiered, "release": in.Release, "releasedate": in.ReleaseDate, "aired": in.Aired}
+	for _, f := range dateFields {
+		if v := strings.TrimSpace(dates[f]); v != "" {
+			if _, err := time.Parse("2006-01-02", v); err != nil {
+				add(f, "<%s>=%q 不是 YYYY-MM-DD 日期", f, v)
+			}
+		}
+	}
+	if v := strings.TrimSpace(in.Year); v != "" {
+		y, err := strconv.Atoi(v)
+		switch {
+		case err != nil || y < 1900 || y > 2100:
+			add("year", "<year>=%q 不是合法年份", v)
+		case len(in.release()) >= 4 && in.release()[:4] != v:
+			add("year", "<year>=%s 与发行日期 %s 不一致", v, in.release())
+		}
+	}
+	if v := strings.TrimSpace(in.Runtime); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 0 {
+			add("runtime", "<runtime>=%q 不是非负整数（分钟）", v)
+		}
+	}
+	if v := strings.TrimSpace(in.Rating); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err != nil || f < 0 || f > 10 {
+			add("rating", "<rating>=%q 不在 0~10", v)
+		}
+	}
+	if v := strings.TrimSpace(in.Votes); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 0 {
+			add("votes", "<votes>=%q 不是非负整数", v)
+		}
+	}
+	for i, a := range in.Actors {
+		if strings.TrimSpace(a.Name) == "" {
+			add("actor", "第 %d 个 <actor> 缺少 <name>", i+1)
+		}
+	}
+	return ps
+}
+
+// code 依次取 <num>、<uniqueid type="num">、<id>。
+func (in movieIn) code() domain.Code {
+	raw := ""
+	if len(in.Num) > 0 {
+		raw = strings.TrimSpace(in.Num[0])
+	}
+	for _, u := range in.UniqueIDs {
+		if raw == "" && strings.EqualFold(u.Type, "num") {
+			raw = strings.TrimSpace(u.Value)
+		}
+	}
+	if raw == "" {
+		raw = strings.TrimSpace(in.ID)
+	}
+	return domain.Code(strings.ToUpper(raw))
+}
+
+func (in movieIn) release() string {
+	return firstNonEmpty(in.Premiered, in.Release, in.ReleaseDate, in.Aired)
+}
+
+// rating 优先取 <ratings> 中 default 的评分（按 max 换算为 0~10），其次扁平的 <rating>/<votes>。
+func (in movieIn) rating() (float64, int) {
+	rs := in.Rates.Rating
+	for i := range rs {
+		if rs[i].Default || i == len(rs)-1 {
+			r := rs[i]
+			v := r.Value
+			if r.Max > 0 && r.Max != 10 {
+				v = v * 10 / float64(r.Max)
+			}
+			return v, r.Votes
+		}
+	}
+	v, _ := strconv.ParseFloat(strings.TrimSpace(in.Rating), 64)
+	n, _ := strconv.Atoi(strings.TrimSpace(in.Votes))
+	return v, n
+}
+
+func withoutNames(in []string, names map[string]struct{}) []string {
+	var out []string
+	seen := make(map[string]struct{}, len(in))
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if _, ok := names[s]; ok {
+			continue
+		}
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	return out
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// leadingInt 解析开头的整数（兼容 "120 min" 之类的第三方写法）。
+func leadingInt(s string) int {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+func isURL(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
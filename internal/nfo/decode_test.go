@@ -0,0 +1,160 @@
+package nfo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func fullMeta() domain.MovieMeta {
+	code, _ := domain.ParseCode("JUR-566")
+	return domain.MovieMeta{
+		Code:          code,
+		Title:         "译文标题",
+		OriginalTitle: "原文タイトル",
+		Plot:          "简介",
+		Studio:        "Madonna",
+		Maker:         "マドンナ",
+		Label:         "Madonna Label",
+		Director:      "导演",
+		Series:        "系列",
+		Release:       "2025-01-02",
+		Year:          2025,
+		RuntimeM:      120,
+		Rating:        8.6,
+		Votes:         783,
+		Actors:        []domain.Actor{{Name: "A", Thumb: ".actors/A.jpg", URL: "https://javdb.com/actors/a"}, {Name: "B", Thumb: "https://img.test/b.jpg"}},
+		Genres:        []string{"剧情", "熟女"},
+		Tags:          []string{"独占"},
+		Website:       "https://javdb.com/v/z4Pxwb",
+		CoverURL:      "https://img.test/cover.jpg",
+		FanartURL:     "https://img.test/fanart.jpg",
+		TrailerURL:    "https://img.test/t.mp4",
+		SampleURLs:    []string{"https://img.test/s1.jpg", "https://img.test/s2.jpg"},
+	}
+}
+
+func TestDecode_RoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		opt  Options
+		// Kodi 不写 <cover>，封面 URL 不可还原。
+		noCover bool
+	}{
+		{name: "default"},
+		{name: "jellyfin", opt: Options{Profile: ProfileJellyfin, Provider: "javdb", LockData: true}},
+		{name: "kodi", opt: Options{Profile: ProfileKodi, Provider: "javdb"}, noCover: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			want := fullMeta()
+			b, err := EncodeWithOptions(want, tc.opt)
+			if err != nil {
+				t.Fatalf("不期望错误：%v", err)
+			}
+			got, err := Decode(b)
+			if err != nil {
+				t.Fatalf("Decode 失败：%v", err)
+			}
+			if tc.noCover {
+				want.CoverURL = ""
+			}
+			// NFO 的 <fanart>/extrafanart 引用的是已下载的本地文件，<actor> 只写头像：
+			// 远程 fanart、样品图与演员页 URL 不进入 NFO，读回为零值。
+			want.FanartURL, want.SampleURLs = "", nil
+			for i := range want.Actors {
+				want.Actors[i].URL = ""
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("round-trip 不一致：\n got=%+v\nwant=%+v", got, want)
+			}
+			if ps := Validate(b); len(ps) != 0 {
+				t.Fatalf("自身输出不应有结构问题：%+v", ps)
+			}
+		})
+	}
+
+	// 空标题编码为 CODE，读回仍为空。
+	code, _ := domain.ParseCode("ABC-123")
+	b, _ := Encode(domain.MovieMeta{Code: code})
+	got, err := Decode(b)
+	if err != nil || got.Code != code || got.Title != "" {
+		t.Fatalf("空标题 round-trip 不符合预期：%+v err=%v", got, err)
+	}
+}
+
+func TestDecode_ThirdParty(t *testing.T) {
+	b := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<movie>
+  <title>abc-123 Some Title</title>
+  <outline>Outline text</outline>
+  <id>abc-123</id>
+  <set><name>Series X</name></set>
+  <publisher>Pub</publisher>
+  <releasedate>2021-05-06</releasedate>
+  <runtime>95 min</runtime>
+  <director>D1</director>
+  <director>D2</director>
+  <ratings><rating name="javdb" max="5" default="true"><value>4.2</value><votes>10</votes></rating></ratings>
+  <thumb>https://img.test/poster.jpg</thumb>
+  <fanart><thumb>https://img.test/fanart.jpg</thumb></fanart>
+  <actor><name>A</name></actor>
+  <actor><name></name></actor>
+  <genre>A</genre>
+  <genre>G</genre>
+  <tag>Series X</tag>
+</movie>`)
+	got, err := Decode(b)
+	if err != nil {
+		t.Fatalf("Decode 失败：%v", err)
+	}
+	if got.Code != "ABC-123" || got.Title != "abc-123 Some Title" || got.Plot != "Outline text" || got.Series != "Series X" || got.Label != "Pub" {
+		t.Fatalf("基本字段不符合预期：%+v", got)
+	}
+	if got.Release != "2021-05-06" || got.Year != 2021 || got.RuntimeM != 95 || got.Director != "D1" || got.Rating != 8.4 || got.Votes != 10 {
+		t.Fatalf("日期/时长/评分不符合预期：%+v", got)
+	}
+	if got.CoverURL != "https://img.test/poster.jpg" || got.FanartURL != "https://img.test/fanart.jpg" {
+		t.Fatalf("远程图片不符合预期：%+v", got)
+	}
+	if len(got.Actors) != 1 || !reflect.DeepEqual(got.Genres, []string{"G"}) || got.Tags != nil {
+		t.Fatalf("演员/genre/tag 不符合预期：%+v", got)
+	}
+
+	for _, bad := range []string{"", "<movie>", "<tvshow><num>ABC-123</num></tvshow>"} {
+		if _, err := Decode([]byte(bad)); err == nil {
+			t.Fatalf("期望 %q 解析失败", bad)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	b := []byte(`<movie>
+  <num>abc123</num>
+  <premiered>2021/05/06</premiered>
+  <year>21</year>
+  <runtime>95 min</runtime>
+  <rating>11</rating>
+  <votes>-1</votes>
+  <actor><role>x</role></actor>
+</movie>`)
+	fields := map[string]bool{}
+	for _, p := range Validate(b) {
+		fields[p.Field] = true
+	}
+	for _, f := range []string{"num", "title", "premiered", "year", "runtime", "rating", "votes", "actor"} {
+		if !fields[f] {
+			t.Fatalf("期望报告 %s 的问题：%+v", f, Validate(b))
+		}
+	}
+
+	ps := Validate([]byte(`<movie><title>T</title><release>2020-01-01</release><year>2021</year></movie>`))
+	if len(ps) != 2 || ps[0].Field != "num" || !strings.Contains(ps[1].Msg, "不一致") {
+		t.Fatalf("缺少 num 与年份不一致应各报一次：%+v", ps)
+	}
+	if ps := Validate([]byte("not xml")); len(ps) != 1 || ps[0].Field != "" {
+		t.Fatalf("非 XML 应报告一个文档级问题：%+v", ps)
+	}
+}
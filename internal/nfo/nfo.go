@@ -2,26 +2,68 @@ package nfo
 
 import (
 	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
 
 	"github.com/John-Robertt/AVMC/internal/domain"
+	"github.com/John-Robertt/AVMC/internal/tags"
 )
 
 const (
-	// DefaultCountry / DefaultMPAA 不对外暴露配置；保持最小但够用。
+	// DefaultCountry / DefaultMPAA 是未配置 nfo.country / nfo.mpaa 时的取值。
 	DefaultCountry = "JP"
 	DefaultMPAA    = "R18+"
 )
 
+// NFO profile：不同媒体库读取 NFO 的习惯略有差异（空串为默认，与旧版本输出一致）。
+const (
+	ProfileDefault  = ""
+	ProfileKodi     = "kodi"     // <thumb aspect="poster">、<fanart><thumb>、<ratings> 块；不写非标准的 <release>/<poster>/<cover>
+	ProfileJellyfin = "jellyfin" // 默认结构；默认写 <uniqueid>
+	ProfileEmby     = "emby"     // 同 jellyfin
+	ProfilePlex     = "plex"     // 默认结构（XBMCnfoMoviesImporter 读取扁平的 <rating>）
+)
+
+// ValidProfile 判断 s 是否为合法的 profile。
+func ValidProfile(s string) bool {
+	switch s {
+	case ProfileDefault, ProfileKodi, ProfileJellyfin, ProfileEmby, ProfilePlex:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultUniqueIDs 返回 profile 默认是否写 <uniqueid>（Options.UniqueIDs 为 nil 时使用）。
+func DefaultUniqueIDs(profile string) bool {
+	switch profile {
+	case ProfileKodi, ProfileJellyfin, ProfileEmby:
+		return true
+	default:
+		return false
+	}
+}
+
 type movie struct {
 	XMLName xml.Name `xml:"movie"`
 
-	Title     string `xml:"title"`
-	SortTitle string `xml:"sorttitle"`
-	Num       string `xml:"num"`
+	Title         string     `xml:"title"`
+	OriginalTitle string     `xml:"originaltitle,omitempty"`
+	SortTitle     string     `xml:"sorttitle"`
+	Num           string     `xml:"num"`
+	Outline       string     `xml:"outline,omitempty"`
+	Plot          string     `xml:"plot,omitempty"`
+	UniqueIDs     []uniqueID `xml:"uniqueid,omitempty"`
 
-	Studio string `xml:"studio,omitempty"`
-	Set    string `xml:"set,omitempty"`
+	Studio   string `xml:"studio,omitempty"`
+	Maker    string `xml:"maker,omitempty"`
+	Label    string `xml:"label,omitempty"`
+	Director string `xml:"director,omitempty"`
+	Set      string `xml:"set,omitempty"`
 
 	Release   string `xml:"release,omitempty"`
 	Premiered string `xml:"premiered,omitempty"`
@@ -31,34 +73,132 @@ type movie struct {
 	MPAA    string `xml:"mpaa,omitempty"`
 	Country string `xml:"country,omitempty"`
 
-	Poster string `xml:"poster,omitempty"`
-	Thumb  string `xml:"thumb,omitempty"`
-	Fanart string `xml:"fanart,omitempty"`
+	Poster string  `xml:"poster,omitempty"`
+	Thumb  *art    `xml:"thumb,omitempty"`
+	Fanart *fanart `xml:"fanart,omitempty"`
 
-	Rating     int `xml:"rating"`
-	UserRating int `xml:"userrating"`
-	Votes      int `xml:"votes"`
+	// 默认结构写扁平的 rating/userrating/votes（始终输出）；kodi 写 <ratings> 块，扁平字段为 nil 即省略。
+	Ratings    *ratings `xml:"ratings,omitempty"`
+	Rating     *float64 `xml:"rating"`
+	UserRating *int     `xml:"userrating"`
+	Votes      *int     `xml:"votes"`
 
 	Actors []actor  `xml:"actor,omitempty"`
 	Tags   []string `xml:"tag,omitempty"`
 	Genres []string `xml:"genre,omitempty"`
 
-	Cover   string `xml:"cover,omitempty"`
-	Website string `xml:"website,omitempty"`
+	Cover    string `xml:"cover,omitempty"`
+	Website  string `xml:"website,omitempty"`
+	Trailer  string `xml:"trailer,omitempty"`
+	LockData bool   `xml:"lockdata,omitempty"`
+
+	FileInfo *fileInfo `xml:"fileinfo,omitempty"`
 }
 
 type actor struct {
-	Name string `xml:"name"`
-	Role string `xml:"role,omitempty"`
+	Name  string `xml:"name"`
+	Role  string `xml:"role,omitempty"`
+	Thumb string `xml:"thumb,omitempty"`
+}
+
+type art struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	Path   string `xml:",chardata"`
+}
+
+type fanart struct {
+	Path   string `xml:",chardata"`
+	Thumbs []art  `xml:"thumb,omitempty"`
+}
+
+type uniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+// fileInfo 是 Kodi 的 <fileinfo><streamdetails>（媒体库在自身扫描完成前即可展示分辨率/编码）。
+type fileInfo struct {
+	Video *videoStream `xml:"streamdetails>video,omitempty"`
+	Audio *audioStream `xml:"streamdetails>audio,omitempty"`
+}
+
+type videoStream struct {
+	Codec    string `xml:"codec,omitempty"`
+	Aspect   string `xml:"aspect,omitempty"`
+	Width    int    `xml:"width,omitempty"`
+	Height   int    `xml:"height,omitempty"`
+	Duration int    `xml:"durationinseconds,omitempty"`
 }
 
-// Encode 把 MovieMeta 转成 Kodi/Jellyfin/Emby 可读取的 NFO（XML）。
+type audioStream struct {
+	Codec    string `xml:"codec,omitempty"`
+	Language string `xml:"language,omitempty"`
+	Channels int    `xml:"channels,omitempty"`
+}
+
+type ratings struct {
+	Rating []namedRating `xml:"rating"`
+}
+
+type namedRating struct {
+	Name    string  `xml:"name,attr"`
+	Max     int     `xml:"max,attr"`
+	Default bool    `xml:"default,attr"`
+	Value   float64 `xml:"value"`
+	Votes   int     `xml:"votes"`
+}
+
+// Options 控制 NFO 的组成。零值与旧版本输出一致（默认结构、默认 genre/tag 策略）。
+type Options struct {
+	Tags tags.Options
+	// Markers 是文件名 marker 的标签（例如“中文字幕”），按 Tags.Policy.Markers 写入。
+	Markers []string
+
+	// Profile 见 Profile* 常量。
+	Profile   string
+	Templates Templates
+	// MPAA / Country 为 nil 时使用 DefaultMPAA / DefaultCountry；指向空串表示不输出该元素。
+	MPAA    *string
+	Country *string
+	// UniqueIDs 为 nil 时按 DefaultUniqueIDs(Profile)；为 true 时写 <uniqueid type="<Provider>">。
+	UniqueIDs *bool
+	// Provider 是元数据来源（uniqueid 与 kodi ratings 的 type/name）；为空时不写 uniqueid。
+	Provider string
+	// LockData 为 true 时写 <lockdata>true</lockdata>（Jellyfin/Emby 不再自动刷新该条目的元数据）。
+	LockData bool
+
+	// Media 是视频文件头部解析出的流信息；非 nil 时写 <fileinfo><streamdetails>，
+	// 且 meta.RuntimeM 为 0 时用它的时长补齐 <runtime>。
+	Media *domain.MediaInfo
+	// FileInfo 为 nil 时等价于 true；为 false 时忽略 Media（不写 fileinfo、不补 runtime）。
+	FileInfo *bool
+}
+
+// Encode 以默认选项生成 NFO（见 EncodeWithOptions）。
+func Encode(meta domain.MovieMeta) ([]byte, error) {
+	return EncodeWithOptions(meta, Options{})
+}
+
+// EncodeWithOptions 把 MovieMeta 转成 Kodi/Jellyfin/Emby/Plex 可读取的 NFO（XML）。
 //
 // 规则：
 // - 字段缺失允许为空；但输出结构尽量稳定（去空白、去重、保持输入顺序）
-// - title 为空时回退到 CODE（避免生成空 title）
-func Encode(meta domain.MovieMeta) ([]byte, error) {
+// - title 为空时回退到 CODE（避免生成空 title）；模板展开为空时同样回退
+// - genre/tag 由 tags.Compose 按映射字典与来源策略计算
+func EncodeWithOptions(meta domain.MovieMeta, opt Options) ([]byte, error) {
+	if !ValidProfile(opt.Profile) {
+		return nil, fmt.Errorf("未知的 NFO profile：%q", opt.Profile)
+	}
 	code := strings.TrimSpace(string(meta.Code))
+	vals := templateValues(meta)
+	expand := func(tpl, def string) (string, error) {
+		if tpl == "" {
+			return def, nil
+		}
+		return render(tpl, vals)
+	}
+
 	title := strings.TrimSpace(meta.Title)
 	if title == "" {
 		title = code
@@ -66,44 +206,113 @@ func Encode(meta domain.MovieMeta) ([]byte, error) {
 		// 约定：title 以 CODE 开头（更利于媒体库识别与展示）。
 		title = code + " " + title
 	}
+	title, err := expand(opt.Templates.Title, title)
+	if err != nil {
+		return nil, err
+	}
+	if title == "" {
+		title = code
+	}
+	sortTitle, err := expand(opt.Templates.SortTitle, code)
+	if err != nil {
+		return nil, err
+	}
+	originalTitle, err := expand(opt.Templates.OriginalTitle, strings.TrimSpace(meta.OriginalTitle))
+	if err != nil {
+		return nil, err
+	}
+	outline, err := expand(opt.Templates.Outline, "")
+	if err != nil {
+		return nil, err
+	}
+
+	orDefault := func(v *string, def string) string {
+		if v == nil {
+			return def
+		}
+		return strings.TrimSpace(*v)
+	}
 
 	m := movie{
-		Title:     title,
-		SortTitle: code,
-		Num:       code,
+		Title:         title,
+		OriginalTitle: originalTitle,
+		SortTitle:     sortTitle,
+		Num:           code,
+		Outline:       outline,
+		Plot:          strings.TrimSpace(meta.Plot),
 
-		Studio: strings.TrimSpace(meta.Studio),
-		Set:    strings.TrimSpace(meta.Series),
+		Studio:   strings.TrimSpace(meta.Studio),
+		Maker:    strings.TrimSpace(meta.Maker),
+		Label:    strings.TrimSpace(meta.Label),
+		Director: strings.TrimSpace(meta.Director),
+		Set:      strings.TrimSpace(meta.Series),
 
 		Release:   strings.TrimSpace(meta.Release),
 		Premiered: strings.TrimSpace(meta.Release),
 		Year:      meta.Year,
 		Runtime:   meta.RuntimeM,
 
-		MPAA:    DefaultMPAA,
-		Country: DefaultCountry,
+		MPAA:    orDefault(opt.MPAA, DefaultMPAA),
+		Country: orDefault(opt.Country, DefaultCountry),
 
 		Poster: "poster.jpg",
-		Thumb:  "poster.jpg",
-		Fanart: "fanart.jpg",
+		Thumb:  &art{Path: "poster.jpg"},
+		Fanart: &fanart{Path: "fanart.jpg"},
+
+		Cover:    strings.TrimSpace(meta.CoverURL),
+		Website:  strings.TrimSpace(meta.Website),
+		Trailer:  strings.TrimSpace(meta.TrailerURL),
+		LockData: opt.LockData,
+	}
 
-		Rating:     0,
-		UserRating: 0,
-		Votes:      0,
+	provider := strings.ToLower(strings.TrimSpace(opt.Provider))
+	uniqueIDs := DefaultUniqueIDs(opt.Profile)
+	if opt.UniqueIDs != nil {
+		uniqueIDs = *opt.UniqueIDs
+	}
+	if uniqueIDs && provider != "" {
+		m.UniqueIDs = []uniqueID{{Type: provider, Default: true, Value: providerID(meta)}}
+	}
 
-		Tags:   normList(append(meta.Tags, meta.Actors...)),
-		Genres: normList(append(meta.Genres, meta.Actors...)),
+	// rating 为 0~10 分的站点评分；userrating 属于用户本人，始终为 0。
+	if opt.Profile == ProfileKodi {
+		// Kodi 的 art 与 ratings 约定；<release>/<poster>/<cover> 不是 Kodi 元素。
+		m.Release, m.Poster, m.Cover = "", "", ""
+		m.Thumb = &art{Aspect: "poster", Path: "poster.jpg"}
+		m.Fanart = &fanart{Thumbs: []art{{Path: "fanart.jpg"}}}
+		if meta.Rating > 0 {
+			name := provider
+			if name == "" {
+				name = "default"
+			}
+			m.Ratings = &ratings{Rating: []namedRating{{Name: name, Max: 10, Default: true, Value: meta.Rating, Votes: meta.Votes}}}
+		}
+	} else {
+		rating, userRating, votes := meta.Rating, 0, meta.Votes
+		m.Rating, m.UserRating, m.Votes = &rating, &userRating, &votes
+	}
 
-		Cover:   strings.TrimSpace(meta.CoverURL),
-		Website: strings.TrimSpace(meta.Website),
+	if opt.Media != nil && (opt.FileInfo == nil || *opt.FileInfo) {
+		if m.Runtime == 0 {
+			m.Runtime = int(math.Round(opt.Media.DurationS / 60))
+		}
+		m.FileInfo = newFileInfo(*opt.Media)
 	}
 
-	actors := normList(meta.Actors)
-	if len(actors) > 0 {
-		m.Actors = make([]actor, 0, len(actors))
-		for _, a := range actors {
-			m.Actors = append(m.Actors, actor{Name: a, Role: a})
+	m.Genres, m.Tags = tags.Compose(meta, opt.Markers, opt.Tags)
+
+	// 演员去空白、按名字去重；thumb 可能是远程 URL 或已下载的本地头像路径。
+	seen := make(map[string]struct{}, len(meta.Actors))
+	for _, a := range meta.Actors {
+		name := strings.TrimSpace(a.Name)
+		if name == "" {
+			continue
 		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		m.Actors = append(m.Actors, actor{Name: name, Role: name, Thumb: strings.TrimSpace(a.Thumb)})
 	}
 
 	b, err := xml.MarshalIndent(m, "", "  ")
@@ -115,25 +324,30 @@ func Encode(meta domain.MovieMeta) ([]byte, error) {
 	return append([]byte(header), b...), nil
 }
 
-func normList(in []string) []string {
-	if len(in) == 0 {
-		return nil
-	}
-	m := make(map[string]struct{}, len(in))
-	out := make([]string, 0, len(in))
-	for _, s := range in {
-		s = strings.TrimSpace(s)
-		if s == "" {
-			continue
+// newFileInfo 把 MediaInfo 转成 streamdetails；没有任何视频/音频信息时返回 nil（不写空的 <fileinfo>）。
+func newFileInfo(mi domain.MediaInfo) *fileInfo {
+	fi := &fileInfo{}
+	if mi.VideoCodec != "" || mi.Width > 0 {
+		fi.Video = &videoStream{Codec: mi.VideoCodec, Width: mi.Width, Height: mi.Height, Duration: int(math.Round(mi.DurationS))}
+		if mi.Width > 0 && mi.Height > 0 {
+			fi.Video.Aspect = strconv.FormatFloat(float64(mi.Width)/float64(mi.Height), 'f', 2, 64)
 		}
-		if _, ok := m[s]; ok {
-			continue
-		}
-		m[s] = struct{}{}
-		out = append(out, s)
 	}
-	if len(out) == 0 {
+	if mi.AudioCodec != "" {
+		fi.Audio = &audioStream{Codec: mi.AudioCodec, Language: mi.AudioLanguage, Channels: mi.AudioChannels}
+	}
+	if fi.Video == nil && fi.Audio == nil {
 		return nil
 	}
-	return out
+	return fi
+}
+
+// providerID 返回 provider 的作品 ID：详情页 URL 的最后一段（JavBus 为 CODE，JavDB 为 /v/<id>），取不到时回退 CODE。
+func providerID(meta domain.MovieMeta) string {
+	if u, err := url.Parse(strings.TrimSpace(meta.Website)); err == nil {
+		if id := path.Base(strings.TrimRight(u.Path, "/")); id != "." && id != "/" && id != "" {
+			return id
+		}
+	}
+	return strings.TrimSpace(string(meta.Code))
 }
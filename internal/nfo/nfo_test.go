@@ -2,6 +2,7 @@ package nfo
 
 import (
 	"encoding/xml"
+	"strings"
 	"testing"
 
 	"github.com/John-Robertt/AVMC/internal/domain"
@@ -22,7 +23,7 @@ type movieOut struct {
 	Poster    string   `xml:"poster"`
 	Thumb     string   `xml:"thumb"`
 	Fanart    string   `xml:"fanart"`
-	Rating    int      `xml:"rating"`
+	Rating    float64  `xml:"rating"`
 	UserRate  int      `xml:"userrating"`
 	Votes     int      `xml:"votes"`
 	Website   string   `xml:"website"`
@@ -30,8 +31,9 @@ type movieOut struct {
 	Genres    []string `xml:"genre"`
 	Cover     string   `xml:"cover"`
 	Actors    []struct {
-		Name string `xml:"name"`
-		Role string `xml:"role"`
+		Name  string `xml:"name"`
+		Role  string `xml:"role"`
+		Thumb string `xml:"thumb"`
 	} `xml:"actor"`
 }
 
@@ -45,7 +47,7 @@ func TestEncode_XMLRoundTripAndDeterministicLists(t *testing.T) {
 		Release:  "2025-01-02",
 		Year:     2025,
 		RuntimeM: 120,
-		Actors:   []string{"b", "a", "a", " "},
+		Actors:   []domain.Actor{{Name: "b", Thumb: ".actors/b.jpg"}, {Name: "a"}, {Name: "a"}, {Name: " "}},
 		Genres:   []string{"z", "x", "x"},
 		Tags:     []string{"t2", "t1"},
 		Website:  "https://example.test/page",
@@ -75,11 +77,14 @@ func TestEncode_XMLRoundTripAndDeterministicLists(t *testing.T) {
 		t.Fatalf("poster/thumb/fanart 不一致：%q %q %q", out.Poster, out.Thumb, out.Fanart)
 	}
 	if out.Rating != 0 || out.UserRate != 0 || out.Votes != 0 {
-		t.Fatalf("rating/userrating/votes 不一致：%d %d %d", out.Rating, out.UserRate, out.Votes)
+		t.Fatalf("rating/userrating/votes 不一致：%v %d %d", out.Rating, out.UserRate, out.Votes)
 	}
 	if len(out.Actors) != 2 || out.Actors[0].Name != "b" || out.Actors[1].Name != "a" || out.Actors[0].Role != "b" || out.Actors[1].Role != "a" {
 		t.Fatalf("actors 未去重且 role 应与 name 相同：%v", out.Actors)
 	}
+	if out.Actors[0].Thumb != ".actors/b.jpg" || out.Actors[1].Thumb != "" {
+		t.Fatalf("actor thumb 不一致：%v", out.Actors)
+	}
 	// tags/genres 会追加 actors（便于媒体库按人名过滤）。
 	if len(out.Tags) != 4 || out.Tags[0] != "t2" || out.Tags[1] != "t1" || out.Tags[2] != "b" || out.Tags[3] != "a" {
 		t.Fatalf("tags 未按输入顺序去重并追加 actors：%v", out.Tags)
@@ -110,3 +115,188 @@ func TestEncode_TitleFallbackToCode(t *testing.T) {
 		t.Fatalf("期望 title 回退到 CODE，实际=%q", out.Title)
 	}
 }
+
+func TestEncode_OriginalTitle(t *testing.T) {
+	code, _ := domain.ParseCode("CAWD-895")
+	b, err := Encode(domain.MovieMeta{Code: code, Title: "译文标题", OriginalTitle: " 原文タイトル "})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	var out struct {
+		Title         string `xml:"title"`
+		OriginalTitle string `xml:"originaltitle"`
+	}
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatalf("xml.Unmarshal 失败：%v", err)
+	}
+	if out.Title != "CAWD-895 译文标题" || out.OriginalTitle != "原文タイトル" {
+		t.Fatalf("title/originaltitle 不符合预期：%+v", out)
+	}
+
+	b, _ = Encode(domain.MovieMeta{Code: code, Title: "T"})
+	if strings.Contains(string(b), "originaltitle") {
+		t.Fatalf("未翻译时不应输出 originaltitle：%s", b)
+	}
+}
+
+func TestEncode_RicherFields(t *testing.T) {
+	code, _ := domain.ParseCode("JUR-566")
+	b, err := Encode(domain.MovieMeta{
+		Code:       code,
+		Title:      "T",
+		Plot:       " 简介 ",
+		Studio:     "Madonna",
+		Maker:      "マドンナ",
+		Label:      "Madonna",
+		Director:   "加州夏",
+		Rating:     8.6,
+		Votes:      783,
+		TrailerURL: "https://example.test/t.m3u8",
+	})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	var out struct {
+		Plot     string  `xml:"plot"`
+		Maker    string  `xml:"maker"`
+		Label    string  `xml:"label"`
+		Director string  `xml:"director"`
+		Rating   float64 `xml:"rating"`
+		Votes    int     `xml:"votes"`
+		Trailer  string  `xml:"trailer"`
+	}
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatalf("xml.Unmarshal 失败：%v", err)
+	}
+	if out.Plot != "简介" || out.Maker != "マドンナ" || out.Label != "Madonna" || out.Director != "加州夏" {
+		t.Fatalf("plot/maker/label/director 不符合预期：%+v", out)
+	}
+	if out.Rating != 8.6 || out.Votes != 783 || out.Trailer != "https://example.test/t.m3u8" {
+		t.Fatalf("rating/votes/trailer 不符合预期：%+v", out)
+	}
+
+	b, _ = Encode(domain.MovieMeta{Code: code, Title: "T"})
+	for _, tag := range []string{"<plot>", "<maker>", "<label>", "<director>", "<trailer>"} {
+		if strings.Contains(string(b), tag) {
+			t.Fatalf("字段为空时不应输出 %s：%s", tag, b)
+		}
+	}
+}
+
+func TestEncode_KodiProfileAndUniqueID(t *testing.T) {
+	code, _ := domain.ParseCode("JUR-566")
+	meta := domain.MovieMeta{Code: code, Title: "T", Release: "2025-01-01", Rating: 8.6, Votes: 783, Website: "https://javdb.com/v/z4Pxwb", CoverURL: "https://img.test/c.jpg"}
+	b, err := EncodeWithOptions(meta, Options{Profile: ProfileKodi, Provider: "javdb"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	s := string(b)
+	for _, want := range []string{
+		`<uniqueid type="javdb" default="true">z4Pxwb</uniqueid>`,
+		`<thumb aspect="poster">poster.jpg</thumb>`,
+		"<fanart>\n    <thumb>fanart.jpg</thumb>\n  </fanart>",
+		`<rating name="javdb" max="10" default="true">`,
+		`<value>8.6</value>`,
+		`<premiered>2025-01-01</premiered>`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("kodi NFO 缺少 %s：%s", want, s)
+		}
+	}
+	for _, unwanted := range []string{"<release>", "<poster>", "<cover>", "<userrating>"} {
+		if strings.Contains(s, unwanted) {
+			t.Fatalf("kodi NFO 不应包含 %s：%s", unwanted, s)
+		}
+	}
+
+	// 默认结构不写 uniqueid；显式开启后写入。
+	b, _ = Encode(meta)
+	if strings.Contains(string(b), "<uniqueid") {
+		t.Fatalf("默认结构不应写 uniqueid：%s", b)
+	}
+	on := true
+	b, _ = EncodeWithOptions(meta, Options{UniqueIDs: &on, Provider: "javbus", LockData: true})
+	if !strings.Contains(string(b), `<uniqueid type="javbus" default="true">z4Pxwb</uniqueid>`) || !strings.Contains(string(b), "<lockdata>true</lockdata>") {
+		t.Fatalf("uniqueid/lockdata 未生效：%s", b)
+	}
+
+	if _, err := EncodeWithOptions(meta, Options{Profile: "xbmc"}); err == nil {
+		t.Fatalf("未知 profile 应报错")
+	}
+}
+
+func TestEncode_TemplatesAndFixedElements(t *testing.T) {
+	code, _ := domain.ParseCode("JUR-566")
+	meta := domain.MovieMeta{Code: code, Title: "译文", OriginalTitle: "原文", Year: 2025, Actors: []domain.Actor{{Name: "A"}, {Name: "B"}}}
+	empty := ""
+	b, err := EncodeWithOptions(meta, Options{
+		Templates: Templates{
+			Title:         "{title} [{code}]",
+			SortTitle:     "{year} {code}",
+			OriginalTitle: "{code} {original_title}",
+			Outline:       "{actors} · {studio}",
+		},
+		MPAA:    &empty,
+		Country: &empty,
+	})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	var out struct {
+		Title         string `xml:"title"`
+		SortTitle     string `xml:"sorttitle"`
+		OriginalTitle string `xml:"originaltitle"`
+		Outline       string `xml:"outline"`
+	}
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatalf("xml.Unmarshal 失败：%v", err)
+	}
+	if out.Title != "译文 [JUR-566]" || out.SortTitle != "2025 JUR-566" || out.OriginalTitle != "JUR-566 原文" || out.Outline != "A B ·" {
+		t.Fatalf("模板展开不符合预期：%+v", out)
+	}
+	if strings.Contains(string(b), "<mpaa>") || strings.Contains(string(b), "<country>") {
+		t.Fatalf("mpaa/country 配置为空串时不应输出：%s", b)
+	}
+
+	for _, bad := range []string{"{title", "title}", "{nope}"} {
+		if err := ValidateTemplate(bad); err == nil {
+			t.Fatalf("模板 %q 应报错", bad)
+		}
+	}
+	if err := ValidateTemplate("{code} {title}"); err != nil {
+		t.Fatalf("合法模板不应报错：%v", err)
+	}
+}
+
+func TestEncode_FileInfo(t *testing.T) {
+	code, _ := domain.ParseCode("ABC-123")
+	media := &domain.MediaInfo{Container: "mkv", DurationS: 7265.4, VideoCodec: "hevc", Width: 3840, Height: 2160, AudioCodec: "aac", AudioChannels: 2, AudioLanguage: "jpn"}
+	b, err := EncodeWithOptions(domain.MovieMeta{Code: code}, Options{Media: media})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	s := string(b)
+	for _, want := range []string{
+		"<runtime>121</runtime>",
+		"<fileinfo>\n    <streamdetails>\n      <video>\n        <codec>hevc</codec>\n        <aspect>1.78</aspect>\n        <width>3840</width>\n        <height>2160</height>\n        <durationinseconds>7265</durationinseconds>\n      </video>",
+		"<audio>\n        <codec>aac</codec>\n        <language>jpn</language>\n        <channels>2</channels>\n      </audio>",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("NFO 缺少 %q：%s", want, s)
+		}
+	}
+	if got, err := Decode(b); err != nil || got.Code != code || got.RuntimeM != 121 {
+		t.Fatalf("含 fileinfo 的 NFO 应可读回：%+v err=%v", got, err)
+	}
+
+	// 站点提供的时长优先；关闭 fileinfo 后完全忽略 Media。
+	b, _ = EncodeWithOptions(domain.MovieMeta{Code: code, RuntimeM: 120}, Options{Media: media})
+	if !strings.Contains(string(b), "<runtime>120</runtime>") {
+		t.Fatalf("站点时长应优先：%s", b)
+	}
+	off := false
+	b, _ = EncodeWithOptions(domain.MovieMeta{Code: code}, Options{Media: media, FileInfo: &off})
+	if strings.Contains(string(b), "<fileinfo>") || strings.Contains(string(b), "<runtime>") {
+		t.Fatalf("fileinfo=false 时不应写 fileinfo/runtime：%s", b)
+	}
+}
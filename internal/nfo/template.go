@@ -0,0 +1,90 @@
+package nfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// Templates 是标题类元素的模板；空串表示使用内置规则。
+//
+// 语法：{name} 占位符，可用名字见 TemplateFields；其余文本原样保留，结果会合并连续空白。
+type Templates struct {
+	Title         string // 默认：CODE + 空格 + 标题（标题已以 CODE 开头时不重复）
+	SortTitle     string // 默认：{code}
+	OriginalTitle string // 默认：{original_title}
+	Outline       string // 默认：不输出 <outline>
+}
+
+// TemplateFields 是模板可用的占位符（按字母序）。
+var TemplateFields = []string{
+	"actors", "code", "director", "label", "maker", "original_title",
+	"plot", "release", "series", "studio", "title", "year",
+}
+
+// ValidateTemplate 检查模板的花括号是否成对、占位符是否可用。
+func ValidateTemplate(tpl string) error {
+	_, err := render(tpl, nil)
+	return err
+}
+
+// templateValues 返回 meta 对应的占位符取值。
+func templateValues(meta domain.MovieMeta) map[string]string {
+	year := ""
+	if meta.Year > 0 {
+		year = strconv.Itoa(meta.Year)
+	}
+	return map[string]string{
+		"actors":         strings.Join(domain.ActorNames(meta.Actors), " "),
+		"code":           strings.TrimSpace(string(meta.Code)),
+		"director":       strings.TrimSpace(meta.Director),
+		"label":          strings.TrimSpace(meta.Label),
+		"maker":          strings.TrimSpace(meta.Maker),
+		"original_title": strings.TrimSpace(meta.OriginalTitle),
+		"plot":           strings.TrimSpace(meta.Plot),
+		"release":        strings.TrimSpace(meta.Release),
+		"series":         strings.TrimSpace(meta.Series),
+		"studio":         strings.TrimSpace(meta.Studio),
+		"title":          strings.TrimSpace(meta.Title),
+		"year":           year,
+	}
+}
+
+// render 展开模板；vals 为 nil 时只做校验（占位符一律视为可用名字检查）。
+func render(tpl string, vals map[string]string) (string, error) {
+	var b strings.Builder
+	rest := tpl
+	for {
+		i := strings.IndexAny(rest, "{}")
+		if i < 0 {
+			b.WriteString(rest)
+			break
+		}
+		if rest[i] == '}' {
+			return "", fmt.Errorf("模板 %q 中的 '}' 没有对应的 '{'", tpl)
+		}
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return "", fmt.Errorf("模板 %q 中的 '{' 没有闭合", tpl)
+		}
+		name := strings.TrimSpace(rest[i+1 : i+j])
+		if !knownField(name) {
+			return "", fmt.Errorf("模板 %q 中的占位符 {%s} 不可用（可用：%s）", tpl, name, strings.Join(TemplateFields, ", "))
+		}
+		b.WriteString(rest[:i])
+		b.WriteString(vals[name])
+		rest = rest[i+j+1:]
+	}
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
+
+func knownField(name string) bool {
+	for _, f := range TemplateFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -36,6 +36,19 @@ func (Provider) Fetch(ctx context.Context, code domain.Code, c *http.Client) ([]
 	}
 
 	pageURL := "https://www.javbus.com/" + url.PathEscape(string(code))
+	b, err := fetchDetail(ctx, c, pageURL)
+	return b, pageURL, err
+}
+
+// FetchPage 直接抓取给定详情页（overrides 固定 URL 时使用）。
+func (Provider) FetchPage(ctx context.Context, pageURL string, c *http.Client) ([]byte, error) {
+	if c == nil {
+		return nil, errors.New("http client 不能为空")
+	}
+	return fetchDetail(ctx, c, pageURL)
+}
+
+func fetchDetail(ctx context.Context, c *http.Client, pageURL string) ([]byte, error) {
 	// JavBus 在未通过“成年确认”时通常会返回 302 到 /doc/driver-verify，
 	// 但很多情况下 302 的 body 仍然是完整详情页 HTML。
 	//
@@ -45,11 +58,11 @@ func (Provider) Fetch(ctx context.Context, code domain.Code, c *http.Client) ([]
 	c2.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 		return http.ErrUseLastResponse
 	}
-	b, err := fetchURL(ctx, &c2, pageURL)
-	return b, pageURL, err
+	return fetchURL(ctx, &c2, pageURL)
 }
 
 // Parse 把 JavBus 详情页 HTML 解析为最小可用 MovieMeta。
+// 详情页没有剧情简介：<meta name="description"> 只是“发行日期/长度/CODE+标题”的拼接，因此 Plot 始终为空（需要时用 overrides 填写）。
 func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.MovieMeta, error) {
 	if code == "" {
 		return domain.MovieMeta{}, errors.New("code 不能为空")
@@ -88,18 +101,37 @@ func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.Mov
 	runtimeM := firstInt(runtimeS)
 
 	// “發行商”更像对外的厂牌标识；缺失时再回退“製作商”。
-	studio := findInfoValueAny(doc, []string{"發行商", "发行商", "Label", "Publisher"})
+	label := findInfoValueAny(doc, []string{"發行商", "发行商", "Label", "Publisher"})
+	maker := findInfoValueAny(doc, []string{"製作商", "制作商", "Studio", "Maker", "Manufacturer"})
+	studio := label
 	if studio == "" {
-		studio = findInfoValueAny(doc, []string{"製作商", "制作商", "Studio", "Maker", "Manufacturer"})
+		studio = maker
 	}
+	director := findInfoValueAny(doc, []string{"導演", "导演", "Director"})
 
 	series := findInfoValueAny(doc, []string{"系列", "Series"})
 
-	actors := make([]string, 0, 8)
+	// 演员：star-box 中名字链接指向演员页，同一 li 内的 img 是头像（nowprinting 占位图视为无头像）。
+	actors := make([]domain.Actor, 0, 8)
+	seenActor := make(map[string]struct{}, 8)
 	doc.Find("div.star-name a").Each(func(_ int, s *goquery.Selection) {
-		actors = append(actors, strings.TrimSpace(s.Text()))
+		name := normSpace(s.Text())
+		if name == "" {
+			return
+		}
+		if _, ok := seenActor[name]; ok {
+			return
+		}
+		seenActor[name] = struct{}{}
+		a := domain.Actor{Name: name}
+		if href, ok := s.Attr("href"); ok {
+			a.URL = resolveURL(pageURL, href)
+		}
+		if src, ok := s.Closest("li").Find("img").First().Attr("src"); ok && !strings.Contains(src, "nowprinting") {
+			a.Thumb = resolveURL(pageURL, src)
+		}
+		actors = append(actors, a)
 	})
-	actors = normList(actors)
 
 	genres := parseKeywordTags(doc, code, studio, series)
 	if len(genres) == 0 {
@@ -132,12 +164,24 @@ func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.Mov
 		}
 	}
 
+	// 样品图：a.sample-box 的 href 指向大图（img src 是缩略图）。
+	samples := make([]string, 0, 16)
+	doc.Find("#sample-waterfall a.sample-box").Each(func(_ int, s *goquery.Selection) {
+		if href, ok := s.Attr("href"); ok {
+			samples = append(samples, resolveURL(pageURL, href))
+		}
+	})
+	samples = normList(samples)
+
 	year := yearFromRelease(release)
 
 	meta := domain.MovieMeta{
 		Code:     code,
 		Title:    title,
 		Studio:   studio,
+		Maker:    maker,
+		Label:    label,
+		Director: director,
 		Series:   series,
 		Release:  release,
 		Year:     year,
@@ -149,7 +193,8 @@ func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.Mov
 		Website:  strings.TrimSpace(pageURL),
 		CoverURL: coverURL,
 		// 若无单独背景图，则回退为 cover（避免 apply 因 fanart 缺失而失败）。
-		FanartURL: fanartURL,
+		FanartURL:  fanartURL,
+		SampleURLs: samples,
 	}
 	return meta, nil
 }
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -67,7 +69,48 @@ func (p Provider) Fetch(ctx context.Context, code domain.Code, c *http.Client) (
 	return b, pageURL, err
 }
 
+// FetchPage 直接抓取给定详情页（overrides 固定 URL 时使用，跳过搜索）。
+func (Provider) FetchPage(ctx context.Context, pageURL string, c *http.Client) ([]byte, error) {
+	if c == nil {
+		return nil, errors.New("http client 不能为空")
+	}
+	return fetchURL(ctx, c, pageURL)
+}
+
+// ActorThumb 抓取演员页并返回头像 URL；页面没有头像时返回空串。
+func (Provider) ActorThumb(ctx context.Context, actorURL string, c *http.Client) (string, error) {
+	if c == nil {
+		return "", errors.New("http client 不能为空")
+	}
+	b, err := fetchURL(ctx, c, actorURL)
+	if err != nil {
+		return "", err
+	}
+	return parseActorThumb(b, actorURL)
+}
+
+// parseActorThumb 从演员页提取头像：优先 span.avatar 的 background-image，其次 img.avatar。
+func parseActorThumb(html []byte, pageURL string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return "", err
+	}
+	avatar := doc.Find(".actor-avatar .avatar").First()
+	if style, ok := avatar.Attr("style"); ok {
+		if m := bgImageRE.FindStringSubmatch(style); m != nil {
+			return resolveURL(pageURL, m[1]), nil
+		}
+	}
+	if src, ok := avatar.Attr("src"); ok {
+		return resolveURL(pageURL, src), nil
+	}
+	return "", nil
+}
+
+var bgImageRE = regexp.MustCompile(`background-image:\s*url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
 // Parse 把 JavDB 详情页 HTML 解析为最小可用 MovieMeta。
+// 详情页没有剧情简介：panel 中没有对应字段，<meta name="description"> 是全站通用的站点介绍，因此 Plot 始终为空（需要时用 overrides 填写）。
 func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.MovieMeta, error) {
 	if code == "" {
 		return domain.MovieMeta{}, errors.New("code 不能为空")
@@ -96,9 +139,13 @@ func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.Mov
 	var (
 		release  string
 		runtimeM int
-		studio   string
+		maker    string
+		label    string
+		director string
 		series   string
-		actors   []string
+		rating   float64
+		votes    int
+		actors   []domain.Actor
 		tags     []string
 	)
 
@@ -109,13 +156,25 @@ func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.Mov
 			release = strings.TrimSpace(s.Find("span.value").First().Text())
 		case "時長", "时长", "Length", "Duration":
 			runtimeM = firstInt(s.Find("span.value").First().Text())
-		case "片商", "Maker", "Studio", "Manufacturer", "Label":
-			studio = strings.TrimSpace(s.Find("span.value a").First().Text())
+		case "片商", "Maker", "Studio", "Manufacturer":
+			maker = strings.TrimSpace(s.Find("span.value a").First().Text())
+		case "發行", "发行", "發行商", "发行商", "Label", "Publisher":
+			label = strings.TrimSpace(s.Find("span.value a").First().Text())
+		case "導演", "导演", "Director":
+			director = strings.TrimSpace(s.Find("span.value a").First().Text())
+		case "評分", "评分", "Rating":
+			rating, votes = parseScore(s.Find("span.value").First().Text())
 		case "系列", "Series":
 			series = strings.TrimSpace(s.Find("span.value a").First().Text())
 		case "演員", "演员", "Actor", "Actors", "Actress", "Cast":
+			// 详情页只有演员页链接；头像需要再抓演员页（见 ActorThumb）。
 			s.Find("span.value a").Each(func(_ int, a *goquery.Selection) {
-				actors = append(actors, strings.TrimSpace(a.Text()))
+				name := normSpace(a.Text())
+				if name == "" || hasActor(actors, name) {
+					return
+				}
+				href, _ := a.Attr("href")
+				actors = append(actors, domain.Actor{Name: name, URL: resolveURL(pageURL, href)})
 			})
 		case "類別", "类别", "Tag", "Tags", "Genre", "Genres", "Category", "Categories":
 			s.Find("span.value a").Each(func(_ int, a *goquery.Selection) {
@@ -124,9 +183,13 @@ func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.Mov
 		}
 	})
 
-	actors = normList(actors)
 	tags = normList(tags)
 
+	studio := maker
+	if studio == "" {
+		studio = label
+	}
+
 	coverURL := ""
 	if href, ok := doc.Find(".column-video-cover a[data-fancybox='gallery']").First().Attr("href"); ok {
 		coverURL = strings.TrimSpace(href)
@@ -140,23 +203,45 @@ func (Provider) Parse(code domain.Code, html []byte, pageURL string) (domain.Mov
 	// fanart 采用背景大图（这里直接复用 cover），poster 由 fanart 右半边裁切得到。
 	fanartURL := coverURL
 
+	// 样品图：.preview-images 下的 a.tile-item（预告片入口是 a.preview-video-container，不在此列）。
+	samples := make([]string, 0, 16)
+	doc.Find(".preview-images a.tile-item").Each(func(_ int, s *goquery.Selection) {
+		if href, ok := s.Attr("href"); ok {
+			samples = append(samples, resolveURL(pageURL, href))
+		}
+	})
+	samples = normList(samples)
+
+	// 预告片：详情页内嵌的 <video id="preview-video">（m3u8 带签名参数，可能过期）。
+	trailerURL := ""
+	if src, ok := doc.Find("#preview-video source").First().Attr("src"); ok {
+		trailerURL = resolveURL(pageURL, src)
+	}
+
 	year := yearFromRelease(release)
 
 	meta := domain.MovieMeta{
 		Code:     code,
 		Title:    title,
 		Studio:   studio,
+		Maker:    maker,
+		Label:    label,
+		Director: director,
 		Series:   series,
 		Release:  release,
 		Year:     year,
 		RuntimeM: runtimeM,
+		Rating:   rating,
+		Votes:    votes,
 		Actors:   actors,
 		Genres:   tags,
 		Tags:     tags,
 		Website:  strings.TrimSpace(pageURL),
 		CoverURL: coverURL,
 		// 若无单独背景图，则回退为 cover（避免 apply 因 fanart 缺失而失败）。
-		FanartURL: fanartURL,
+		FanartURL:  fanartURL,
+		TrailerURL: trailerURL,
+		SampleURLs: samples,
 	}
 	return meta, nil
 }
@@ -226,6 +311,34 @@ func resolveURL(base, href string) string {
 	return bu.ResolveReference(ru).String()
 }
 
+// parseScore 解析「4.3分, 由783人評價」/「4.3, by 783 users」：JavDB 为 5 星制，换算为 0~10 分（保留一位小数）。
+func parseScore(s string) (float64, int) {
+	nums := numberRE.FindAllString(s, 2)
+	if len(nums) == 0 {
+		return 0, 0
+	}
+	score, err := strconv.ParseFloat(nums[0], 64)
+	if err != nil || score < 0 || score > 5 {
+		return 0, 0
+	}
+	votes := 0
+	if len(nums) == 2 {
+		votes, _ = strconv.Atoi(nums[1])
+	}
+	return math.Round(score*20) / 10, votes
+}
+
+var numberRE = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+func hasActor(actors []domain.Actor, name string) bool {
+	for _, a := range actors {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func normSpace(s string) string { return strings.Join(strings.Fields(s), " ") }
 
 func normHeader(s string) string {
@@ -30,6 +30,22 @@ func TestFindDetailHref_FromSearchFixture(t *testing.T) {
 	}
 }
 
+func TestParseActorThumb(t *testing.T) {
+	html := []byte(`<div class="column actor-avatar"><div class="image"><span class="avatar" style="background-image: url('/avatars/vd/vDQ0n.jpg')"></span></div></div>`)
+	got, err := parseActorThumb(html, "https://javdb.com/actors/vDQ0n")
+	if err != nil {
+		t.Fatalf("parseActorThumb 失败：%v", err)
+	}
+	if got != "https://javdb.com/avatars/vd/vDQ0n.jpg" {
+		t.Fatalf("头像 URL 不一致：%q", got)
+	}
+
+	got, err = parseActorThumb([]byte(`<div class="actor-avatar"></div>`), "https://javdb.com/actors/x")
+	if err != nil || got != "" {
+		t.Fatalf("没有头像时应返回空串：%q err=%v", got, err)
+	}
+}
+
 func TestParse_Golden(t *testing.T) {
 	entries, err := os.ReadDir("testdata")
 	if err != nil {
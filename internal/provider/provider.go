@@ -18,3 +18,13 @@ type Provider interface {
 	Fetch(ctx context.Context, code domain.Code, c *http.Client) (html []byte, pageURL string, err error)
 	Parse(code domain.Code, html []byte, pageURL string) (domain.MovieMeta, error)
 }
+
+// ActorThumbFetcher 是可选能力：详情页不含演员头像时，按演员页 URL 抓取头像 URL（空串表示没有头像）。
+type ActorThumbFetcher interface {
+	ActorThumb(ctx context.Context, actorURL string, c *http.Client) (string, error)
+}
+
+// PageFetcher 是可选能力：直接抓取给定的详情页（用于 overrides 固定 URL，跳过搜索）。
+type PageFetcher interface {
+	FetchPage(ctx context.Context, pageURL string, c *http.Client) (html []byte, err error)
+}
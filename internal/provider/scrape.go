@@ -77,6 +77,43 @@ func FetchParseTrace(ctx context.Context, reg Registry, providerRequested string
 	return domain.MovieMeta{}, "", "", nil, attempts, lastErr
 }
 
+// FetchParsePinned 只使用 name 指定的 provider（不降级）。
+// pageURL 非空时直接抓取该详情页（provider 必须实现 PageFetcher），否则走 provider 自身的 Fetch。
+func FetchParsePinned(ctx context.Context, reg Registry, name string, pageURL string, code domain.Code, c *http.Client) (meta domain.MovieMeta, website string, html []byte, attempts []Attempt, err error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if code == "" {
+		return domain.MovieMeta{}, "", nil, nil, fmt.Errorf("code 不能为空")
+	}
+	p, ok := reg.Get(name)
+	if !ok {
+		err = fmt.Errorf("provider 未注册：%q", name)
+		return domain.MovieMeta{}, "", nil, []Attempt{{Provider: name, Stage: "fetch", Err: err}}, err
+	}
+
+	var h []byte
+	var ferr error
+	if strings.TrimSpace(pageURL) != "" {
+		pf, ok := p.(PageFetcher)
+		if !ok {
+			ferr = fmt.Errorf("provider %q 不支持指定详情页 URL", name)
+		} else {
+			h, ferr = pf.FetchPage(ctx, pageURL, c)
+		}
+	} else {
+		h, pageURL, ferr = p.Fetch(ctx, code, c)
+	}
+	if ferr != nil {
+		return domain.MovieMeta{}, "", nil, []Attempt{{Provider: name, Stage: "fetch", Err: ferr}}, &Error{Provider: name, Stage: "fetch", Err: ferr}
+	}
+
+	m, perr := p.Parse(code, h, pageURL)
+	if perr != nil {
+		return domain.MovieMeta{}, "", nil, []Attempt{{Provider: name, Stage: "parse", Err: perr}}, &Error{Provider: name, Stage: "parse", Err: perr}
+	}
+	m.Website = pageURL
+	return m, pageURL, h, []Attempt{{Provider: name, Stage: "ok"}}, nil
+}
+
 // Error 是 provider 阶段的可追溯错误。
 // 上层可以据此把失败归类为 fetch_failed / parse_failed，并写入 report。
 type Error struct {
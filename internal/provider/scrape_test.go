@@ -137,3 +137,45 @@ func TestFetchParse_UnknownProvider(t *testing.T) {
 		t.Fatalf("期望错误，但得到 nil")
 	}
 }
+
+type pageStub struct {
+	stubProvider
+	pageHTML []byte
+	gotURL   string
+}
+
+func (p *pageStub) FetchPage(ctx context.Context, pageURL string, c *http.Client) ([]byte, error) {
+	p.gotURL = pageURL
+	return p.pageHTML, nil
+}
+
+func TestFetchParsePinned_NoFallbackAndFixedURL(t *testing.T) {
+	code, _ := domain.ParseCode("CAWD-895")
+
+	javbus := &stubProvider{name: "javbus", html: []byte("<html/>"), url: "https://example.test/javbus/1"}
+	javdb := &pageStub{stubProvider: stubProvider{name: "javdb", fetchErr: errors.New("nope")}, pageHTML: []byte("<html/>")}
+	reg, err := NewRegistry(javbus, javdb)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+
+	// 固定 provider：失败也不降级到 javbus。
+	if _, _, _, attempts, err := FetchParsePinned(context.Background(), reg, "javdb", "", code, nil); err == nil || len(attempts) != 1 || javbus.fetchCalls != 0 {
+		t.Fatalf("固定 provider 不应降级：err=%v attempts=%+v javbus.fetch=%d", err, attempts, javbus.fetchCalls)
+	}
+
+	// 固定 URL：直接抓取该详情页，website 即该 URL。
+	const u = "https://example.test/javdb/fixed"
+	meta, website, _, _, err := FetchParsePinned(context.Background(), reg, "javdb", u, code, nil)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if javdb.gotURL != u || website != u || meta.Website != u {
+		t.Fatalf("应抓取固定 URL：got=%q website=%q", javdb.gotURL, website)
+	}
+
+	// 不支持 PageFetcher 的 provider 不能固定 URL。
+	if _, _, _, _, err := FetchParsePinned(context.Background(), reg, "javbus", u, code, nil); err == nil {
+		t.Fatalf("期望不支持固定 URL 的 provider 返回错误")
+	}
+}
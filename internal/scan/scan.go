@@ -1,7 +1,9 @@
 package scan
 
 import (
+	"fmt"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -9,18 +11,74 @@ import (
 	"github.com/John-Robertt/AVMC/internal/domain"
 )
 
-// ScanVideos 扫描 root 下的视频文件，并应用目录排除规则。
+// IgnoreMarker 是目录级忽略标记：目录内存在该文件时，整个目录（含子目录）都不扫描。
+const IgnoreMarker = ".avmcignore"
+
+// DefaultVideoExts 是未配置 scan.video_exts 时识别的视频扩展名（小写、含 '.'）。
+// .iso/.strm 等需要用户显式开启（体积/语义差异较大）。
+var DefaultVideoExts = []string{
+	".mp4", ".mkv", ".avi", ".wmv", ".ts", ".m2ts", ".mov", ".rmvb",
+	".m4v", ".flv", ".webm", ".mpg", ".mpeg",
+}
+
+// Options 控制扫描范围。零值 = 默认扩展名、不限大小、无文件名排除。
+type Options struct {
+	// ExcludeDirs 来自配置文件，均视为相对 root 的路径（若是绝对路径，则按绝对路径处理）。
+	ExcludeDirs []string
+	// VideoExts 为小写、含 '.' 的扩展名；为空时使用 DefaultVideoExts。
+	VideoExts []string
+	// MinSize 是视频的最小字节数（小于则跳过并记录）；0 表示不限。
+	MinSize int64
+	// ExcludePatterns 是文件名 glob（filepath.Match 语法，不区分大小写），命中则跳过并记录。
+	ExcludePatterns []string
+}
+
+// Result 是一次扫描的输出：视频文件与被规则跳过的文件（用于报告解释）。
+type Result struct {
+	Files   []domain.VideoFile
+	Skipped []domain.SkippedFile
+}
+
+// ScanVideos 以默认规则扫描 root 下的视频文件（见 Scan）。
+func ScanVideos(root string, excludeDirs []string) ([]domain.VideoFile, error) {
+	res, err := Scan(root, Options{ExcludeDirs: excludeDirs})
+	if err != nil {
+		return nil, err
+	}
+	return res.Files, nil
+}
+
+// Scan 扫描 root 下的视频文件，并应用目录排除与文件过滤规则。
 //
 // 规则（硬约束）：
 // - 永久排除：<root>/out/ 与 <root>/cache/
-// - excludeDirs：来自配置文件，均视为相对 root 的路径（若是绝对路径，则按绝对路径处理）
+// - opt.ExcludeDirs：配置的排除目录（静默跳过，不进入报告）
+// - 含 .avmcignore 的目录（root 本身除外）：整目录跳过，作为一条 skipped 记录
+// - 扩展名命中但小于 MinSize / 文件名命中 ExcludePatterns 的视频：跳过并记录原因
 //
 // 注意：扫描阶段只做 stat（DirEntry.Info），不读文件内容。
-func ScanVideos(root string, excludeDirs []string) ([]domain.VideoFile, error) {
+func Scan(root string, opt Options) (Result, error) {
 	root = filepath.Clean(root)
-	excluded := buildExcluded(root, excludeDirs)
+	excluded := buildExcluded(root, opt.ExcludeDirs)
+	exts := opt.VideoExts
+	if len(exts) == 0 {
+		exts = DefaultVideoExts
+	}
+
+	res := Result{
+		Files:   make([]domain.VideoFile, 0, 128),
+		Skipped: make([]domain.SkippedFile, 0),
+	}
+	companions := make([]string, 0, 16)
+	skip := func(path, reason, detail string) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		res.Skipped = append(res.Skipped, domain.SkippedFile{Path: rel, Reason: reason, Detail: detail})
+		return nil
+	}
 
-	files := make([]domain.VideoFile, 0, 128)
 	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
@@ -35,26 +93,43 @@ func ScanVideos(root string, excludeDirs []string) ([]domain.VideoFile, error) {
 		}
 
 		if d.IsDir() {
+			if path != root && hasIgnoreMarker(path) {
+				if err := skip(path, domain.SkipReasonIgnoreMarker, IgnoreMarker); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		name := d.Name()
 		ext := strings.ToLower(filepath.Ext(name))
-		if !isVideoExt(ext) {
+		if IsCompanionExt(ext) {
+			companions = append(companions, path)
 			return nil
 		}
+		if !HasExt(exts, ext) {
+			return nil
+		}
+
+		if p, ok := matchPattern(opt.ExcludePatterns, name); ok {
+			return skip(path, domain.SkipReasonExcludedPattern, p)
+		}
 
 		info, err := d.Info()
 		if err != nil {
 			return err
 		}
+		if opt.MinSize > 0 && info.Size() < opt.MinSize {
+			return skip(path, domain.SkipReasonTooSmall, fmt.Sprintf("%d < %d bytes", info.Size(), opt.MinSize))
+		}
 
 		rel, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
 
-		files = append(files, domain.VideoFile{
+		res.Files = append(res.Files, domain.VideoFile{
 			AbsPath: path,
 			RelPath: rel,
 			Base:    strings.TrimSuffix(name, filepath.Ext(name)),
@@ -65,17 +140,84 @@ func ScanVideos(root string, excludeDirs []string) ([]domain.VideoFile, error) {
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return Result{}, err
 	}
 
 	// 强制稳定输出，避免不同平台/文件系统行为差异带来的不确定性。
-	sort.Slice(files, func(i, j int) bool { return files[i].RelPath < files[j].RelPath })
-	return files, nil
+	sort.Slice(res.Files, func(i, j int) bool { return res.Files[i].RelPath < res.Files[j].RelPath })
+	sort.Slice(res.Skipped, func(i, j int) bool { return res.Skipped[i].Path < res.Skipped[j].Path })
+	attachCompanions(res.Files, companions)
+	return res, nil
+}
+
+func hasIgnoreMarker(dir string) bool {
+	_, err := os.Lstat(filepath.Join(dir, IgnoreMarker))
+	return err == nil
+}
+
+// matchPattern 返回 name 命中的第一个 pattern（不区分大小写）。
+func matchPattern(patterns []string, name string) (string, bool) {
+	lower := strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(p), lower); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// attachCompanions 把伴随文件挂到同目录、basename 最长匹配的视频上；没有匹配视频的伴随文件保持不动。
+//
+// 例：ABC-123.mp4 与 ABC-123-cd2.mp4 同目录时，ABC-123-cd2.srt 只属于后者（要求 basename 后紧跟 '.'）。
+func attachCompanions(files []domain.VideoFile, companions []string) {
+	byDir := make(map[string][]int, len(files))
+	for i := range files {
+		dir := filepath.Dir(files[i].AbsPath)
+		byDir[dir] = append(byDir[dir], i)
+	}
+
+	sort.Strings(companions)
+	for _, c := range companions {
+		name := filepath.Base(c)
+		best := -1
+		for _, i := range byDir[filepath.Dir(c)] {
+			if !hasBasePrefix(name, files[i].Base) {
+				continue
+			}
+			if best < 0 || len(files[i].Base) > len(files[best].Base) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			files[best].Companions = append(files[best].Companions, c)
+		}
+	}
+}
+
+// hasBasePrefix 判断 name 是否形如 "<base>.xxx"（base 比较忽略大小写）。
+func hasBasePrefix(name, base string) bool {
+	return base != "" && len(name) > len(base) && name[len(base)] == '.' && strings.EqualFold(name[:len(base)], base)
+}
+
+// IsVideoExt 判断扩展名（小写、含 '.'）是否属于 DefaultVideoExts。
+func IsVideoExt(ext string) bool {
+	return HasExt(DefaultVideoExts, ext)
+}
+
+// HasExt 判断扩展名（小写、含 '.'）是否在 exts 中。
+func HasExt(exts []string, ext string) bool {
+	for _, e := range exts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
 }
 
-func isVideoExt(ext string) bool {
+// IsCompanionExt 判断扩展名（小写、含 '.'）是否为随视频移动的伴随文件（字幕）。
+func IsCompanionExt(ext string) bool {
 	switch ext {
-	case ".mp4", ".mkv", ".avi":
+	case ".srt", ".ass", ".ssa", ".sub", ".idx", ".vtt", ".sup", ".smi":
 		return true
 	default:
 		return false
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
 )
 
 func TestScanVideos_ExcludeOutAndCache(t *testing.T) {
@@ -65,6 +67,83 @@ func TestScanVideos_ExtCaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestScanVideos_AttachesCompanions(t *testing.T) {
+	root := t.TempDir()
+	touch(t, filepath.Join(root, "in", "ABC-123.mp4"))
+	touch(t, filepath.Join(root, "in", "ABC-123-cd2.mp4"))
+	touch(t, filepath.Join(root, "in", "ABC-123.srt"))
+	touch(t, filepath.Join(root, "in", "abc-123.chs.ASS"))
+	touch(t, filepath.Join(root, "in", "ABC-123-cd2.srt"))
+	touch(t, filepath.Join(root, "in", "ABC-1234.srt"))   // 不是 "<Base>." 前缀：不挂载
+	touch(t, filepath.Join(root, "other", "ABC-123.srt")) // 不同目录：不挂载
+
+	got, err := ScanVideos(root, nil)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("伴随文件不应作为视频：%+v", got)
+	}
+
+	want := map[string][]string{
+		"ABC-123":     {filepath.Join(root, "in", "ABC-123.srt"), filepath.Join(root, "in", "abc-123.chs.ASS")},
+		"ABC-123-cd2": {filepath.Join(root, "in", "ABC-123-cd2.srt")},
+	}
+	for _, f := range got {
+		w := want[f.Base]
+		if len(f.Companions) != len(w) {
+			t.Fatalf("%s 的伴随文件不符合预期：%v", f.Base, f.Companions)
+		}
+		for i := range w {
+			if f.Companions[i] != w[i] {
+				t.Fatalf("%s 的伴随文件不符合预期：%v", f.Base, f.Companions)
+			}
+		}
+	}
+}
+
+func TestScan_FiltersAndReportsSkipped(t *testing.T) {
+	root := t.TempDir()
+	touch(t, filepath.Join(root, "in", "ABC-123.wmv"))
+	touch(t, filepath.Join(root, "in", "ABC-123-Sample.mp4"))
+	touch(t, filepath.Join(root, "in", "ABC-124.iso"))
+	touch(t, filepath.Join(root, "keep", ".avmcignore"))
+	touch(t, filepath.Join(root, "keep", "ABC-125.mp4"))
+
+	opt := Options{
+		VideoExts:       []string{".mp4", ".wmv"},
+		ExcludePatterns: []string{"*-SAMPLE.*"},
+	}
+	got, err := Scan(root, opt)
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(got.Files) != 1 || got.Files[0].RelPath != filepath.Join("in", "ABC-123.wmv") {
+		t.Fatalf("files 不符合预期：%+v", got.Files)
+	}
+	want := []domain.SkippedFile{
+		{Path: filepath.Join("in", "ABC-123-Sample.mp4"), Reason: domain.SkipReasonExcludedPattern, Detail: "*-SAMPLE.*"},
+		{Path: "keep", Reason: domain.SkipReasonIgnoreMarker, Detail: IgnoreMarker},
+	}
+	if len(got.Skipped) != len(want) {
+		t.Fatalf("skipped 不符合预期：%+v", got.Skipped)
+	}
+	for i := range want {
+		if got.Skipped[i] != want[i] {
+			t.Fatalf("skipped[%d] 期望 %+v，实际 %+v", i, want[i], got.Skipped[i])
+		}
+	}
+
+	// 最小大小：1 字节文件小于 2 字节。
+	got, err = Scan(root, Options{VideoExts: []string{".wmv"}, MinSize: 2})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if len(got.Files) != 0 || len(got.Skipped) != 2 || got.Skipped[0].Reason != domain.SkipReasonTooSmall {
+		t.Fatalf("min size 过滤不符合预期：%+v", got)
+	}
+}
+
 func touch(t *testing.T, path string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -0,0 +1,2 @@
+// Package tags 负责 NFO 中 genre/tag 的组成：映射字典（规范化/翻译/合并/丢弃）与来源策略。
+package tags
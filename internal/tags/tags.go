@@ -0,0 +1,174 @@
+package tags
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+// Target 表示某类来源写入 NFO 的位置。
+const (
+	TargetNone  = "none"
+	TargetTag   = "tag"
+	TargetGenre = "genre"
+	TargetBoth  = "both"
+)
+
+// ValidTarget 判断 s 是否为合法的 Target（空串表示使用默认值）。
+func ValidTarget(s string) bool {
+	switch s {
+	case "", TargetNone, TargetTag, TargetGenre, TargetBoth:
+		return true
+	default:
+		return false
+	}
+}
+
+// Policy 决定演员/片商/系列/文件名 marker 是否写成 genre 或 tag；空串表示使用 DefaultPolicy 的取值。
+type Policy struct {
+	Actors  string
+	Studio  string
+	Series  string
+	Markers string
+}
+
+// DefaultPolicy 与引入策略前的行为一致：演员与 marker 同时写入 genre 和 tag，片商/系列不写。
+var DefaultPolicy = Policy{
+	Actors:  TargetBoth,
+	Studio:  TargetNone,
+	Series:  TargetNone,
+	Markers: TargetBoth,
+}
+
+// Mapper 是 genre/tag 映射字典：key 为规范化后的原值（去空白、小写），value 为目标值，空串表示丢弃。
+// 多个原值映射到同一目标即“合并”。未收录的值原样保留。
+type Mapper map[string]string
+
+// NewMapper 由原始字典构造 Mapper（key 规范化）。
+func NewMapper(raw map[string]string) Mapper {
+	return Mapper(nil).Merge(raw)
+}
+
+// Merge 返回合并了 raw 的新 Mapper；同一原值以 raw 为准。
+func (m Mapper) Merge(raw map[string]string) Mapper {
+	if len(m) == 0 && len(raw) == 0 {
+		return nil
+	}
+	out := make(Mapper, len(m)+len(raw))
+	for k, v := range m {
+		out[k] = v
+	}
+	for k, v := range raw {
+		if key := mapKey(k); key != "" {
+			out[key] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
+// LoadMapFile 读取映射字典文件（JSON 对象 {"原值": "目标值"}）。
+func LoadMapFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取映射字典失败：%w", err)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("映射字典不是有效的 JSON 对象：%w", err)
+	}
+	return raw, nil
+}
+
+// Map 映射单个值；ok=false 表示该值应被丢弃。
+func (m Mapper) Map(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", false
+	}
+	if v, hit := m[mapKey(s)]; hit {
+		return v, v != ""
+	}
+	return s, true
+}
+
+func mapKey(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+
+// Options 是 genre/tag 组成规则。零值等价于 DefaultPolicy + 不映射。
+type Options struct {
+	Policy Policy
+	Map    Mapper
+}
+
+// Compose 计算 NFO 的 genre 与 tag 列表（去空白、去重、保持顺序）：
+//  1. provider 的 genres/tags 与文件名 marker 先经过映射字典
+//  2. 再按策略追加演员、片商、系列（人名/品牌不做映射）
+func Compose(meta domain.MovieMeta, markers []string, opt Options) (genres, tagList []string) {
+	p := opt.Policy.withDefaults()
+
+	genres = opt.Map.mapAll(meta.Genres)
+	tagList = opt.Map.mapAll(meta.Tags)
+
+	add := func(target string, vals []string) {
+		if target == TargetGenre || target == TargetBoth {
+			genres = append(genres, vals...)
+		}
+		if target == TargetTag || target == TargetBoth {
+			tagList = append(tagList, vals...)
+		}
+	}
+	add(p.Markers, opt.Map.mapAll(markers))
+	add(p.Actors, domain.ActorNames(meta.Actors))
+	add(p.Studio, []string{meta.Studio})
+	add(p.Series, []string{meta.Series})
+
+	return dedupe(genres), dedupe(tagList)
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.Actors == "" {
+		p.Actors = DefaultPolicy.Actors
+	}
+	if p.Studio == "" {
+		p.Studio = DefaultPolicy.Studio
+	}
+	if p.Series == "" {
+		p.Series = DefaultPolicy.Series
+	}
+	if p.Markers == "" {
+		p.Markers = DefaultPolicy.Markers
+	}
+	return p
+}
+
+func (m Mapper) mapAll(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if v, ok := m.Map(s); ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func dedupe(in []string) []string {
+	seen := make(map[string]struct{}, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
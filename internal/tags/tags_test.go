@@ -0,0 +1,55 @@
+package tags
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/John-Robertt/AVMC/internal/domain"
+)
+
+func TestCompose_DefaultPolicyMatchesLegacy(t *testing.T) {
+	meta := domain.MovieMeta{
+		Actors: []domain.Actor{{Name: "b"}, {Name: "a"}},
+		Genres: []string{"z", "x", "x"},
+		Tags:   []string{"t1"},
+		Studio: "S",
+		Series: "R",
+	}
+	genres, tagList := Compose(meta, []string{"中文字幕"}, Options{})
+	if want := []string{"z", "x", "中文字幕", "b", "a"}; !reflect.DeepEqual(genres, want) {
+		t.Fatalf("genres：期望 %v，实际 %v", want, genres)
+	}
+	if want := []string{"t1", "中文字幕", "b", "a"}; !reflect.DeepEqual(tagList, want) {
+		t.Fatalf("tags：期望 %v，实际 %v", want, tagList)
+	}
+}
+
+func TestCompose_MapAndPolicy(t *testing.T) {
+	m := NewMapper(map[string]string{
+		"高畫質":  "",   // 丢弃
+		"巨乳":   "巨乳", // 规范化（繁简同形，原样）
+		"中出し":  "中出", // 翻译
+		"中出":   "中出", // 合并
+		" HD ": "",   // key 去空白、大小写不敏感
+		"中文字幕": "中字", // marker 也经过映射
+	}).Merge(map[string]string{"中文字幕": "Chinese Sub"})
+
+	meta := domain.MovieMeta{
+		Actors: []domain.Actor{{Name: "演员A"}},
+		Genres: []string{"高畫質", "中出し", "中出", "hd", "巨乳"},
+		Tags:   []string{"中出し"},
+		Studio: "S1",
+		Series: "",
+	}
+	opt := Options{
+		Policy: Policy{Actors: TargetNone, Studio: TargetTag, Series: TargetBoth, Markers: TargetTag},
+		Map:    m,
+	}
+	genres, tagList := Compose(meta, []string{"中文字幕"}, opt)
+	if want := []string{"中出", "巨乳"}; !reflect.DeepEqual(genres, want) {
+		t.Fatalf("genres：期望 %v，实际 %v", want, genres)
+	}
+	if want := []string{"中出", "Chinese Sub", "S1"}; !reflect.DeepEqual(tagList, want) {
+		t.Fatalf("tags：期望 %v，实际 %v", want, tagList)
+	}
+}
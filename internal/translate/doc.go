@@ -0,0 +1,2 @@
+// Package translate 提供可插拔的标题翻译后端（字典文件 / 外部命令 / HTTP 接口）。
+package translate
@@ -0,0 +1,214 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	BackendDict    = "dict"
+	BackendCommand = "command"
+	BackendHTTP    = "http"
+)
+
+// DefaultTarget 是默认目标语言；DefaultTimeout 是 command/http 单次翻译的超时。
+const (
+	DefaultTarget  = "zh"
+	DefaultTimeout = 30 * time.Second
+)
+
+// ErrNoTranslation 表示后端没有给出译文（例如字典未收录）；调用方应保留原文，不视为失败。
+var ErrNoTranslation = errors.New("没有可用的译文")
+
+// Translator 把一段文本翻译成目标语言。
+//
+// 约束：
+// - 不做缓存（由上层按 CODE 缓存）
+// - 没有译文时返回 ErrNoTranslation；其它错误视为翻译失败
+type Translator interface {
+	// Name 标识后端与目标语言（例如 "http:zh"），用于判断缓存是否仍然有效。
+	Name() string
+	Translate(ctx context.Context, text string) (string, error)
+}
+
+// Options 描述要构造的翻译后端（由配置层校验并填充默认值）。
+type Options struct {
+	Backend string
+	Target  string
+
+	DictFile string   // dict：JSON 对象 {"原文": "译文"}
+	Command  []string // command：argv；原文写入 stdin，译文从 stdout 读取
+	URL      string   // http：POST {"text","target"}，响应 {"text"}
+
+	Timeout time.Duration
+}
+
+// New 按 Options 构造 Translator；dict 会在此时读取并校验字典文件。
+func New(opt Options) (Translator, error) {
+	target := strings.TrimSpace(opt.Target)
+	if target == "" {
+		target = DefaultTarget
+	}
+	timeout := opt.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	switch opt.Backend {
+	case BackendDict:
+		return LoadDict(opt.DictFile, target)
+	case BackendCommand:
+		if len(opt.Command) == 0 || strings.TrimSpace(opt.Command[0]) == "" {
+			return nil, fmt.Errorf("command 后端需要非空的 command")
+		}
+		return Command{Argv: append([]string(nil), opt.Command...), Target: target, Timeout: timeout}, nil
+	case BackendHTTP:
+		u, err := url.Parse(strings.TrimSpace(opt.URL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("http 后端的 url 必须是 http/https 地址：%q", opt.URL)
+		}
+		return HTTP{URL: u.String(), Target: target, Client: &http.Client{Timeout: timeout}}, nil
+	default:
+		return nil, fmt.Errorf("backend 只能是 dict|command|http，实际是 %q", opt.Backend)
+	}
+}
+
+// Dict 是基于字典文件的翻译：按去除首尾空白后的原文精确匹配。
+type Dict struct {
+	Target  string
+	Entries map[string]string
+}
+
+// LoadDict 读取字典文件（JSON 对象 {"原文": "译文"}）。
+func LoadDict(path, target string) (Dict, error) {
+	if strings.TrimSpace(path) == "" {
+		return Dict{}, fmt.Errorf("dict 后端需要 dict_file")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Dict{}, fmt.Errorf("读取字典失败：%w", err)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return Dict{}, fmt.Errorf("字典不是有效的 JSON 对象：%w", err)
+	}
+	entries := make(map[string]string, len(raw))
+	for k, v := range raw {
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if k != "" && v != "" {
+			entries[k] = v
+		}
+	}
+	return Dict{Target: target, Entries: entries}, nil
+}
+
+func (d Dict) Name() string { return BackendDict + ":" + d.Target }
+
+func (d Dict) Translate(ctx context.Context, text string) (string, error) {
+	if v, ok := d.Entries[strings.TrimSpace(text)]; ok {
+		return v, nil
+	}
+	return "", ErrNoTranslation
+}
+
+// Command 调用外部命令翻译：原文写入 stdin，环境变量 AVMC_TRANSLATE_TARGET 为目标语言，
+// stdout（去除首尾空白）为译文；输出为空视为没有译文。
+type Command struct {
+	Argv    []string
+	Target  string
+	Timeout time.Duration
+}
+
+func (c Command) Name() string { return BackendCommand + ":" + c.Target }
+
+func (c Command) Translate(ctx context.Context, text string) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, c.Argv[0], c.Argv[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Env = append(os.Environ(), "AVMC_TRANSLATE_TARGET="+c.Target)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w：%s", err, msg)
+		}
+		return "", err
+	}
+	s := strings.TrimSpace(string(out))
+	if s == "" {
+		return "", ErrNoTranslation
+	}
+	return s, nil
+}
+
+// HTTP 调用翻译接口：POST JSON {"text": 原文, "target": 目标语言}，期望 2xx + {"text": 译文}。
+// 接口可以是任意本地服务（例如把在线翻译包装成统一协议的小代理）。
+type HTTP struct {
+	URL    string
+	Target string
+	Client *http.Client
+}
+
+type httpRequest struct {
+	Text   string `json:"text"`
+	Target string `json:"target"`
+}
+
+type httpResponse struct {
+	Text string `json:"text"`
+}
+
+func (h HTTP) Name() string { return BackendHTTP + ":" + h.Target }
+
+func (h HTTP) Translate(ctx context.Context, text string) (string, error) {
+	body, err := json.Marshal(httpRequest{Text: text, Target: h.Target})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c := h.Client
+	if c == nil {
+		c = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var out httpResponse
+	if err := json.Unmarshal(b, &out); err != nil {
+		return "", fmt.Errorf("响应不是有效的 JSON：%w", err)
+	}
+	s := strings.TrimSpace(out.Text)
+	if s == "" {
+		return "", ErrNoTranslation
+	}
+	return s, nil
+}
@@ -0,0 +1,98 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDict(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "dict.json")
+	if err := os.WriteFile(p, []byte(`{" 原文 ":"译文","空":""}`), 0o644); err != nil {
+		t.Fatalf("写入字典失败：%v", err)
+	}
+	tr, err := New(Options{Backend: BackendDict, DictFile: p})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if tr.Name() != "dict:zh" {
+		t.Fatalf("Name 不符合预期：%q", tr.Name())
+	}
+	if got, err := tr.Translate(context.Background(), "原文"); err != nil || got != "译文" {
+		t.Fatalf("期望命中字典：%q %v", got, err)
+	}
+	for _, s := range []string{"空", "未收录"} {
+		if _, err := tr.Translate(context.Background(), s); !errors.Is(err, ErrNoTranslation) {
+			t.Fatalf("%q：期望 ErrNoTranslation，实际 %v", s, err)
+		}
+	}
+
+	if _, err := New(Options{Backend: BackendDict, DictFile: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Fatalf("期望字典不存在时报错")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh")
+	}
+	tr, err := New(Options{Backend: BackendCommand, Target: "en", Command: []string{"sh", "-c", `printf '%s|%s\n' "$(cat)" "$AVMC_TRANSLATE_TARGET"`}})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if got, err := tr.Translate(context.Background(), "タイトル"); err != nil || got != "タイトル|en" {
+		t.Fatalf("命令输出不符合预期：%q %v", got, err)
+	}
+
+	fail, _ := New(Options{Backend: BackendCommand, Command: []string{"sh", "-c", "echo boom >&2; exit 3"}})
+	if _, err := fail.Translate(context.Background(), "x"); err == nil || errors.Is(err, ErrNoTranslation) {
+		t.Fatalf("期望命令失败返回错误，实际 %v", err)
+	}
+	empty, _ := New(Options{Backend: BackendCommand, Command: []string{"true"}})
+	if _, err := empty.Translate(context.Background(), "x"); !errors.Is(err, ErrNoTranslation) {
+		t.Fatalf("期望空输出返回 ErrNoTranslation，实际 %v", err)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req httpRequest
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad", http.StatusBadRequest)
+			return
+		}
+		if req.Text == "boom" {
+			http.Error(w, "boom", http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(httpResponse{Text: req.Target + ":" + req.Text})
+	}))
+	defer srv.Close()
+
+	tr, err := New(Options{Backend: BackendHTTP, URL: srv.URL, Target: "en"})
+	if err != nil {
+		t.Fatalf("不期望错误：%v", err)
+	}
+	if got, err := tr.Translate(context.Background(), "タイトル"); err != nil || got != "en:タイトル" {
+		t.Fatalf("HTTP 译文不符合预期：%q %v", got, err)
+	}
+	if _, err := tr.Translate(context.Background(), "boom"); err == nil {
+		t.Fatalf("期望非 2xx 返回错误")
+	}
+
+	for _, bad := range []Options{
+		{Backend: BackendHTTP, URL: "ftp://x"},
+		{Backend: BackendCommand},
+		{Backend: "google"},
+	} {
+		if _, err := New(bad); err == nil {
+			t.Fatalf("%+v：期望错误", bad)
+		}
+	}
+}